@@ -0,0 +1,109 @@
+package main
+
+import (
+	"auth-service/internal/config"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// clientsUsage описание подкоманды clients
+const clientsUsage = `Использование: auth-service clients <команда>
+
+Команды:
+  create [--scopes S] NAME   зарегистрировать сервисного клиента и показать его секрет
+  list                      показать сервисных клиентов
+
+Клиент получает токены запросом POST /auth/token с grant_type=client_credentials.
+Области доступа перечисляются через запятую; клиент с областью admin может обращаться
+к административному API со своим access токеном.`
+
+// runClients выполняет подкоманду clients
+func runClients(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("clients create", flag.ContinueOnError)
+		scopes := flags.String("scopes", "", "")
+		if err := parseFlags(flags, args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 || strings.TrimSpace(flags.Arg(0)) == "" {
+			return errUsage
+		}
+		return createClient(cfg, strings.TrimSpace(flags.Arg(0)), splitScopes(*scopes))
+	case "list":
+		if len(args) != 1 {
+			return errUsage
+		}
+		return listClients(cfg)
+	default:
+		return errUsage
+	}
+}
+
+// createClient регистрирует сервисного клиента и выводит его учетные данные
+func createClient(cfg *config.Config, name string, scopes []string) error {
+	authService, repo, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	client, secret, err := authService.CreateClient(context.Background(), name, scopes, cliActor())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("ID клиента:     %s\n", client.ID)
+	fmt.Printf("Секрет клиента: %s\n", secret)
+	fmt.Fprintln(os.Stderr, "Секрет не хранится и показывается только один раз.")
+	return nil
+}
+
+// listClients выводит сервисных клиентов в порядке создания
+func listClients(cfg *config.Config) error {
+	_, repo, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	clients, err := repo.ListClients(context.Background())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tНАЗВАНИЕ\tОБЛАСТИ ДОСТУПА\tСОЗДАН\tСОСТОЯНИЕ")
+	for _, client := range clients {
+		state := "активен"
+		if client.Disabled {
+			state = "отключен"
+		}
+		scopes := strings.Join(client.Scopes, ",")
+		if scopes == "" {
+			scopes = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", client.ID, client.Name, scopes, client.CreatedAt.Format(time.RFC3339), state)
+	}
+	return w.Flush()
+}
+
+// splitScopes разбирает список областей доступа, разделенных запятыми
+func splitScopes(value string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(value, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/repository"
+	"auth-service/internal/revocation"
+	"auth-service/internal/service"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// command подкоманда, выполняемая вместо запуска сервера
+type command struct {
+	name string
+	// summary краткое описание для списка команд
+	summary string
+	usage   string
+	// failure сообщение в логе при ошибке выполнения
+	failure string
+	run     func(cfg *config.Config, args []string) error
+}
+
+// commands подкоманды в порядке их вывода в списке команд
+var commands = []command{
+	{name: "migrate", summary: "управление миграциями схемы", usage: migrateUsage, failure: "Ошибка миграции схемы", run: runMigrate},
+	{name: "gc", summary: "удалить истекшие и заблокированные сессии", usage: gcUsage, failure: "Ошибка очистки сессий", run: runGC},
+	{name: "sessions", summary: "просмотр и блокировка сессий", usage: sessionsUsage, failure: "Ошибка управления сессиями", run: runSessions},
+	{name: "clients", summary: "управление сервисными клиентами", usage: clientsUsage, failure: "Ошибка управления клиентами", run: runClients},
+	{name: "keys", summary: "создание и ротация ключей подписи", usage: keysUsage, failure: "Ошибка управления ключами", run: runKeys},
+	{name: "token", summary: "разбор и проверка access токена", usage: tokenUsage, failure: "Ошибка проверки токена", run: runToken},
+	{name: "config", summary: "проверка и вывод конфигурации", usage: configUsage, failure: "Ошибка работы с конфигурацией", run: runConfig},
+}
+
+// runCommand выполняет подкоманду и возвращает код завершения процесса
+func runCommand(cfg *config.Config, args []string) int {
+	switch args[0] {
+	case "help", "-h", "--help":
+		fmt.Println(commandList())
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+
+		err := cmd.run(cfg, args[1:])
+		if errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, cmd.usage)
+			return 2
+		}
+		if err != nil {
+			slog.Error(cmd.failure, slog.Any("error", err))
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "Неизвестная команда: %s\n\n%s\n", args[0], commandList())
+	return 2
+}
+
+// commandList возвращает список подкоманд с кратким описанием
+func commandList() string {
+	var b strings.Builder
+	b.WriteString("Использование: auth-service [команда]\n\nБез команды запускается сервер.\n\nКоманды:")
+	for _, cmd := range commands {
+		fmt.Fprintf(&b, "\n  %-10s %s", cmd.name, cmd.summary)
+	}
+	return b.String()
+}
+
+// openService открывает хранилище и создает поверх него сервис авторизации.
+// Блокировки сессий, выполненные через сервис, распространяются на запущенные
+// экземпляры сервиса через уведомления хранилища.
+func openService(cfg *config.Config) (*service.AuthService, *revocation.Repository, error) {
+	store, err := repository.Open(cfg.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revocations := revocation.NewRepository(store, cfg.Revocation, cfg.JWT)
+	return service.NewAuthService(revocations, config.NewStore(cfg)), revocations, nil
+}
+
+// parseFlags разбирает флаги подкоманды; ошибка разбора означает некорректные аргументы
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// cliActor инициатор действий, выполненных из командной строки, для журнала аудита
+func cliActor() string {
+	if current, err := user.Current(); err == nil {
+		return "cli:" + current.Username
+	}
+	return "cli"
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+const testAccessSecret = "cli-access-secret-0123456789abcdef"
+
+// newCLIConfig возвращает конфигурацию с хранилищем SQLite в каталоге теста.
+// Рабочий каталог меняется на каталог теста, чтобы не прочитать чужой .env файл.
+func newCLIConfig(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_DSN", filepath.Join(dir, "auth.db"))
+	t.Setenv("JWT_ACCESS_SECRET", testAccessSecret)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	return cfg
+}
+
+// runCLI выполняет подкоманду args и возвращает код завершения и вывод в stdout
+func runCLI(t *testing.T, cfg *config.Config, args ...string) (int, string) {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		var b bytes.Buffer
+		_, _ = io.Copy(&b, reader)
+		output <- b.String()
+	}()
+
+	code := runCommand(cfg, args)
+	_ = writer.Close()
+	return code, <-output
+}
+
+// pendingMigrations возвращает число непримененных миграций в выводе migrate status
+func pendingMigrations(t *testing.T, status string) int {
+	t.Helper()
+	lines := strings.Split(strings.TrimSpace(status), "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "ВЕРСИЯ") {
+		t.Fatalf("вывод migrate status:\n%s", status)
+	}
+	pending := 0
+	for _, line := range lines[1:] {
+		if strings.HasSuffix(line, "нет") {
+			pending++
+		}
+	}
+	return pending
+}
+
+func TestMigrateCommand(t *testing.T) {
+	cfg := newCLIConfig(t)
+
+	code, status := runCLI(t, cfg, "migrate", "status")
+	if code != 0 {
+		t.Fatalf("migrate status: код %d", code)
+	}
+	all := pendingMigrations(t, status)
+
+	steps := []struct {
+		args    []string
+		code    int
+		pending int
+	}{
+		{args: []string{"up"}, pending: 0},
+		{args: []string{"down"}, pending: 1},
+		{args: []string{"down", "2"}, pending: 3},
+		{args: []string{"goto", "0"}, pending: all},
+		{args: []string{"goto", strconv.Itoa(all)}, pending: 0},
+		{args: []string{"goto", strconv.Itoa(all + 1)}, code: 1, pending: 0},
+		{args: []string{"down", "0"}, code: 2, pending: 0},
+		{args: []string{"goto"}, code: 2, pending: 0},
+		{args: []string{"sideways"}, code: 2, pending: 0},
+		{args: nil, code: 2, pending: 0},
+	}
+	for _, step := range steps {
+		name := strings.Join(append([]string{"migrate"}, step.args...), " ")
+		if code, _ := runCLI(t, cfg, append([]string{"migrate"}, step.args...)...); code != step.code {
+			t.Fatalf("%s: код %d, ожидался %d", name, code, step.code)
+		}
+		if _, status := runCLI(t, cfg, "migrate", "status"); pendingMigrations(t, status) != step.pending {
+			t.Fatalf("после %s не применено миграций %d, ожидалось %d:\n%s", name, pendingMigrations(t, status), step.pending, status)
+		}
+	}
+
+	t.Run("хранилище без миграций", func(t *testing.T) {
+		cfg := newCLIConfig(t)
+		cfg.Database.Driver = "memory"
+		if code, _ := runCLI(t, cfg, "migrate", "status"); code != 1 {
+			t.Fatalf("migrate status с DB_DRIVER=memory: код %d, ожидался 1", code)
+		}
+	})
+}
+
+func TestSessionsRevokeCommand(t *testing.T) {
+	cfg := newCLIConfig(t)
+	if code, _ := runCLI(t, cfg, "migrate", "up"); code != 0 {
+		t.Fatalf("migrate up: код %d", code)
+	}
+
+	// Две сессии пользователя user и одна сессия пользователя other
+	user, other := uuid.New(), uuid.New()
+	authService, repo, err := openService(cfg)
+	if err != nil {
+		t.Fatalf("openService: %v", err)
+	}
+	ctx := context.Background()
+	for _, userID := range []uuid.UUID{user, user, other} {
+		if _, err := authService.Login(ctx, userID, "agent", "192.0.2.1", models.TokenBinding{}); err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+	}
+	sessions := func(userID uuid.UUID) []*models.Session {
+		t.Helper()
+		list, err := repo.ListSessions(ctx, models.SessionFilter{UserID: userID})
+		if err != nil {
+			t.Fatalf("ListSessions: %v", err)
+		}
+		return list
+	}
+	userSessions, otherSessions := sessions(user), sessions(other)
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// blocked возвращает число заблокированных сессий пользователя
+	blocked := func(userID uuid.UUID) int {
+		t.Helper()
+		_, repo, err := openService(cfg)
+		if err != nil {
+			t.Fatalf("openService: %v", err)
+		}
+		defer repo.Close()
+		list, err := repo.ListSessions(ctx, models.SessionFilter{UserID: userID})
+		if err != nil {
+			t.Fatalf("ListSessions: %v", err)
+		}
+		count := 0
+		for _, session := range list {
+			if session.IsBlocked {
+				count++
+			}
+		}
+		return count
+	}
+
+	steps := []struct {
+		name string
+		args []string
+		code int
+		// userBlocked и otherBlocked число заблокированных сессий после команды
+		userBlocked, otherBlocked int
+	}{
+		{
+			name: "сессия другого пользователя",
+			args: []string{user.String(), strconv.Itoa(otherSessions[0].ID)},
+			code: 1,
+		},
+		{
+			name:        "одна сессия",
+			args:        []string{user.String(), strconv.Itoa(userSessions[0].ID)},
+			userBlocked: 1,
+		},
+		{
+			name:        "некорректный ID пользователя",
+			args:        []string{"user"},
+			code:        2,
+			userBlocked: 1,
+		},
+		{
+			name:        "некорректный ID сессии",
+			args:        []string{user.String(), "0"},
+			code:        2,
+			userBlocked: 1,
+		},
+		{
+			name:        "все сессии пользователя",
+			args:        []string{"--reason", "compromised", user.String()},
+			userBlocked: 2,
+		},
+	}
+	for _, step := range steps {
+		code, output := runCLI(t, cfg, append([]string{"sessions", "revoke"}, step.args...)...)
+		if code != step.code {
+			t.Fatalf("%s: код %d, ожидался %d: %s", step.name, code, step.code, output)
+		}
+		if got := blocked(user); got != step.userBlocked {
+			t.Fatalf("%s: заблокировано сессий пользователя %d, ожидалось %d", step.name, got, step.userBlocked)
+		}
+		if got := blocked(other); got != step.otherBlocked {
+			t.Fatalf("%s: заблокировано сессий другого пользователя %d, ожидалось %d", step.name, got, step.otherBlocked)
+		}
+	}
+}
+
+func TestConfigCommand(t *testing.T) {
+	cfg := newCLIConfig(t)
+
+	t.Run("dump", func(t *testing.T) {
+		code, output := runCLI(t, cfg, "config", "dump")
+		if code != 0 {
+			t.Fatalf("config dump: код %d", code)
+		}
+		for _, line := range []string{"DB_DRIVER=sqlite # env", "JWT_ACCESS_SECRET=*** # env", "SERVER_PORT=8080 # default"} {
+			if !strings.Contains(output, line+"\n") {
+				t.Fatalf("в выводе config dump нет строки %q:\n%s", line, output)
+			}
+		}
+		if strings.Contains(output, testAccessSecret) {
+			t.Fatalf("config dump вывел значение секрета:\n%s", output)
+		}
+	})
+
+	t.Run("check", func(t *testing.T) {
+		code, output := runCLI(t, cfg, "config", "check")
+		if code != 1 || !strings.Contains(output, "не применены миграции схемы") {
+			t.Fatalf("config check до миграций: код %d, ожидался 1:\n%s", code, output)
+		}
+
+		if code, _ := runCLI(t, cfg, "migrate", "up"); code != 0 {
+			t.Fatalf("migrate up: код %d", code)
+		}
+		code, output = runCLI(t, cfg, "config", "check")
+		if code != 0 {
+			t.Fatalf("config check: код %d:\n%s", code, output)
+		}
+		for _, check := range []string{"database", "schema", "signing_key", "trusted_proxies", "webhook", "event_sinks"} {
+			if !strings.Contains(output, check) {
+				t.Fatalf("в выводе config check нет проверки %s:\n%s", check, output)
+			}
+		}
+	})
+
+	t.Run("некорректные аргументы", func(t *testing.T) {
+		for _, args := range [][]string{{"config"}, {"config", "show"}, {"config", "dump", "extra"}} {
+			if code, _ := runCLI(t, cfg, args...); code != 2 {
+				t.Fatalf("%s: код %d, ожидался 2", strings.Join(args, " "), code)
+			}
+		}
+	})
+}
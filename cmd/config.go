@@ -0,0 +1,134 @@
+package main
+
+import (
+	"auth-service/internal/cache"
+	"auth-service/internal/clientip"
+	"auth-service/internal/config"
+	"auth-service/internal/health"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"auth-service/internal/webhook"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// configUsage описание подкоманды config
+const configUsage = `Использование: auth-service config <команда>
+
+Команды:
+  check   проверить подключение к хранилищу и его схему, ключ подписи access токенов,
+          доверенные прокси, сертификаты TLS, подпись webhook, sink-и шины событий
+          и подключение к кешу
+  dump    показать действующие значения параметров и их источники; секреты скрыты
+
+Конфигурация читается из файла CONFIG_FILE (YAML или TOML), .env файла и переменных
+окружения; переменные окружения имеют приоритет над файлом.`
+
+// runConfig выполняет подкоманду config
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "check":
+		return checkConfig(cfg)
+	case "dump":
+		return dumpConfig(cfg)
+	default:
+		return errUsage
+	}
+}
+
+// dumpConfig выводит действующую конфигурацию в формате .env с источником каждого значения
+func dumpConfig(cfg *config.Config) error {
+	w := bufio.NewWriter(os.Stdout)
+	for _, setting := range cfg.Settings() {
+		value := setting.Value
+		if strings.ContainsAny(value, " \t#\"'\\") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(w, "%s=%s # %s\n", setting.Key, value, setting.Source)
+	}
+	return w.Flush()
+}
+
+// checkConfig проверяет доступность зависимостей сервиса с текущей конфигурацией
+func checkConfig(cfg *config.Config) error {
+	store, err := repository.Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	authService := service.NewAuthService(store, config.NewStore(cfg))
+	checker := health.NewChecker(cfg.Health.Timeout)
+	checker.Add("database", store.Ping)
+	checker.Add("schema", store.CheckSchema)
+	checker.Add("signing_key", authService.CheckSigningKey)
+	checker.Add("trusted_proxies", func(context.Context) error {
+		_, err := clientip.NewResolver(cfg.Server.TrustedProxies, cfg.Server.TrustedProxyHeader)
+		return err
+	})
+	if cfg.Server.TLSCertFile != "" {
+		checker.Add("tls", func(context.Context) error {
+			_, err := newTLSReloader(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile, cfg.Server.ClientCAFile, cfg.Server.RequireClientCert)
+			return err
+		})
+	}
+	if cfg.Admin.TLSCertFile != "" {
+		checker.Add("admin_tls", func(context.Context) error {
+			_, err := newTLSReloader(cfg.Admin.TLSCertFile, cfg.Admin.TLSKeyFile, cfg.Admin.ClientCAFile, false)
+			return err
+		})
+	}
+	checker.Add("webhook", func(context.Context) error {
+		_, err := webhook.NewDispatcher(store, cfg.Webhook)
+		return err
+	})
+	checker.Add("event_sinks", func(context.Context) error {
+		_, closeSinks, err := buildEventSinks(cfg.Events, store)
+		if err == nil {
+			closeSinks()
+		}
+		return err
+	})
+	if cfg.Cache.RedisURL != "" {
+		checker.Add("cache", func(ctx context.Context) error {
+			client, err := cache.NewClient(cfg.Cache)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			return client.Ping(ctx).Err()
+		})
+	}
+
+	results, ok := checker.Run(context.Background())
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ПРОВЕРКА\tРЕЗУЛЬТАТ")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\n", name, results[name])
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !ok {
+		return errors.New("конфигурация содержит ошибки")
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/janitor"
+	"auth-service/internal/repository"
+	"context"
+	"fmt"
+)
+
+// gcUsage описание подкоманды gc
+const gcUsage = `Использование: auth-service gc
+
+Удаляет сессии, срок действия которых истек или которые заблокированы
+раньше чем SESSION_GC_RETENTION назад, вместе с историей их refresh токенов.`
+
+// runGC выполняет подкоманду gc
+func runGC(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	store, err := repository.Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	deleted, err := janitor.NewJanitor(store, cfg.SessionGC).RunOnce(context.Background())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Удалено сессий: %d\n", deleted)
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"auth-service/internal/config"
+	"auth-service/pkg/jwt"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// keysUsage описание подкоманды keys
+const keysUsage = `Использование: auth-service keys <команда>
+
+Команды:
+  generate   создать ключ подписи access токенов
+  rotate     создать новый ключ и вывести переменные окружения для ротации
+  list       показать идентификаторы (kid) действующих ключей
+  notify     уведомить запущенные экземпляры о ротации: они перечитывают конфигурацию
+
+После ротации access токены, подписанные прежним ключом, принимаются, пока ключ
+остается в JWT_ACCESS_PREVIOUS_SECRETS. Его можно удалить через JWT_ACCESS_EXPIRY
+после применения новых значений на всех экземплярах сервиса.
+
+notify отправляет уведомление через хранилище PostgreSQL (REVOCATION_NOTIFY_CHANNEL)
+после того, как новые значения записаны в файл конфигурации или файлы секретов,
+общие для экземпляров.`
+
+// runKeys выполняет подкоманду keys
+func runKeys(cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "generate":
+		secret, err := jwt.GenerateSecret()
+		if err != nil {
+			return err
+		}
+		fmt.Println(secret)
+		return nil
+	case "rotate":
+		return rotateKeys(cfg)
+	case "list":
+		return listKeys(cfg)
+	case "notify":
+		return notifyKeys(cfg)
+	default:
+		return errUsage
+	}
+}
+
+// rotateKeys выводит значения переменных окружения, в которых новый ключ становится текущим,
+// а текущий ключ добавляется к прежним
+func rotateKeys(cfg *config.Config) error {
+	if cfg.JWT.AccessSecret == "" {
+		return errors.New("не задан текущий ключ подписи JWT_ACCESS_SECRET")
+	}
+	// Прежние ключи перечисляются через запятую
+	if strings.Contains(cfg.JWT.AccessSecret, ",") {
+		return errors.New("текущий ключ подписи содержит запятую и не может быть добавлен в JWT_ACCESS_PREVIOUS_SECRETS")
+	}
+
+	secret, err := jwt.GenerateSecret()
+	if err != nil {
+		return err
+	}
+
+	previous := append([]string{cfg.JWT.AccessSecret}, cfg.JWT.PreviousAccessSecrets...)
+	fmt.Printf("JWT_ACCESS_SECRET=%s\n", secret)
+	fmt.Printf("JWT_ACCESS_PREVIOUS_SECRETS=%s\n", strings.Join(previous, ","))
+	fmt.Fprintf(os.Stderr, "Новый ключ: %s. Прежние ключи можно удалить через %s после применения новых значений.\n",
+		jwt.KeyID(secret), cfg.JWT.AccessExpiry)
+	return nil
+}
+
+// listKeys выводит идентификаторы текущего и прежних ключей подписи
+func listKeys(cfg *config.Config) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tКЛЮЧ")
+	fmt.Fprintf(w, "%s\tтекущий\n", jwt.KeyID(cfg.JWT.AccessSecret))
+	for _, secret := range cfg.JWT.PreviousAccessSecrets {
+		fmt.Fprintf(w, "%s\tпрежний\n", jwt.KeyID(secret))
+	}
+	return w.Flush()
+}
+
+// notifyKeys уведомляет запущенные экземпляры сервиса о ротации ключей подписи
+func notifyKeys(cfg *config.Config) error {
+	_, repo, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	if err := repo.NotifyKeysRotated(context.Background()); err != nil {
+		return err
+	}
+	fmt.Printf("Экземпляры сервиса уведомлены о ротации ключей; текущий ключ: %s\n", jwt.KeyID(cfg.JWT.AccessSecret))
+	return nil
+}
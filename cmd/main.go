@@ -2,17 +2,35 @@ package main
 
 import (
 	"auth-service/internal/api"
+	"auth-service/internal/cache"
+	"auth-service/internal/certs"
+	"auth-service/internal/clientip"
 	"auth-service/internal/config"
+	"auth-service/internal/dpop"
+	"auth-service/internal/events"
+	"auth-service/internal/health"
+	"auth-service/internal/janitor"
+	"auth-service/internal/logging"
 	"auth-service/internal/middleware"
+	"auth-service/internal/models"
 	"auth-service/internal/repository"
+	"auth-service/internal/revocation"
 	"auth-service/internal/service"
+	"auth-service/internal/tracing"
+	"auth-service/internal/webhook"
 	"context"
-	"log"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
+
+	"github.com/gin-gonic/gin"
 )
 
 // @title Auth Service API
@@ -26,38 +44,401 @@ import (
 // @in header
 // @name Authorization
 func main() {
+	if err := run(os.Args[1:]); err != nil {
+		var code exitCode
+		if errors.As(err, &code) {
+			os.Exit(int(code))
+		}
+		slog.Error("Сервис остановлен с ошибкой", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+// exitCode код завершения подкоманды, которая уже сообщила о результате
+type exitCode int
+
+func (c exitCode) Error() string {
+	return fmt.Sprintf("код завершения %d", int(c))
+}
+
+// run выполняет подкоманду args или запускает сервер и ожидает сигнала остановки.
+// Процесс завершается только в main, поэтому отложенные вызовы освобождения ресурсов
+// выполняются и при ошибке.
+func run(args []string) error {
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
+		return fmt.Errorf("ошибка загрузки конфигурации: %w", err)
+	}
+
+	logger, err := logging.New(cfg.Log, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("ошибка настройки логирования: %w", err)
+	}
+	// Записи стандартного пакета log также попадают в slog
+	slog.SetDefault(logger)
+
+	// Подкоманды выполняются вместо запуска сервера
+	if len(args) > 0 {
+		if code := runCommand(cfg, args); code != 0 {
+			return exitCode(code)
+		}
+		return nil
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("ошибка настройки трассировки: %w", err)
 	}
+	// Отправляем накопленные span-ы
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Ошибка остановки трассировки", slog.Any("error", err))
+		}
+	}()
 
-	repo, err := repository.NewPostgresRepository(cfg.Database.GetConnectionString())
+	store, err := repository.Open(cfg.Database)
 	if err != nil {
-		log.Fatalf("Ошибка создания репозитория: %v", err)
+		return fmt.Errorf("ошибка создания репозитория: %w", err)
+	}
+	defer store.Close()
+
+	if migrator, ok := store.(repository.Migrator); ok && cfg.Database.AutoMigrate {
+		if err := migrator.Migrate(context.Background()); err != nil {
+			return fmt.Errorf("ошибка миграции схемы: %w", err)
+		}
+	}
+
+	// Хранилище в памяти не использует пул соединений
+	if registerer, ok := store.(metricsRegisterer); ok {
+		if err := registerer.RegisterMetrics(); err != nil {
+			return fmt.Errorf("ошибка регистрации метрик: %w", err)
+		}
+	}
+
+	// Заблокированные сессии загружаются до начала обработки запросов
+	revocations := revocation.NewRepository(store, cfg.Revocation, cfg.JWT)
+	if err := revocations.Resync(context.Background()); err != nil {
+		slog.Warn("Ошибка загрузки заблокированных сессий", slog.Any("error", err))
 	}
-	defer repo.Close()
 
-	authService := service.NewAuthService(repo, cfg)
+	// Кеш сессий и отозванных токенов подключается перед хранилищем, если задан его адрес
+	repo := repository.Repository(revocations)
+	var cached *cache.Repository
+	// Идентификаторы DPoP доказательств хранятся в кеше, если он задан, чтобы повторное
+	// предъявление доказательства обнаруживалось на всех экземплярах
+	var replay dpop.ReplayCache = dpop.NewMemoryReplayCache()
+	if cfg.Cache.RedisURL != "" {
+		client, err := cache.NewClient(cfg.Cache)
+		if err != nil {
+			return fmt.Errorf("ошибка настройки кеша: %w", err)
+		}
+		defer client.Close()
+		// Недоступность кеша не мешает запуску: запросы обслуживаются хранилищем
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			slog.Warn("Кеш недоступен", slog.Any("error", err))
+		}
+		cached = cache.NewRepository(repo, client, cfg.Cache, cfg.JWT)
+		repo = cached
+		replay = dpop.NewRedisReplayCache(client, cfg.Cache.KeyPrefix)
+	}
+
+	// Конфигурация может быть перезагружена без перезапуска (SIGHUP или изменение файлов)
+	cfgStore := config.NewStore(cfg)
+	authService := service.NewAuthService(repo, cfgStore)
 	authMiddleware := middleware.NewAuthMiddleware(authService)
 	authHandler := api.NewAuthHandler(authService)
 
-	server := api.NewServer(cfg.Server.Port, authHandler, authMiddleware)
+	resolver, err := clientip.NewResolver(cfg.Server.TrustedProxies, cfg.Server.TrustedProxyHeader)
+	if err != nil {
+		return fmt.Errorf("ошибка настройки доверенных прокси: %w", err)
+	}
+
+	// Сертификаты TLS перечитываются после изменения файлов без перезапуска
+	var certificates []*certs.Reloader
+	serverTLS, err := newTLSReloader(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile, cfg.Server.ClientCAFile, cfg.Server.RequireClientCert)
+	if err != nil {
+		return fmt.Errorf("ошибка настройки TLS: %w", err)
+	}
+	var serverTLSConfig *tls.Config
+	if serverTLS != nil {
+		certificates = append(certificates, serverTLS)
+		serverTLSConfig = serverTLS.TLSConfig()
+	}
+
+	// Без DPOP_ENABLED доказательства не проверяются, и токены не привязываются к ключам DPoP
+	var dpopProof gin.HandlerFunc
+	if cfg.DPoP.Enabled {
+		dpopProof = middleware.DPoP(dpop.NewVerifier(cfg.DPoP.ProofLifetime, replay, cfg.DPoP.ReplayFailOpen), cfg.DPoP.BaseURL)
+	}
+	server := api.NewServer(cfg.Server, logger, resolver, authHandler, authMiddleware, dpopProof, serverTLSConfig)
+
+	// Административный API обслуживается на отдельном порту, если он задан, или на основном
+	// порту только при ADMIN_ON_MAIN_PORT; без ADMIN_TOKEN доступ к нему есть только
+	// у сервисных клиентов с областью admin
+	adminHandler := api.NewAdminHandler(repo, authService)
+	var adminServer *api.Server
+	if cfg.Admin.Port != "" {
+		adminTLS, err := newTLSReloader(cfg.Admin.TLSCertFile, cfg.Admin.TLSKeyFile, cfg.Admin.ClientCAFile, false)
+		if err != nil {
+			return fmt.Errorf("ошибка настройки административного API: %w", err)
+		}
+		var adminTLSConfig *tls.Config
+		if adminTLS != nil {
+			certificates = append(certificates, adminTLS)
+			adminTLSConfig = adminTLS.TLSConfig()
+		}
+		// Клиентские сертификаты административного порта подписаны удостоверяющими центрами администраторов
+		adminAuth := middleware.AdminAuth(cfgStore, authService, true)
+		adminServer = api.NewAdminServer(cfg.Admin, logger, resolver, adminHandler, adminAuth, adminTLSConfig)
+	} else if cfg.Admin.OnMainPort {
+		server.RegisterAdmin(adminHandler, middleware.AdminAuth(cfgStore, authService, false))
+		slog.Warn("Административный API подключен к основному порту")
+	} else {
+		slog.Info("Административный API отключен: не задан ADMIN_PORT или ADMIN_ON_MAIN_PORT")
+	}
+	if cfg.Server.MetricsOnMainPort {
+		slog.Warn("Метрики опубликованы на основном порту")
+	} else if cfg.Admin.Port == "" {
+		slog.Info("Метрики не опубликованы: не задан ADMIN_PORT или METRICS_ON_MAIN_PORT")
+	}
+
+	// Проверки готовности принимать запросы
+	checker := health.NewChecker(cfg.Health.Timeout)
+	checker.Add("database", repo.Ping)
+	checker.Add("schema", repo.CheckSchema)
+	checker.Add("signing_key", authService.CheckSigningKey)
+	if cfg.Health.WebhookBacklogMax > 0 {
+		checker.Add("webhook_backlog", webhook.BacklogCheck(repo, cfg.Health))
+	}
+	server.RegisterHealth(checker)
+
+	// Запускаем доставку webhook из outbox
+	if err := webhook.EnsureDefaultSubscription(context.Background(), repo, cfg.Webhook); err != nil {
+		return fmt.Errorf("ошибка настройки webhook: %w", err)
+	}
+	dispatcher, err := webhook.NewDispatcher(repo, cfg.Webhook)
+	if err != nil {
+		return fmt.Errorf("ошибка настройки webhook: %w", err)
+	}
+
+	// Запускаем публикацию событий из журнала в sink-и
+	sinks, closeSinks, err := buildEventSinks(cfg.Events, store)
+	if err != nil {
+		return fmt.Errorf("ошибка настройки шины событий: %w", err)
+	}
+	defer closeSinks()
+	relay := events.NewRelay(repo, sinks, cfg.Events.PollInterval)
+
+	// Новая конфигурация применяется к компонентам, которые считали ее при запуске
+	cfgStore.OnReload(func(cfg *config.Config) {
+		revocations.SetJWTConfig(cfg.JWT)
+		if cached != nil {
+			cached.SetJWTConfig(cfg.JWT)
+		}
+		if err := dispatcher.SetConfig(cfg.Webhook); err != nil {
+			slog.Error("Ошибка применения конфигурации webhook", slog.Any("error", err))
+		}
+		if err := webhook.EnsureDefaultSubscription(context.Background(), repo, cfg.Webhook); err != nil {
+			slog.Error("Ошибка настройки webhook", slog.Any("error", err))
+		}
+		if err := logging.SetLevel(cfg.Log.Level); err != nil {
+			slog.Error("Ошибка настройки логирования", slog.Any("error", err))
+		}
+	})
+	reloadConfig := func(trigger string) {
+		changes, err := authService.ReloadConfig(context.Background(), trigger)
+		logConfigReload(trigger, changes, err)
+	}
+	revocations.OnKeysRotated(func() { reloadConfig(config.ReloadKeysRotated) })
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	// Очистка сессий выполняется на исходном хранилище, которое предоставляет блокировки
+	if cfg.SessionGC.Interval > 0 {
+		sessionJanitor := janitor.NewJanitor(store, cfg.SessionGC)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			sessionJanitor.Run(workersCtx)
+		}()
+	}
+	if cfg.Reload.WatchInterval > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			cfgStore.Watch(workersCtx, cfg.Reload.WatchInterval, func() { reloadConfig(config.ReloadFile) })
+		}()
+		for _, reloader := range certificates {
+			workers.Add(1)
+			go func(reloader *certs.Reloader) {
+				defer workers.Done()
+				reloader.Watch(workersCtx, cfg.Reload.WatchInterval)
+			}(reloader)
+		}
+	}
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	workers.Add(4)
+	go func() {
+		defer workers.Done()
+		for {
+			select {
+			case <-workersCtx.Done():
+				return
+			case <-hup:
+				reloadConfig(config.ReloadSignal)
+				for _, reloader := range certificates {
+					reloader.Reload()
+				}
+			}
+		}
+	}()
+	go func() {
+		defer workers.Done()
+		revocations.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		dispatcher.Run(workersCtx)
+	}()
+	go func() {
+		defer workers.Done()
+		relay.Run(workersCtx)
+	}()
 
+	// Ошибка запуска любого из серверов останавливает сервис
+	serveErr := make(chan error, 2)
 	go func() {
 		if err := server.Run(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Ошибка запуска сервера: %v", err)
+			serveErr <- fmt.Errorf("ошибка запуска сервера: %w", err)
 		}
 	}()
 
-	log.Printf("Сервер запущен на порту %s", cfg.Server.Port)
+	slog.Info("Сервер запущен", slog.String("port", cfg.Server.Port))
+
+	if adminServer != nil {
+		go func() {
+			if err := adminServer.Run(); err != nil && err != http.ErrServerClosed {
+				serveErr <- fmt.Errorf("ошибка запуска административного API: %w", err)
+			}
+		}()
+		slog.Info("Административный API запущен", slog.String("port", cfg.Admin.Port))
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	var runErr error
+	select {
+	case <-quit:
+	case runErr = <-serveErr:
+	}
+
+	// Сначала /readyz начинает отвечать 503, и балансировщик перестает направлять новые запросы;
+	// повторный сигнал завершает ожидание досрочно. Если сервер не запустился, ждать незачем.
+	checker.SetShuttingDown()
+	if runErr == nil {
+		slog.Info("Остановка сервера", slog.Duration("delay", cfg.Server.ShutdownDelay))
+		select {
+		case <-time.After(cfg.Server.ShutdownDelay):
+		case <-quit:
+		}
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Ошибка остановки сервера: %v", err)
+		slog.Error("Запросы не завершились за время остановки сервера", slog.Any("error", err))
 	}
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			slog.Error("Запросы не завершились за время остановки административного API", slog.Any("error", err))
+		}
+	}
+
+	// Дожидаемся завершения начатых доставок webhook и публикации событий
+	stopWorkers()
+	workers.Wait()
+
+	return runErr
+}
+
+// metricsRegisterer хранилище, предоставляющее метрики пула соединений и активных сессий
+type metricsRegisterer interface {
+	RegisterMetrics() error
+}
+
+// newTLSReloader загружает сертификаты TLS порта; nil, если TLS не настроен
+func newTLSReloader(certFile, keyFile, caFile string, requireClientCert bool) (*certs.Reloader, error) {
+	if certFile == "" {
+		return nil, nil
+	}
+	return certs.NewReloader(certFile, keyFile, caFile, requireClientCert)
+}
+
+// logConfigReload записывает в лог результат перезагрузки конфигурации
+func logConfigReload(trigger string, changes []models.ConfigChange, err error) {
+	if err != nil {
+		slog.Error("Конфигурация не перезагружена", slog.String("trigger", trigger), slog.Any("error", err))
+		return
+	}
+	if len(changes) == 0 {
+		slog.Info("Конфигурация не изменилась", slog.String("trigger", trigger))
+		return
+	}
+
+	for _, change := range changes {
+		attrs := []any{
+			slog.String("trigger", trigger),
+			slog.String("key", change.Key),
+			slog.String("old", change.Old),
+			slog.String("new", change.New),
+		}
+		if change.Restart {
+			slog.Warn("Параметр конфигурации изменен, новое значение вступит в силу после перезапуска", attrs...)
+			continue
+		}
+		slog.Info("Параметр конфигурации изменен", attrs...)
+	}
+}
+
+// buildEventSinks создает sink-и шины событий, перечисленные в конфигурации
+func buildEventSinks(cfg config.EventsConfig, repo repository.Repository) ([]events.Sink, func(), error) {
+	var sinks []events.Sink
+	var closers []func() error
+	closeAll := func() {
+		for _, closeFn := range closers {
+			_ = closeFn()
+		}
+	}
+
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "webhook":
+			sinks = append(sinks, webhook.NewSink(repo))
+		case "stdout":
+			sinks = append(sinks, events.NewStdoutSink())
+		case "file":
+			sink, err := events.NewFileSink(cfg.FilePath)
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			sinks = append(sinks, sink)
+			closers = append(closers, sink.Close)
+		case "pgnotify":
+			notifier, ok := repo.(events.Notifier)
+			if !ok {
+				closeAll()
+				return nil, nil, fmt.Errorf("sink pgnotify доступен только с хранилищем postgres")
+			}
+			sinks = append(sinks, events.NewNotifySink(notifier, cfg.NotifyChannel))
+		default:
+			closeAll()
+			return nil, nil, fmt.Errorf("неизвестный sink событий: %s", name)
+		}
+	}
+
+	return sinks, closeAll, nil
 }
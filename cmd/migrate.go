@@ -0,0 +1,117 @@
+package main
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// errUsage возвращается, если подкоманда вызвана с некорректными аргументами
+var errUsage = errors.New("некорректные аргументы команды")
+
+// migrateUsage описание подкоманды migrate
+const migrateUsage = `Использование: auth-service migrate <команда>
+
+Команды:
+  up          применить все миграции
+  down [N]    откатить N последних миграций (по умолчанию 1)
+  goto V      привести схему к версии V (0 откатывает все миграции)
+  status      показать состояние миграций
+
+С PostgreSQL одновременно запущенные миграции ожидают друг друга на advisory-блокировке.
+SQLite блокировку миграций не поддерживает: не запускайте migrate, пока работает сервис
+с DB_AUTO_MIGRATE=true или другой экземпляр migrate.`
+
+// runMigrate выполняет подкоманду migrate
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "up", "down", "goto", "status":
+	default:
+		return errUsage
+	}
+
+	store, err := repository.Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	repo, ok := store.(repository.Migrator)
+	if !ok {
+		return fmt.Errorf("хранилище %s не использует миграции схемы", cfg.Database.Driver)
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		return repo.Migrate(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return errUsage
+			}
+		}
+		return migrateDown(ctx, repo, steps)
+	case "goto":
+		if len(args) < 2 {
+			return errUsage
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return errUsage
+		}
+		return repo.MigrateTo(ctx, version)
+	default:
+		return printMigrationStatus(ctx, repo)
+	}
+}
+
+// migrateDown откатывает steps последних примененных миграций
+func migrateDown(ctx context.Context, repo repository.Migrator, steps int) error {
+	statuses, err := repo.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	var applied []int
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			applied = append(applied, status.Version)
+		}
+	}
+
+	target := 0
+	if steps < len(applied) {
+		target = applied[len(applied)-steps-1]
+	}
+	return repo.MigrateTo(ctx, target)
+}
+
+// printMigrationStatus выводит состояние миграций
+func printMigrationStatus(ctx context.Context, repo repository.Migrator) error {
+	statuses, err := repo.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ВЕРСИЯ\tНАЗВАНИЕ\tПРИМЕНЕНА")
+	for _, status := range statuses {
+		appliedAt := "нет"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/service"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sessionsUsage описание подкоманды sessions
+const sessionsUsage = `Использование: auth-service sessions <команда>
+
+Команды:
+  list [--active] USER_ID                    показать сессии пользователя
+  revoke [--reason R] USER_ID [SESSION_ID]   заблокировать все сессии пользователя или одну его сессию
+  revoke-before [--reason R] TIME            заблокировать все сессии, созданные раньше TIME (RFC 3339)
+
+Блокировка отзывает access токены сессий на всех экземплярах сервиса.`
+
+// runSessions выполняет подкоманду sessions
+func runSessions(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet("sessions "+args[0], flag.ContinueOnError)
+	var active bool
+	var reason string
+	switch args[0] {
+	case "list":
+		flags.BoolVar(&active, "active", false, "")
+	case "revoke", "revoke-before":
+		flags.StringVar(&reason, "reason", service.DefaultRevokeReason, "")
+	default:
+		return errUsage
+	}
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		if flags.NArg() != 1 {
+			return errUsage
+		}
+		userID, err := uuid.Parse(flags.Arg(0))
+		if err != nil {
+			return errUsage
+		}
+		return listSessions(cfg, userID, active)
+	case "revoke":
+		if flags.NArg() < 1 || flags.NArg() > 2 {
+			return errUsage
+		}
+		userID, err := uuid.Parse(flags.Arg(0))
+		if err != nil {
+			return errUsage
+		}
+		sessionID := 0
+		if flags.NArg() == 2 {
+			if sessionID, err = strconv.Atoi(flags.Arg(1)); err != nil || sessionID < 1 {
+				return errUsage
+			}
+		}
+		return revokeSessions(cfg, userID, sessionID, reason)
+	default:
+		if flags.NArg() != 1 {
+			return errUsage
+		}
+		before, err := time.Parse(time.RFC3339, flags.Arg(0))
+		if err != nil {
+			return errUsage
+		}
+		return revokeSessionsBefore(cfg, before, reason)
+	}
+}
+
+// listSessions выводит сессии пользователя, начиная с самых новых
+func listSessions(cfg *config.Config, userID uuid.UUID, active bool) error {
+	_, repo, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	sessions, err := repo.ListSessions(context.Background(), models.SessionFilter{UserID: userID, ActiveOnly: active})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tСОЗДАНА\tИСТЕКАЕТ\tСОСТОЯНИЕ\tIP\tUSER-AGENT")
+	for _, session := range sessions {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			session.ID,
+			session.CreatedAt.Format(time.RFC3339),
+			time.Unix(session.ExpiresAt, 0).Format(time.RFC3339),
+			sessionState(session),
+			session.ClientIP,
+			session.UserAgent,
+		)
+	}
+	return w.Flush()
+}
+
+// revokeSessions блокирует сессию sessionID пользователя или все его сессии, если sessionID равен нулю
+func revokeSessions(cfg *config.Config, userID uuid.UUID, sessionID int, reason string) error {
+	authService, repo, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if sessionID == 0 {
+		if err := authService.RevokeUserSessions(ctx, userID, cliActor(), reason); err != nil {
+			return err
+		}
+		fmt.Printf("Сессии пользователя %s заблокированы\n", userID)
+		return nil
+	}
+
+	session, err := repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return fmt.Errorf("сессия %d не принадлежит пользователю %s", sessionID, userID)
+	}
+	if err := authService.RevokeSession(ctx, sessionID, cliActor(), reason); err != nil {
+		return err
+	}
+	fmt.Printf("Сессия %d заблокирована\n", sessionID)
+	return nil
+}
+
+// revokeSessionsBefore блокирует все сессии, созданные раньше before
+func revokeSessionsBefore(cfg *config.Config, before time.Time, reason string) error {
+	authService, repo, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	blocked, err := authService.RevokeSessionsCreatedBefore(context.Background(), before, cliActor(), reason)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Заблокировано сессий: %d\n", blocked)
+	return nil
+}
+
+// sessionState описание состояния сессии для вывода
+func sessionState(session *models.Session) string {
+	switch {
+	case session.IsBlocked:
+		return "заблокирована"
+	case session.ExpiresAt <= time.Now().Unix():
+		return "истекла"
+	default:
+		return "активна"
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/pkg/jwt"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// tokenUsage описание подкоманды token
+const tokenUsage = `Использование: auth-service token <команда> TOKEN
+
+Команды:
+  decode   показать заголовок и claims токена без проверки подписи
+  verify   проверить подпись, срок действия и отзыв access токена`
+
+// runToken выполняет подкоманду token
+func runToken(cfg *config.Config, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "decode":
+		_, err := decodeToken(args[1])
+		return err
+	case "verify":
+		return verifyToken(cfg, args[1])
+	default:
+		return errUsage
+	}
+}
+
+// decodeToken выводит заголовок и claims токена и возвращает claims
+func decodeToken(token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("токен должен состоять из трех частей, разделенных точкой")
+	}
+
+	var payload []byte
+	for i, title := range []string{"Заголовок", "Claims"} {
+		data, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("ошибка декодирования части %q: %w", title, err)
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, data, "", "  "); err != nil {
+			return nil, fmt.Errorf("часть %q не является JSON: %w", title, err)
+		}
+		fmt.Printf("%s:\n%s\n", title, out.String())
+		payload = data
+	}
+	return payload, nil
+}
+
+// verifyToken проверяет access токен так же, как сервис при обработке запроса,
+// и выводит состояние его сессии
+func verifyToken(cfg *config.Config, token string) error {
+	payload, err := decodeToken(token)
+	if err != nil {
+		return err
+	}
+
+	authService, repo, err := openService(cfg)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+
+	// Отозванные токены определяются по сессиям, заблокированным за время жизни access токена
+	ctx := context.Background()
+	if err := repo.Resync(ctx); err != nil {
+		return err
+	}
+	// Владение ключом клиента из командной строки не подтвердить, поэтому привязка
+	// берется из самого токена; его подпись проверяется ниже
+	var claims jwt.TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return err
+	}
+	var binding models.TokenBinding
+	if claims.Confirmation != nil {
+		binding.CertThumbprint = claims.Confirmation.CertThumbprint
+		binding.JWKThumbprint = claims.Confirmation.JWKThumbprint
+	}
+	if _, err := authService.Validate(ctx, token, binding); err != nil {
+		return err
+	}
+
+	fmt.Println("Токен действителен")
+	if claims.ClientID != "" {
+		fmt.Printf("Токен выпущен сервисному клиенту %s\n", claims.ClientID)
+	}
+	if binding.CertThumbprint != "" {
+		fmt.Printf("Токен привязан к клиентскому сертификату x5t#S256=%s\n", binding.CertThumbprint)
+	}
+	if binding.JWKThumbprint != "" {
+		fmt.Printf("Токен привязан к ключу DPoP jkt=%s\n", binding.JWKThumbprint)
+	}
+
+	if claims.SessionID == 0 {
+		return nil
+	}
+	session, err := repo.GetSession(ctx, claims.SessionID)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		fmt.Printf("Сессия %d не найдена\n", claims.SessionID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Сессия %d: %s\n", session.ID, sessionState(session))
+	return nil
+}
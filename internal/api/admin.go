@@ -0,0 +1,234 @@
+package api
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// AdminHandler обработчик административного API
+type AdminHandler struct {
+	repo  repository.Repository
+	admin service.Admin
+}
+
+// NewAdminHandler создает новый экземпляр AdminHandler
+func NewAdminHandler(repo repository.Repository, admin service.Admin) *AdminHandler {
+	return &AdminHandler{
+		repo:  repo,
+		admin: admin,
+	}
+}
+
+// @Summary Список доставок webhook
+// @Description Возвращает сообщения outbox webhook и статус их доставки
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Статус доставки (pending, delivered, dead)"
+// @Param subscription_id query int false "ID подписки"
+// @Param limit query int false "Количество записей (по умолчанию 50, максимум 500)"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.WebhookMessage "Сообщения webhook"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/deliveries [get]
+func (h *AdminHandler) ListWebhookDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookStatusPending, models.WebhookStatusDelivered, models.WebhookStatusDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":        "error",
+			"error_code":    "INVALID_REQUEST",
+			"error_message": "некорректный статус доставки",
+		})
+		return
+	}
+
+	var subscriptionID int64
+	if value := c.Query("subscription_id"); value != "" {
+		var err error
+		if subscriptionID, err = strconv.ParseInt(value, 10, 64); err != nil || subscriptionID <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":        "error",
+				"error_code":    "INVALID_REQUEST",
+				"error_message": "некорректный параметр subscription_id",
+			})
+			return
+		}
+	}
+
+	limit, offset, ok := parsePage(c)
+	if !ok {
+		return
+	}
+
+	messages, err := h.repo.ListWebhookMessages(c.Request.Context(), models.WebhookMessageFilter{
+		SubscriptionID: subscriptionID,
+		Status:         status,
+		Limit:          limit,
+		Offset:         offset,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":        "error",
+			"error_code":    "INTERNAL_ERROR",
+			"error_message": "ошибка получения доставок webhook",
+		})
+		return
+	}
+
+	if messages == nil {
+		messages = []*models.WebhookMessage{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   messages,
+	})
+}
+
+// @Summary Статус доставки webhook
+// @Description Возвращает сообщение outbox webhook по ID
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID сообщения"
+// @Success 200 {object} models.WebhookMessage "Сообщение webhook"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Сообщение не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/deliveries/{id} [get]
+func (h *AdminHandler) GetWebhookDelivery(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	message, err := h.repo.GetWebhookMessage(c.Request.Context(), id)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   message,
+	})
+}
+
+// @Summary Повторная доставка webhook
+// @Description Возвращает недоставленное сообщение в очередь для немедленной отправки
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID сообщения"
+// @Success 200 {object} models.Response "Сообщение поставлено в очередь"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Сообщение не найдено"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/deliveries/{id}/retry [post]
+func (h *AdminHandler) RetryWebhookDelivery(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.RetryWebhookMessage(c.Request.Context(), id); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "сообщение поставлено в очередь",
+	})
+}
+
+// respondWebhookError возвращает ошибку работы с outbox клиенту
+func respondWebhookError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrWebhookMessageNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":        "error",
+			"error_code":    "NOT_FOUND",
+			"error_message": "сообщение webhook не найдено",
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"status":        "error",
+		"error_code":    "INTERNAL_ERROR",
+		"error_message": "ошибка работы с outbox webhook",
+	})
+}
+
+// parseID разбирает числовой параметр пути id
+func parseID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":        "error",
+			"error_code":    "INVALID_REQUEST",
+			"error_message": "некорректный идентификатор",
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+// parseUUID разбирает параметр пути id в формате GUID
+func parseUUID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":        "error",
+			"error_code":    "INVALID_REQUEST",
+			"error_message": "некорректный идентификатор",
+		})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// parsePage разбирает параметры постраничной выборки limit и offset
+func parsePage(c *gin.Context) (limit, offset int, ok bool) {
+	limit = defaultPageLimit
+	var err error
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 || limit > maxPageLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":        "error",
+				"error_code":    "INVALID_REQUEST",
+				"error_message": "некорректный параметр limit",
+			})
+			return 0, 0, false
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		if offset, err = strconv.Atoi(value); err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":        "error",
+				"error_code":    "INVALID_REQUEST",
+				"error_message": "некорректный параметр offset",
+			})
+			return 0, 0, false
+		}
+	}
+	return limit, offset, true
+}
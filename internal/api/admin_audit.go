@@ -0,0 +1,131 @@
+package api
+
+import (
+	"auth-service/internal/models"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Поиск по журналу аудита
+// @Description Возвращает записи аудита, начиная с самых новых
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query string false "ID пользователя (инициатор или субъект действия)"
+// @Param event_type query string false "Тип события"
+// @Param from query string false "Начало интервала (RFC 3339, включительно)"
+// @Param to query string false "Конец интервала (RFC 3339, не включительно)"
+// @Param limit query int false "Количество записей (по умолчанию 50, максимум 500)"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.AuditEvent "Записи аудита"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/audit/events [get]
+func (h *AdminHandler) ListAuditEvents(c *gin.Context) {
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+
+	auditEvents, err := h.repo.ListAuditEvents(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":        "error",
+			"error_code":    "INTERNAL_ERROR",
+			"error_message": "ошибка поиска по журналу аудита",
+		})
+		return
+	}
+
+	if auditEvents == nil {
+		auditEvents = []*models.AuditEvent{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   auditEvents,
+	})
+}
+
+// @Summary Выгрузка журнала аудита
+// @Description Выгружает все записи аудита, удовлетворяющие фильтру, в формате JSON Lines в порядке их добавления
+// @Tags admin
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param user_id query string false "ID пользователя (инициатор или субъект действия)"
+// @Param event_type query string false "Тип события"
+// @Param from query string false "Начало интервала (RFC 3339, включительно)"
+// @Param to query string false "Конец интервала (RFC 3339, не включительно)"
+// @Success 200 {string} string "Записи аудита, по одной в строке"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Router /admin/audit/events/export [get]
+func (h *AdminHandler) ExportAuditEvents(c *gin.Context) {
+	filter, ok := parseAuditFilter(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit-events.jsonl"`)
+	c.Status(http.StatusOK)
+
+	// Записи передаются по мере чтения, поэтому ошибку после начала ответа можно только залогировать
+	encoder := json.NewEncoder(c.Writer)
+	err := h.repo.ExportAuditEvents(c.Request.Context(), filter, func(event *models.AuditEvent) error {
+		return encoder.Encode(event)
+	})
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Ошибка выгрузки журнала аудита", slog.Any("error", err))
+	}
+}
+
+// parseAuditFilter читает фильтр журнала аудита из параметров запроса
+func parseAuditFilter(c *gin.Context) (models.AuditEventFilter, bool) {
+	filter := models.AuditEventFilter{
+		UserID:    c.Query("user_id"),
+		EventType: c.Query("event_type"),
+	}
+
+	if filter.EventType != "" && (filter.EventType == models.EventAll || !isKnownEventType(filter.EventType)) {
+		respondInvalidAuditFilter(c, "неизвестный тип события: "+filter.EventType)
+		return filter, false
+	}
+
+	var err error
+	if value := c.Query("from"); value != "" {
+		if filter.From, err = time.Parse(time.RFC3339, value); err != nil {
+			respondInvalidAuditFilter(c, "некорректный параметр from")
+			return filter, false
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if filter.To, err = time.Parse(time.RFC3339, value); err != nil {
+			respondInvalidAuditFilter(c, "некорректный параметр to")
+			return filter, false
+		}
+	}
+
+	return filter, true
+}
+
+// respondInvalidAuditFilter возвращает ошибку в параметрах поиска по журналу аудита
+func respondInvalidAuditFilter(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"status":        "error",
+		"error_code":    "INVALID_REQUEST",
+		"error_message": message,
+	})
+}
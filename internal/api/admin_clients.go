@@ -0,0 +1,243 @@
+package api
+
+import (
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientRequest тело запроса на создание или изменение сервисного клиента.
+// При изменении клиента отсутствующие поля не меняются; клиент создается включенным.
+type clientRequest struct {
+	Name     *string   `json:"name"`
+	Scopes   *[]string `json:"scopes"`
+	Disabled *bool     `json:"disabled"`
+}
+
+// clientSecretResponse клиент вместе с секретом, который показывается только один раз
+type clientSecretResponse struct {
+	*models.Client
+	Secret string `json:"secret"`
+}
+
+// @Summary Список сервисных клиентов
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Client "Клиенты"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/clients [get]
+func (h *AdminHandler) ListClients(c *gin.Context) {
+	clients, err := h.repo.ListClients(c.Request.Context())
+	if err != nil {
+		respondClientError(c, err)
+		return
+	}
+
+	if clients == nil {
+		clients = []*models.Client{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   clients,
+	})
+}
+
+// @Summary Регистрация сервисного клиента
+// @Description Создает клиента, получающего токены по client_credentials.
+// @Description Секрет возвращается только в ответе на этот запрос.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param client body clientRequest true "Клиент"
+// @Success 201 {object} clientSecretResponse "Созданный клиент"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/clients [post]
+func (h *AdminHandler) CreateClient(c *gin.Context) {
+	var request clientRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.Name == nil || strings.TrimSpace(*request.Name) == "" {
+		respondInvalidClient(c, "отсутствует параметр name")
+		return
+	}
+
+	var scopes []string
+	if request.Scopes != nil {
+		scopes = *request.Scopes
+	}
+
+	client, secret, err := h.admin.CreateClient(c.Request.Context(), strings.TrimSpace(*request.Name), scopes, middleware.AdminActor(c))
+	if err != nil {
+		respondClientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   clientSecretResponse{Client: client, Secret: secret},
+	})
+}
+
+// @Summary Сервисный клиент
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID клиента (GUID)"
+// @Success 200 {object} models.Client "Клиент"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Клиент не найден"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/clients/{id} [get]
+func (h *AdminHandler) GetClient(c *gin.Context) {
+	id, ok := parseUUID(c)
+	if !ok {
+		return
+	}
+
+	client, err := h.repo.GetClient(c.Request.Context(), id)
+	if err != nil {
+		respondClientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   client,
+	})
+}
+
+// @Summary Изменение сервисного клиента
+// @Description Изменяет название, области доступа или состояние клиента.
+// @Description При отключении клиента его сессии блокируются.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID клиента (GUID)"
+// @Param client body clientRequest true "Изменяемые поля"
+// @Success 200 {object} models.Client "Клиент"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Клиент не найден"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/clients/{id} [patch]
+func (h *AdminHandler) UpdateClient(c *gin.Context) {
+	id, ok := parseUUID(c)
+	if !ok {
+		return
+	}
+
+	var request clientRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondInvalidClient(c, "некорректное тело запроса")
+		return
+	}
+
+	client, err := h.repo.GetClient(c.Request.Context(), id)
+	if err != nil {
+		respondClientError(c, err)
+		return
+	}
+
+	if request.Name != nil {
+		if strings.TrimSpace(*request.Name) == "" {
+			respondInvalidClient(c, "пустое название клиента")
+			return
+		}
+		client.Name = strings.TrimSpace(*request.Name)
+	}
+	if request.Scopes != nil {
+		client.Scopes = *request.Scopes
+	}
+	if request.Disabled != nil {
+		client.Disabled = *request.Disabled
+	}
+
+	if err := h.admin.UpdateClient(c.Request.Context(), client, middleware.AdminActor(c)); err != nil {
+		respondClientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   client,
+	})
+}
+
+// @Summary Замена секрета сервисного клиента
+// @Description Создает новый секрет клиента; прежний секрет сразу перестает действовать.
+// @Description Секрет возвращается только в ответе на этот запрос.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID клиента (GUID)"
+// @Success 200 {object} clientSecretResponse "Клиент с новым секретом"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Клиент не найден"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/clients/{id}/rotate-secret [post]
+func (h *AdminHandler) RotateClientSecret(c *gin.Context) {
+	id, ok := parseUUID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	secret, err := h.admin.RotateClientSecret(ctx, id, middleware.AdminActor(c))
+	if err != nil {
+		respondClientError(c, err)
+		return
+	}
+
+	client, err := h.repo.GetClient(ctx, id)
+	if err != nil {
+		respondClientError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   clientSecretResponse{Client: client, Secret: secret},
+	})
+}
+
+// respondInvalidClient возвращает ошибку проверки параметров клиента
+func respondInvalidClient(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"status":        "error",
+		"error_code":    "INVALID_REQUEST",
+		"error_message": message,
+	})
+}
+
+// respondClientError возвращает ошибку работы с реестром клиентов
+func respondClientError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrClientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":        "error",
+			"error_code":    "NOT_FOUND",
+			"error_message": "клиент не найден",
+		})
+	case errors.Is(err, service.ErrUnknownScope):
+		respondInvalidClient(c, err.Error())
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":        "error",
+			"error_code":    "INTERNAL_ERROR",
+			"error_message": "ошибка работы с реестром клиентов",
+		})
+	}
+}
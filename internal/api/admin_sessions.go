@@ -0,0 +1,354 @@
+package api
+
+import (
+	"auth-service/internal/middleware"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Состояния сессии в ответах административного API
+const (
+	sessionStateActive  = "active"
+	sessionStateExpired = "expired"
+	sessionStateBlocked = "blocked"
+)
+
+// adminSession сессия в ответах административного API
+type adminSession struct {
+	ID        int       `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIP  string    `json:"client_ip"`
+	// State состояние сессии: active, expired или blocked
+	State string `json:"state"`
+	// Kind вид сессии: user или client
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Binding ключ клиента, к которому привязаны токены сессии, в формате claim cnf
+	Binding *models.TokenBinding `json:"cnf,omitempty"`
+}
+
+// adminUser сведения о пользователе, собранные по его сессиям
+type adminUser struct {
+	UserID         uuid.UUID  `json:"user_id"`
+	ActiveSessions int        `json:"active_sessions"`
+	TotalSessions  int        `json:"total_sessions"`
+	LastLoginAt    *time.Time `json:"last_login_at,omitempty"`
+	// Client сервисный клиент, если токены выпускаются на его ID
+	Client *models.Client `json:"client,omitempty"`
+}
+
+// revokeRequest тело запроса на блокировку сессий
+type revokeRequest struct {
+	// Reason причина блокировки для журнала аудита
+	Reason string `json:"reason"`
+}
+
+// @Summary Сведения о пользователе
+// @Description Возвращает количество сессий пользователя и время последнего входа.
+// @Description Для сервисного клиента также возвращаются его параметры.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID пользователя (GUID)"
+// @Success 200 {object} adminUser "Сведения о пользователе"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id} [get]
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, ok := parseUUID(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	sessions, err := h.repo.ListSessions(ctx, models.SessionFilter{UserID: userID})
+	if err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	user := adminUser{
+		UserID:        userID,
+		TotalSessions: len(sessions),
+	}
+	now := time.Now()
+	for _, session := range sessions {
+		if sessionState(session, now) == sessionStateActive {
+			user.ActiveSessions++
+		}
+	}
+	// Сессии упорядочены от новых к старым
+	if len(sessions) > 0 {
+		lastLoginAt := sessions[0].CreatedAt
+		user.LastLoginAt = &lastLoginAt
+	}
+
+	client, err := h.repo.GetClient(ctx, userID)
+	if err != nil && !errors.Is(err, repository.ErrClientNotFound) {
+		respondClientError(c, err)
+		return
+	}
+	user.Client = client
+
+	// Пользователи не хранятся отдельно: пользователь без сессий сервису неизвестен
+	if len(sessions) == 0 && client == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":        "error",
+			"error_code":    "NOT_FOUND",
+			"error_message": "пользователь не найден",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   user,
+	})
+}
+
+// @Summary Принудительный выход пользователя
+// @Description Блокирует все сессии пользователя и отзывает выданные ему access токены
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID пользователя (GUID)"
+// @Param request body revokeRequest false "Причина блокировки"
+// @Success 200 {object} models.Response "Сессии заблокированы"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{id}/logout [post]
+func (h *AdminHandler) LogoutUser(c *gin.Context) {
+	userID, ok := parseUUID(c)
+	if !ok {
+		return
+	}
+
+	reason, ok := parseRevokeReason(c)
+	if !ok {
+		return
+	}
+
+	if err := h.admin.RevokeUserSessions(c.Request.Context(), userID, middleware.AdminActor(c), reason); err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "сессии пользователя заблокированы",
+	})
+}
+
+// @Summary Поиск сессий
+// @Description Возвращает сессии, начиная с самых новых
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query string false "ID пользователя (GUID)"
+// @Param active query bool false "Только незаблокированные сессии с действующим refresh токеном"
+// @Param limit query int false "Количество записей (по умолчанию 50, максимум 500)"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} adminSession "Сессии"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/sessions [get]
+func (h *AdminHandler) ListSessions(c *gin.Context) {
+	var filter models.SessionFilter
+	if value := c.Query("user_id"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":        "error",
+				"error_code":    "INVALID_REQUEST",
+				"error_message": "некорректный параметр user_id",
+			})
+			return
+		}
+		filter.UserID = userID
+	}
+	if value := c.Query("active"); value != "" {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":        "error",
+				"error_code":    "INVALID_REQUEST",
+				"error_message": "некорректный параметр active",
+			})
+			return
+		}
+		filter.ActiveOnly = active
+	}
+
+	limit, offset, ok := parsePage(c)
+	if !ok {
+		return
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+
+	sessions, err := h.repo.ListSessions(c.Request.Context(), filter)
+	if err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	now := time.Now()
+	data := make([]adminSession, 0, len(sessions))
+	for _, session := range sessions {
+		data = append(data, newAdminSession(session, now))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   data,
+	})
+}
+
+// @Summary Сессия
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID сессии"
+// @Success 200 {object} adminSession "Сессия"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Сессия не найдена"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/sessions/{id} [get]
+func (h *AdminHandler) GetSession(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	session, err := h.repo.GetSession(c.Request.Context(), int(id))
+	if err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   newAdminSession(session, time.Now()),
+	})
+}
+
+// @Summary Блокировка сессии
+// @Description Блокирует сессию и отзывает выданные в ней access токены
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID сессии"
+// @Param request body revokeRequest false "Причина блокировки"
+// @Success 200 {object} models.Response "Сессия заблокирована"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Сессия не найдена"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/sessions/{id}/revoke [post]
+func (h *AdminHandler) RevokeSession(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	reason, ok := parseRevokeReason(c)
+	if !ok {
+		return
+	}
+
+	if err := h.admin.RevokeSession(c.Request.Context(), int(id), middleware.AdminActor(c), reason); err != nil {
+		respondSessionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "сессия заблокирована",
+	})
+}
+
+// parseRevokeReason разбирает необязательное тело запроса на блокировку сессий
+func parseRevokeReason(c *gin.Context) (string, bool) {
+	var request revokeRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status":        "error",
+				"error_code":    "INVALID_REQUEST",
+				"error_message": "некорректное тело запроса",
+			})
+			return "", false
+		}
+	}
+
+	if request.Reason == "" {
+		return service.DefaultRevokeReason, true
+	}
+	return request.Reason, true
+}
+
+// newAdminSession преобразует сессию для ответа административного API
+func newAdminSession(session *models.Session, now time.Time) adminSession {
+	view := adminSession{
+		ID:        session.ID,
+		UserID:    session.UserID,
+		UserAgent: session.UserAgent,
+		ClientIP:  session.ClientIP,
+		State:     sessionState(session, now),
+		Kind:      session.Kind,
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: time.Unix(session.ExpiresAt, 0).UTC(),
+	}
+	if !session.Binding.IsZero() {
+		binding := session.Binding
+		view.Binding = &binding
+	}
+	return view
+}
+
+// sessionState определяет состояние сессии в момент now
+func sessionState(session *models.Session, now time.Time) string {
+	switch {
+	case session.IsBlocked:
+		return sessionStateBlocked
+	case session.ExpiresAt <= now.Unix():
+		return sessionStateExpired
+	default:
+		return sessionStateActive
+	}
+}
+
+// respondSessionError возвращает ошибку работы с сессиями клиенту
+func respondSessionError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":        "error",
+			"error_code":    "NOT_FOUND",
+			"error_message": "сессия не найдена",
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"status":        "error",
+		"error_code":    "INTERNAL_ERROR",
+		"error_message": "ошибка работы с сессиями",
+	})
+}
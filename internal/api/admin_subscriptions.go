@@ -0,0 +1,348 @@
+package api
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/pkg/webhooksig"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// subscriptionRequest тело запроса на создание или изменение подписки.
+// При изменении подписки отсутствующие поля не меняются.
+type subscriptionRequest struct {
+	URL         *string  `json:"url"`
+	Description *string  `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	Secret      *string  `json:"secret"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+// subscriptionSecretResponse подписка вместе с секретом, который показывается только один раз
+type subscriptionSecretResponse struct {
+	*models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// @Summary Список подписок на webhook
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.WebhookSubscription "Подписки"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/subscriptions [get]
+func (h *AdminHandler) ListWebhookSubscriptions(c *gin.Context) {
+	subscriptions, err := h.repo.ListWebhookSubscriptions(c.Request.Context())
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	if subscriptions == nil {
+		subscriptions = []*models.WebhookSubscription{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscriptions,
+	})
+}
+
+// @Summary Создание подписки на webhook
+// @Description Создает подписку на указанные типы событий. Если секрет не передан, он генерируется.
+// @Description Секрет возвращается только в ответе на этот запрос.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param subscription body subscriptionRequest true "Подписка"
+// @Success 201 {object} subscriptionSecretResponse "Созданная подписка"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/subscriptions [post]
+func (h *AdminHandler) CreateWebhookSubscription(c *gin.Context) {
+	var request subscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.URL == nil {
+		respondInvalidSubscription(c, "отсутствует параметр url")
+		return
+	}
+
+	subscription := &models.WebhookSubscription{
+		URL:        *request.URL,
+		EventTypes: request.EventTypes,
+		Enabled:    true,
+	}
+	if request.Description != nil {
+		subscription.Description = *request.Description
+	}
+	if request.Enabled != nil {
+		subscription.Enabled = *request.Enabled
+	}
+
+	var secret string
+	if request.Secret != nil && *request.Secret != "" {
+		secret = *request.Secret
+	} else {
+		var err error
+		if secret, err = webhooksig.GenerateSecret(); err != nil {
+			respondSubscriptionError(c, err)
+			return
+		}
+	}
+	subscription.Secrets = []string{secret}
+
+	if message := validateSubscription(subscription); message != "" {
+		respondInvalidSubscription(c, message)
+		return
+	}
+
+	if err := h.repo.CreateWebhookSubscription(c.Request.Context(), subscription); err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   subscriptionSecretResponse{WebhookSubscription: subscription, Secret: secret},
+	})
+}
+
+// @Summary Подписка на webhook
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подписки"
+// @Success 200 {object} models.WebhookSubscription "Подписка"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/subscriptions/{id} [get]
+func (h *AdminHandler) GetWebhookSubscription(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.repo.GetWebhookSubscription(c.Request.Context(), id)
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscription,
+	})
+}
+
+// @Summary Изменение подписки на webhook
+// @Description Изменяет адрес, описание, типы событий или включает/отключает подписку
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подписки"
+// @Param subscription body subscriptionRequest true "Изменяемые поля"
+// @Success 200 {object} models.WebhookSubscription "Подписка"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/subscriptions/{id} [patch]
+func (h *AdminHandler) UpdateWebhookSubscription(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var request subscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondInvalidSubscription(c, "некорректное тело запроса")
+		return
+	}
+	if request.Secret != nil {
+		respondInvalidSubscription(c, "секрет меняется через rotate-secret")
+		return
+	}
+
+	subscription, err := h.repo.GetWebhookSubscription(c.Request.Context(), id)
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	if request.URL != nil {
+		subscription.URL = *request.URL
+	}
+	if request.Description != nil {
+		subscription.Description = *request.Description
+	}
+	if request.EventTypes != nil {
+		subscription.EventTypes = request.EventTypes
+	}
+	if request.Enabled != nil {
+		subscription.Enabled = *request.Enabled
+	}
+
+	if message := validateSubscription(subscription); message != "" {
+		respondInvalidSubscription(c, message)
+		return
+	}
+
+	if err := h.repo.UpdateWebhookSubscription(c.Request.Context(), subscription); err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscription,
+	})
+}
+
+// @Summary Ротация секрета подписки
+// @Description Генерирует новый секрет. Предыдущий секрет остается активным до следующей ротации,
+// @Description поэтому сообщения подписываются обоими секретами. Новый секрет возвращается только в этом ответе.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подписки"
+// @Success 200 {object} subscriptionSecretResponse "Подписка с новым секретом"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/subscriptions/{id}/rotate-secret [post]
+func (h *AdminHandler) RotateWebhookSubscriptionSecret(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	subscription, err := h.repo.GetWebhookSubscription(c.Request.Context(), id)
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	secret, err := webhooksig.GenerateSecret()
+	if err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	// Оставляем активными новый и предыдущий секреты
+	secrets := []string{secret}
+	if len(subscription.Secrets) > 0 {
+		secrets = append(secrets, subscription.Secrets[0])
+	}
+	subscription.Secrets = secrets
+
+	if err := h.repo.UpdateWebhookSubscription(c.Request.Context(), subscription); err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscriptionSecretResponse{WebhookSubscription: subscription, Secret: secret},
+	})
+}
+
+// @Summary Удаление подписки на webhook
+// @Description Удаляет подписку и все ее сообщения в outbox
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "ID подписки"
+// @Success 200 {object} models.Response "Подписка удалена"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Не авторизован"
+// @Failure 404 {object} models.ErrorResponse "Подписка не найдена"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/webhooks/subscriptions/{id} [delete]
+func (h *AdminHandler) DeleteWebhookSubscription(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.DeleteWebhookSubscription(c.Request.Context(), id); err != nil {
+		respondSubscriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "подписка удалена",
+	})
+}
+
+// validateSubscription проверяет подписку и возвращает описание ошибки
+func validateSubscription(subscription *models.WebhookSubscription) string {
+	target, err := url.ParseRequestURI(subscription.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "некорректный url подписки"
+	}
+
+	if len(subscription.EventTypes) == 0 {
+		return "не указаны типы событий"
+	}
+	for _, eventType := range subscription.EventTypes {
+		if !isKnownEventType(eventType) {
+			return "неизвестный тип события: " + eventType
+		}
+	}
+
+	if _, err := webhooksig.NewSigner(subscription.Secrets); err != nil {
+		return "некорректный секрет подписки"
+	}
+
+	return ""
+}
+
+// isKnownEventType сообщает, поддерживается ли тип события
+func isKnownEventType(eventType string) bool {
+	if eventType == models.EventAll {
+		return true
+	}
+	for _, known := range models.EventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// respondInvalidSubscription возвращает ошибку валидации подписки
+func respondInvalidSubscription(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"status":        "error",
+		"error_code":    "INVALID_REQUEST",
+		"error_message": message,
+	})
+}
+
+// respondSubscriptionError возвращает ошибку работы с реестром подписок
+func respondSubscriptionError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status":        "error",
+			"error_code":    "NOT_FOUND",
+			"error_message": "подписка на webhook не найдена",
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"status":        "error",
+		"error_code":    "INTERNAL_ERROR",
+		"error_message": "ошибка работы с подписками на webhook",
+	})
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"auth-service/internal/middleware"
 	"auth-service/internal/service"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -28,6 +30,7 @@ func NewAuthHandler(service service.Service) *AuthHandler {
 // @Param user_id query string true "ID пользователя (GUID)"
 // @Success 200 {object} models.TokenPair "Пара токенов"
 // @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 403 {object} models.ErrorResponse "ID принадлежит сервисному клиенту"
 // @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -54,11 +57,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Получаем User-Agent и IP-адрес клиента
-	userAgent := c.GetHeader("User-Agent")
-	clientIP := c.ClientIP()
+	userAgent, clientIP := middleware.ClientInfo(c)
 
 	// Генерируем токены
-	tokens, err := h.service.Login(userID, userAgent, clientIP)
+	tokens, err := h.service.Login(c.Request.Context(), userID, userAgent, clientIP, middleware.ClientBinding(c))
+	if errors.Is(err, service.ErrClientLogin) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"status":        "error",
+			"error_code":    "CLIENT_LOGIN_FORBIDDEN",
+			"error_message": "сервисный клиент получает токены через /auth/token",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":        "error",
@@ -102,12 +112,20 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	}
 
 	// Получаем User-Agent и IP-адрес клиента
-	userAgent := c.GetHeader("User-Agent")
-	clientIP := c.ClientIP()
+	userAgent, clientIP := middleware.ClientInfo(c)
 
 	// Обновляем токены
-	tokens, err := h.service.Refresh(request.RefreshToken, userAgent, clientIP)
+	tokens, err := h.service.Refresh(c.Request.Context(), request.RefreshToken, userAgent, clientIP, middleware.ClientBinding(c))
 	if err != nil {
+		if errors.Is(err, service.ErrTokenBindingMismatch) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status":        "error",
+				"error_code":    "TOKEN_BINDING_MISMATCH",
+				"error_message": "токен привязан к другому ключу клиента",
+			})
+			return
+		}
+
 		// Если ошибка связана с изменением User-Agent
 		if err.Error() == "обновление токенов с другого устройства запрещено" {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -133,6 +151,87 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	})
 }
 
+// @Summary Получение токенов сервисного клиента
+// @Description Выдает пару токенов сервисному клиенту по client_credentials. Учетные данные передаются
+// @Description в теле запроса (JSON или форма) либо в заголовке Authorization: Basic.
+// @Description Если клиент предъявил сертификат при установке TLS соединения, токены привязываются к нему (RFC 8705).
+// @Tags auth
+// @Accept json
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "Тип гранта (client_credentials)"
+// @Param client_id formData string false "ID клиента"
+// @Param client_secret formData string false "Секрет клиента"
+// @Success 200 {object} models.TokenPair "Пара токенов"
+// @Failure 400 {object} models.ErrorResponse "Некорректный запрос"
+// @Failure 401 {object} models.ErrorResponse "Неверные учетные данные клиента"
+// @Failure 500 {object} models.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/token [post]
+func (h *AuthHandler) Token(c *gin.Context) {
+	var request struct {
+		GrantType    string `json:"grant_type" form:"grant_type"`
+		ClientID     string `json:"client_id" form:"client_id"`
+		ClientSecret string `json:"client_secret" form:"client_secret"`
+	}
+
+	if err := c.ShouldBind(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":        "error",
+			"error_code":    "INVALID_REQUEST",
+			"error_message": "некорректное тело запроса",
+		})
+		return
+	}
+
+	if request.GrantType != "client_credentials" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status":        "error",
+			"error_code":    "UNSUPPORTED_GRANT_TYPE",
+			"error_message": "поддерживается только grant_type=client_credentials",
+		})
+		return
+	}
+
+	// Учетные данные из заголовка Authorization имеют приоритет над телом запроса
+	if clientID, secret, ok := c.Request.BasicAuth(); ok {
+		request.ClientID, request.ClientSecret = clientID, secret
+	}
+
+	clientID, err := uuid.Parse(request.ClientID)
+	if err != nil || request.ClientSecret == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":        "error",
+			"error_code":    "INVALID_CLIENT",
+			"error_message": "неверные учетные данные клиента",
+		})
+		return
+	}
+
+	userAgent, clientIP := middleware.ClientInfo(c)
+	tokens, err := h.service.ClientCredentials(c.Request.Context(), clientID, request.ClientSecret, userAgent, clientIP, middleware.ClientBinding(c))
+	if errors.Is(err, service.ErrInvalidClient) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"status":        "error",
+			"error_code":    "INVALID_CLIENT",
+			"error_message": "неверные учетные данные клиента",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":        "error",
+			"error_code":    "INTERNAL_ERROR",
+			"error_message": "ошибка при генерации токенов",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   tokens,
+	})
+}
+
 // @Summary Получение ID текущего пользователя
 // @Description Получение ID пользователя, которому принадлежит текущий access токен
 // @Tags auth
@@ -186,7 +285,8 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	}
 
 	// Деавторизуем пользователя
-	err := h.service.Logout(accessToken.(string))
+	userAgent, clientIP := middleware.ClientInfo(c)
+	err := h.service.Logout(c.Request.Context(), accessToken.(string), userAgent, clientIP)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":        "error",
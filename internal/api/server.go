@@ -1,28 +1,45 @@
 package api
 
 import (
+	"auth-service/internal/clientip"
+	"auth-service/internal/config"
+	"auth-service/internal/health"
+	"auth-service/internal/metrics"
 	"auth-service/internal/middleware"
 	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pires/go-proxyproto"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // Server представляет HTTP сервер
 type Server struct {
-	httpServer *http.Server
-	router     *gin.Engine
+	httpServer    *http.Server
+	router        *gin.Engine
+	resolver      *clientip.Resolver
+	proxyProtocol bool
+	// cancelRequests отменяет контекст обрабатываемых запросов
+	cancelRequests context.CancelFunc
 }
 
-// NewServer создает новый экземпляр сервера
-func NewServer(port string, handler *AuthHandler, authMiddleware *middleware.AuthMiddleware) *Server {
-	// Создаем роутер
-	router := gin.Default()
+// NewServer создает новый экземпляр сервера. DPoP доказательства запросов проверяет dpopProof;
+// если он nil, заголовок DPoP не учитывается. Если tlsConfig не nil, порт принимает только TLS соединения.
+func NewServer(cfg config.ServerConfig, logger *slog.Logger, resolver *clientip.Resolver, handler *AuthHandler, authMiddleware *middleware.AuthMiddleware, dpopProof gin.HandlerFunc, tlsConfig *tls.Config) *Server {
+	router := newRouter(logger, resolver)
+	if dpopProof != nil {
+		router.Use(dpopProof)
+	}
 
-	// Настраиваем middleware
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	// Метрики Prometheus публикуются на основном порту только явно
+	if cfg.MetricsOnMainPort {
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
 
 	// Добавляем Swagger документацию
 	router.GET("/swagger/*any", gin.WrapH(http.StripPrefix("/swagger/", http.FileServer(http.Dir("./swagger")))))
@@ -32,6 +49,7 @@ func NewServer(port string, handler *AuthHandler, authMiddleware *middleware.Aut
 	{
 		authGroup.POST("/login", handler.Login)
 		authGroup.POST("/refresh", handler.Refresh)
+		authGroup.POST("/token", handler.Token)
 		authGroup.POST("/logout", authMiddleware.CheckAuth(), handler.Logout)
 	}
 
@@ -41,6 +59,48 @@ func NewServer(port string, handler *AuthHandler, authMiddleware *middleware.Aut
 		userGroup.GET("/me", authMiddleware.CheckAuth(), handler.GetCurrentUser)
 	}
 
+	return newServer(cfg.Port, router, resolver, cfg.ProxyProtocol, tlsConfig)
+}
+
+// NewAdminServer создает сервер административного API на отдельном порту, который
+// можно не публиковать во внешнем балансировщике. Если tlsConfig не nil, порт принимает
+// только TLS соединения; клиентский сертификат, проверенный по удостоверяющим центрам
+// администраторов, подтверждает доступ без токена.
+func NewAdminServer(cfg config.AdminConfig, logger *slog.Logger, resolver *clientip.Resolver, handler *AdminHandler, adminAuth gin.HandlerFunc, tlsConfig *tls.Config) *Server {
+	router := newRouter(logger, resolver)
+	registerAdminRoutes(router, handler, adminAuth)
+
+	// Метрики Prometheus не требуют учетных данных: порт доступен только из внутренней сети
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	return newServer(cfg.Port, router, resolver, false, tlsConfig)
+}
+
+// newRouter создает роутер с общими для всех портов middleware
+func newRouter(logger *slog.Logger, resolver *clientip.Resolver) *gin.Engine {
+	// Создаем роутер; запросы и паники логируются через slog
+	router := gin.New()
+
+	// Адрес клиента определяет только clientip.Resolver, поэтому gin не должен доверять заголовкам
+	_ = router.SetTrustedProxies(nil)
+
+	// Настраиваем middleware
+	router.Use(middleware.Recovery(logger))
+	router.Use(middleware.RequestID())
+	router.Use(otelgin.Middleware("auth-service"))
+	router.Use(middleware.AccessLog(logger))
+	router.Use(middleware.Metrics())
+	router.Use(middleware.ClientIdentity(resolver))
+
+	return router
+}
+
+// newServer создает HTTP сервер на порту port
+func newServer(port string, router *gin.Engine, resolver *clientip.Resolver, proxyProtocol bool, tlsConfig *tls.Config) *Server {
+	// Контекст запросов наследуется от baseCtx, чтобы при остановке
+	// можно было прервать запросы, не успевшие завершиться
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+
 	// Создаем HTTP сервер
 	httpServer := &http.Server{
 		Addr:           ":" + port,
@@ -48,22 +108,104 @@ func NewServer(port string, handler *AuthHandler, authMiddleware *middleware.Aut
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   10 * time.Second,
 		MaxHeaderBytes: 1 << 20, // 1 MB
+		TLSConfig:      tlsConfig,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
 
 	return &Server{
-		httpServer: httpServer,
-		router:     router,
+		httpServer:     httpServer,
+		router:         router,
+		resolver:       resolver,
+		proxyProtocol:  proxyProtocol,
+		cancelRequests: cancelRequests,
 	}
 }
 
+// RegisterAdmin подключает административный API к основному порту
+func (s *Server) RegisterAdmin(handler *AdminHandler, adminAuth gin.HandlerFunc) {
+	registerAdminRoutes(s.router, handler, adminAuth)
+}
+
+// registerAdminRoutes подключает маршруты административного API, защищенные adminAuth
+func registerAdminRoutes(router *gin.Engine, handler *AdminHandler, adminAuth gin.HandlerFunc) {
+	adminGroup := router.Group("/admin", adminAuth)
+	{
+		adminGroup.GET("/users/:id", handler.GetUser)
+		adminGroup.POST("/users/:id/logout", handler.LogoutUser)
+
+		adminGroup.GET("/sessions", handler.ListSessions)
+		adminGroup.GET("/sessions/:id", handler.GetSession)
+		adminGroup.POST("/sessions/:id/revoke", handler.RevokeSession)
+
+		adminGroup.GET("/clients", handler.ListClients)
+		adminGroup.POST("/clients", handler.CreateClient)
+		adminGroup.GET("/clients/:id", handler.GetClient)
+		adminGroup.PATCH("/clients/:id", handler.UpdateClient)
+		adminGroup.POST("/clients/:id/rotate-secret", handler.RotateClientSecret)
+
+		adminGroup.GET("/webhooks/deliveries", handler.ListWebhookDeliveries)
+		adminGroup.GET("/webhooks/deliveries/:id", handler.GetWebhookDelivery)
+		adminGroup.POST("/webhooks/deliveries/:id/retry", handler.RetryWebhookDelivery)
+
+		adminGroup.GET("/webhooks/subscriptions", handler.ListWebhookSubscriptions)
+		adminGroup.POST("/webhooks/subscriptions", handler.CreateWebhookSubscription)
+		adminGroup.GET("/webhooks/subscriptions/:id", handler.GetWebhookSubscription)
+		adminGroup.PATCH("/webhooks/subscriptions/:id", handler.UpdateWebhookSubscription)
+		adminGroup.DELETE("/webhooks/subscriptions/:id", handler.DeleteWebhookSubscription)
+		adminGroup.POST("/webhooks/subscriptions/:id/rotate-secret", handler.RotateWebhookSubscriptionSecret)
+
+		adminGroup.GET("/audit/events", handler.ListAuditEvents)
+		adminGroup.GET("/audit/events/export", handler.ExportAuditEvents)
+	}
+}
+
+// RegisterHealth подключает проверки живости (/healthz) и готовности (/readyz)
+func (s *Server) RegisterHealth(checker *health.Checker) {
+	s.router.GET("/healthz", checker.Liveness)
+	s.router.GET("/readyz", checker.Readiness)
+}
+
 // Run запускает HTTP сервер
 func (s *Server) Run() error {
-	return s.httpServer.ListenAndServe()
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	if s.proxyProtocol {
+		// Заголовок PROXY protocol принимается только от доверенных прокси,
+		// от остальных соединений он игнорируется
+		policy, err := proxyproto.LaxWhiteListPolicy(s.resolver.TrustedProxies())
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		listener = &proxyproto.Listener{
+			Listener: listener,
+			Policy:   policy,
+		}
+	}
+
+	// TLS устанавливается после разбора заголовка PROXY protocol
+	if s.httpServer.TLSConfig != nil {
+		listener = tls.NewListener(listener, s.httpServer.TLSConfig)
+	}
+
+	return s.httpServer.Serve(listener)
 }
 
-// Shutdown останавливает HTTP сервер
+// Shutdown останавливает HTTP сервер и ожидает завершения обрабатываемых запросов.
+// Если они не завершились до отмены ctx, их контекст отменяется, а соединения закрываются.
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	defer s.cancelRequests()
+
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		_ = s.httpServer.Close()
+	}
+	return err
 }
 
 // GetRouter возвращает роутер для тестирования
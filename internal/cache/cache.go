@@ -0,0 +1,376 @@
+package cache
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/metrics"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Ключи кеша (после префикса):
+//
+//	session:<хеш refresh токена>       сессия, найденная по refresh токену
+//	user:<ID пользователя>:sessions    сессии пользователя в виде <ID сессии>:<хеш refresh токена>
+//	revoked:session:<ID сессии>        отзыв access токенов сессии
+//	revoked:token:<jti>                отзыв access токена
+//	revoked:user:<ID пользователя>     время блокировки всех сессий пользователя в наносекундах
+
+// Repository кеш сессий и отозванных токенов в хранилище с протоколом Redis перед repository.Repository.
+// Источником истины остается основное хранилище: кеш ускоряет поиск сессий по refresh токену
+// и хранит отзывы access токенов, которые основное хранилище не учитывает.
+// Ошибки кеша при чтении не прерывают запросы, которые тогда обслуживаются основным хранилищем.
+type Repository struct {
+	repository.Repository
+	client redis.Cmdable
+	prefix string
+	// sessionTTL максимальное время хранения сессии в кеше
+	sessionTTL time.Duration
+	// revocationTTL время хранения отзывов в наносекундах: не меньше времени жизни
+	// access токена и времени хранения сессии в кеше
+	revocationTTL atomic.Int64
+	// userSetTTL время хранения списка сессий пользователя в наносекундах
+	userSetTTL atomic.Int64
+}
+
+// Repository учитывает отзыв access токенов
+var _ repository.RevocationChecker = (*Repository)(nil)
+
+// cachedSession сессия в кеше
+type cachedSession struct {
+	ID             int       `json:"id"`
+	UserID         uuid.UUID `json:"user_id"`
+	RefreshToken   string    `json:"refresh_token"`
+	RefreshTokenID string    `json:"refresh_token_id"`
+	UserAgent      string    `json:"user_agent"`
+	ClientIP       string    `json:"client_ip"`
+	ExpiresAt      int64     `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	CertThumbprint string    `json:"cert_thumbprint,omitempty"`
+	JWKThumbprint  string    `json:"jwk_thumbprint,omitempty"`
+	Kind           string    `json:"kind,omitempty"`
+	// FetchedAt время начала чтения сессии из основного хранилища в наносекундах
+	FetchedAt int64 `json:"fetched_at"`
+}
+
+// NewClient создает клиент по адресу cfg.RedisURL
+func NewClient(cfg config.CacheConfig) (*redis.Client, error) {
+	options, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("неверный REDIS_URL: %w", err)
+	}
+	return redis.NewClient(options), nil
+}
+
+// NewRepository создает кеш перед хранилищем repo
+func NewRepository(repo repository.Repository, client redis.Cmdable, cfg config.CacheConfig, jwt config.JWTConfig) *Repository {
+	r := &Repository{
+		Repository: repo,
+		client:     client,
+		prefix:     cfg.KeyPrefix,
+		sessionTTL: cfg.SessionTTL,
+	}
+	r.SetJWTConfig(jwt)
+	return r
+}
+
+// SetJWTConfig применяет новое время жизни токенов после перезагрузки конфигурации.
+// Время хранения отзывов и списков сессий только увеличивается: токены, выпущенные
+// до перезагрузки, действуют прежний срок.
+func (r *Repository) SetJWTConfig(jwt config.JWTConfig) {
+	raiseTTL(&r.revocationTTL, max(jwt.AccessExpiry, r.sessionTTL))
+	raiseTTL(&r.userSetTTL, max(jwt.RefreshExpiry, r.sessionTTL))
+}
+
+// CreateSession создает сессию и добавляет ее в список сессий пользователя,
+// чтобы блокировка всех сессий отозвала и ее access токены
+func (r *Repository) CreateSession(ctx context.Context, userID uuid.UUID, refreshToken, refreshTokenID, userAgent, clientIP, kind string, binding models.TokenBinding, expiresAt int64, events ...models.Event) (int, error) {
+	sessionID, err := r.Repository.CreateSession(ctx, userID, refreshToken, refreshTokenID, userAgent, clientIP, kind, binding, expiresAt, events...)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.addUserSession(context.WithoutCancel(ctx), userID, sessionID, refreshToken); err != nil {
+		slog.WarnContext(ctx, "Ошибка записи сессии в кеш", slog.Int("session_id", sessionID), slog.Any("error", err))
+	}
+
+	return sessionID, nil
+}
+
+// GetSessionByRefreshToken получает сессию по refresh токену из кеша или основного хранилища
+func (r *Repository) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.Session, error) {
+	entry, err := r.lookupSession(ctx, refreshTokenHash)
+	switch {
+	case err != nil:
+		metrics.CacheLookup(metrics.CacheError)
+		slog.WarnContext(ctx, "Ошибка чтения сессии из кеша", slog.Any("error", err))
+	case entry != nil:
+		metrics.CacheLookup(metrics.CacheHit)
+		return entry.session(), nil
+	default:
+		metrics.CacheLookup(metrics.CacheMiss)
+	}
+
+	// Время фиксируется до чтения, чтобы отзыв, записанный во время чтения, признал запись устаревшей
+	fetchedAt := time.Now()
+	session, err := r.Repository.GetSessionByRefreshToken(ctx, refreshTokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.storeSession(ctx, session, fetchedAt); err != nil {
+		slog.WarnContext(ctx, "Ошибка записи сессии в кеш", slog.Int("session_id", session.ID), slog.Any("error", err))
+	}
+
+	return session, nil
+}
+
+// RotateRefreshToken заменяет refresh токен и удаляет из кеша сессию, найденную по старому токену
+func (r *Repository) RotateRefreshToken(ctx context.Context, rotation models.RefreshTokenRotation, events ...models.Event) error {
+	err := r.Repository.RotateRefreshToken(ctx, rotation, events...)
+
+	// Старый токен больше не действует, даже если его успел заменить конкурирующий запрос
+	if cacheErr := r.invalidateSession(context.WithoutCancel(ctx), rotation.OldRefreshToken); cacheErr != nil {
+		slog.WarnContext(ctx, "Ошибка удаления сессии из кеша", slog.Int("session_id", rotation.SessionID), slog.Any("error", cacheErr))
+	}
+
+	return err
+}
+
+// BlockSession блокирует сессию, отзывает ее access токены и удаляет ее из кеша
+func (r *Repository) BlockSession(ctx context.Context, sessionID int, events ...models.Event) error {
+	if err := r.Repository.BlockSession(ctx, sessionID, events...); err != nil {
+		return err
+	}
+
+	// Блокировка уже сохранена, поэтому запись в кеш не должна прерываться отменой запроса
+	ctx = context.WithoutCancel(ctx)
+	session, err := r.Repository.GetSession(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("сессия заблокирована, но не удалось записать отзыв в кеш: %w", err)
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, r.key("revoked", "session", strconv.Itoa(session.ID)), 1, time.Duration(r.revocationTTL.Load()))
+		if session.RefreshTokenID != "" {
+			pipe.Set(ctx, r.key("revoked", "token", session.RefreshTokenID), 1, time.Duration(r.revocationTTL.Load()))
+		}
+		pipe.Del(ctx, r.key("session", session.RefreshToken))
+		pipe.SRem(ctx, r.key("user", session.UserID.String(), "sessions"), sessionMember(session.ID, session.RefreshToken))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("сессия заблокирована, но не удалось записать отзыв в кеш: %w", err)
+	}
+
+	return nil
+}
+
+// BlockAllUserSessions блокирует все сессии пользователя, отзывает выданные ему access токены
+// и удаляет его сессии из кеша
+func (r *Repository) BlockAllUserSessions(ctx context.Context, userID uuid.UUID, events ...models.Event) error {
+	if err := r.Repository.BlockAllUserSessions(ctx, userID, events...); err != nil {
+		return err
+	}
+
+	// Блокировка уже сохранена, поэтому запись в кеш не должна прерываться отменой запроса
+	ctx = context.WithoutCancel(ctx)
+	revokedAt := time.Now()
+	setKey := r.key("user", userID.String(), "sessions")
+
+	// Отметка времени отзыва делает недействительными токены и записи кеша, появившиеся раньше нее,
+	// в том числе сессий, которые не попали в кеш
+	if err := r.client.Set(ctx, r.key("revoked", "user", userID.String()), revokedAt.UnixNano(), time.Duration(r.revocationTTL.Load())).Err(); err != nil {
+		return fmt.Errorf("сессии заблокированы, но не удалось записать отзыв в кеш: %w", err)
+	}
+
+	members, err := r.client.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return fmt.Errorf("сессии заблокированы, но не удалось удалить их из кеша: %w", err)
+	}
+
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, member := range members {
+			sessionID, refreshTokenHash, ok := strings.Cut(member, ":")
+			if !ok {
+				continue
+			}
+			pipe.Set(ctx, r.key("revoked", "session", sessionID), 1, time.Duration(r.revocationTTL.Load()))
+			pipe.Del(ctx, r.key("session", refreshTokenHash))
+		}
+		pipe.Del(ctx, setKey)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("сессии заблокированы, но не удалось удалить их из кеша: %w", err)
+	}
+
+	return nil
+}
+
+// lookupSession возвращает сессию из кеша или nil, если ее нет или запись устарела
+func (r *Repository) lookupSession(ctx context.Context, refreshTokenHash string) (*cachedSession, error) {
+	data, err := r.client.Get(ctx, r.key("session", refreshTokenHash)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &cachedSession{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, fmt.Errorf("ошибка разбора сессии из кеша: %w", err)
+	}
+
+	// Запись могла быть сохранена конкурирующим чтением уже после отзыва
+	stale := entry.ExpiresAt <= time.Now().Unix()
+	if !stale {
+		var revokedSession *redis.IntCmd
+		var revokedUser *redis.StringCmd
+		_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			revokedSession = pipe.Exists(ctx, r.key("revoked", "session", strconv.Itoa(entry.ID)))
+			revokedUser = pipe.Get(ctx, r.key("revoked", "user", entry.UserID.String()))
+			return nil
+		})
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		revokedAt, err := revokedAtValue(revokedUser)
+		if err != nil {
+			return nil, err
+		}
+		stale = revokedSession.Val() > 0 || (revokedAt > 0 && revokedAt >= entry.FetchedAt)
+	}
+
+	if stale {
+		if err := r.invalidateSession(ctx, refreshTokenHash); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	return entry, nil
+}
+
+// storeSession сохраняет сессию в кеше не дольше срока действия ее refresh токена
+func (r *Repository) storeSession(ctx context.Context, session *models.Session, fetchedAt time.Time) error {
+	ttl := min(r.sessionTTL, time.Until(time.Unix(session.ExpiresAt, 0)))
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(cachedSession{
+		ID:             session.ID,
+		UserID:         session.UserID,
+		RefreshToken:   session.RefreshToken,
+		RefreshTokenID: session.RefreshTokenID,
+		UserAgent:      session.UserAgent,
+		ClientIP:       session.ClientIP,
+		ExpiresAt:      session.ExpiresAt,
+		CreatedAt:      session.CreatedAt,
+		CertThumbprint: session.Binding.CertThumbprint,
+		JWKThumbprint:  session.Binding.JWKThumbprint,
+		Kind:           session.Kind,
+		FetchedAt:      fetchedAt.UnixNano(),
+	})
+	if err != nil {
+		return err
+	}
+
+	setKey := r.key("user", session.UserID.String(), "sessions")
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, r.key("session", session.RefreshToken), data, ttl)
+		pipe.SAdd(ctx, setKey, sessionMember(session.ID, session.RefreshToken))
+		pipe.Expire(ctx, setKey, time.Duration(r.userSetTTL.Load()))
+		return nil
+	})
+	return err
+}
+
+// addUserSession добавляет сессию в список сессий пользователя
+func (r *Repository) addUserSession(ctx context.Context, userID uuid.UUID, sessionID int, refreshTokenHash string) error {
+	setKey := r.key("user", userID.String(), "sessions")
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, setKey, sessionMember(sessionID, refreshTokenHash))
+		pipe.Expire(ctx, setKey, time.Duration(r.userSetTTL.Load()))
+		return nil
+	})
+	return err
+}
+
+// invalidateSession удаляет из кеша сессию, найденную по refresh токену
+func (r *Repository) invalidateSession(ctx context.Context, refreshTokenHash string) error {
+	data, err := r.client.GetDel(ctx, r.key("session", refreshTokenHash)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	entry := &cachedSession{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil
+	}
+	return r.client.SRem(ctx, r.key("user", entry.UserID.String(), "sessions"), sessionMember(entry.ID, refreshTokenHash)).Err()
+}
+
+// key строит ключ кеша из частей с префиксом
+func (r *Repository) key(parts ...string) string {
+	return r.prefix + strings.Join(parts, ":")
+}
+
+// session преобразует запись кеша в сессию
+func (e *cachedSession) session() *models.Session {
+	return &models.Session{
+		ID:             e.ID,
+		UserID:         e.UserID,
+		RefreshToken:   e.RefreshToken,
+		RefreshTokenID: e.RefreshTokenID,
+		UserAgent:      e.UserAgent,
+		ClientIP:       e.ClientIP,
+		ExpiresAt:      e.ExpiresAt,
+		CreatedAt:      e.CreatedAt,
+		Binding:        models.TokenBinding{CertThumbprint: e.CertThumbprint, JWKThumbprint: e.JWKThumbprint},
+		Kind:           e.Kind,
+	}
+}
+
+// sessionMember элемент списка закешированных сессий пользователя
+func sessionMember(sessionID int, refreshTokenHash string) string {
+	return strconv.Itoa(sessionID) + ":" + refreshTokenHash
+}
+
+// revokedAtValue возвращает время отзыва сессий пользователя в наносекундах или 0, если отзыва нет
+func revokedAtValue(cmd *redis.StringCmd) (int64, error) {
+	value, err := cmd.Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения отзыва сессий пользователя: %w", err)
+	}
+	return value, nil
+}
+
+// raiseTTL увеличивает время хранения ttl до value
+func raiseTTL(ttl *atomic.Int64, value time.Duration) {
+	for {
+		current := ttl.Load()
+		if int64(value) <= current || ttl.CompareAndSwap(current, int64(value)) {
+			return
+		}
+	}
+}
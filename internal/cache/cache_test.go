@@ -0,0 +1,248 @@
+package cache
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// countingRepository считает чтения сессий из основного хранилища
+type countingRepository struct {
+	repository.Repository
+	lookups int
+}
+
+func (r *countingRepository) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.Session, error) {
+	r.lookups++
+	return r.Repository.GetSessionByRefreshToken(ctx, refreshTokenHash)
+}
+
+// newTestCache создает кеш перед хранилищем в памяти с сервером протокола Redis в процессе теста
+func newTestCache(t *testing.T) (*Repository, *countingRepository, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	backing := &countingRepository{Repository: repository.NewMemoryRepository()}
+	cfg := config.CacheConfig{KeyPrefix: "test:", SessionTTL: time.Hour}
+	jwt := config.JWTConfig{AccessExpiry: 15 * time.Minute, RefreshExpiry: 24 * time.Hour}
+	return NewRepository(backing, client, cfg, jwt), backing, server
+}
+
+// createSession создает сессию с refresh токеном hash через кеш
+func createSession(t *testing.T, repo *Repository, userID uuid.UUID, hash string) int {
+	t.Helper()
+	id, err := repo.CreateSession(context.Background(), userID, hash, "id-"+hash, "agent", "192.0.2.1", models.SessionKindUser, models.TokenBinding{}, time.Now().Add(time.Hour).Unix())
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	return id
+}
+
+// lookup ищет сессию по refresh токену и возвращает число обращений к основному хранилищу
+func lookup(t *testing.T, repo *Repository, backing *countingRepository, hash string) (*models.Session, int, error) {
+	t.Helper()
+	before := backing.lookups
+	session, err := repo.GetSessionByRefreshToken(context.Background(), hash)
+	return session, backing.lookups - before, err
+}
+
+func TestGetSessionByRefreshToken(t *testing.T) {
+	repo, backing, server := newTestCache(t)
+	userID := uuid.New()
+	id := createSession(t, repo, userID, "hash")
+
+	// Первое чтение заполняет кеш, второе обслуживается им
+	if _, reads, err := lookup(t, repo, backing, "hash"); err != nil || reads != 1 {
+		t.Fatalf("промах кеша: ошибка %v, чтений хранилища %d", err, reads)
+	}
+	if !server.Exists("test:session:hash") {
+		t.Fatal("сессия не записана в кеш")
+	}
+	session, reads, err := lookup(t, repo, backing, "hash")
+	if err != nil || reads != 0 {
+		t.Fatalf("попадание в кеш: ошибка %v, чтений хранилища %d", err, reads)
+	}
+	if session.ID != id || session.UserID != userID || session.RefreshTokenID != "id-hash" || session.Kind != models.SessionKindUser {
+		t.Fatalf("из кеша получена сессия %+v", session)
+	}
+
+	// Неизвестный токен не кешируется
+	if _, reads, err := lookup(t, repo, backing, "unknown"); !errors.Is(err, repository.ErrSessionNotFound) || reads != 1 {
+		t.Fatalf("неизвестный токен: ошибка %v, чтений хранилища %d", err, reads)
+	}
+	if server.Exists("test:session:unknown") {
+		t.Fatal("неизвестный токен записан в кеш")
+	}
+}
+
+func TestStaleSession(t *testing.T) {
+	tests := []struct {
+		name  string
+		stale func(server *miniredis.Miniredis, userID uuid.UUID, sessionID int)
+	}{
+		{
+			name: "отзыв сессии",
+			stale: func(server *miniredis.Miniredis, _ uuid.UUID, sessionID int) {
+				_ = server.Set("test:revoked:session:"+strconv.Itoa(sessionID), "1")
+			},
+		},
+		{
+			name: "отзыв всех сессий пользователя после чтения",
+			stale: func(server *miniredis.Miniredis, userID uuid.UUID, _ int) {
+				_ = server.Set("test:revoked:user:"+userID.String(), strconv.FormatInt(time.Now().UnixNano(), 10))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, backing, server := newTestCache(t)
+			userID := uuid.New()
+			id := createSession(t, repo, userID, "hash")
+			if _, _, err := lookup(t, repo, backing, "hash"); err != nil {
+				t.Fatalf("GetSessionByRefreshToken: %v", err)
+			}
+
+			// Устаревшая запись удаляется, и сессия читается из основного хранилища
+			tt.stale(server, userID, id)
+			if _, reads, err := lookup(t, repo, backing, "hash"); err != nil || reads != 1 {
+				t.Fatalf("устаревшая запись: ошибка %v, чтений хранилища %d", err, reads)
+			}
+		})
+	}
+
+	t.Run("отзыв всех сессий пользователя до чтения", func(t *testing.T) {
+		repo, backing, server := newTestCache(t)
+		userID := uuid.New()
+		createSession(t, repo, userID, "hash")
+		_ = server.Set("test:revoked:user:"+userID.String(), strconv.FormatInt(time.Now().Add(-time.Minute).UnixNano(), 10))
+
+		lookup(t, repo, backing, "hash")
+		if _, reads, err := lookup(t, repo, backing, "hash"); err != nil || reads != 0 {
+			t.Fatalf("запись, прочитанная после отзыва: ошибка %v, чтений хранилища %d", err, reads)
+		}
+	})
+}
+
+func TestBlockSession(t *testing.T) {
+	repo, backing, server := newTestCache(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	id := createSession(t, repo, userID, "hash")
+	createSession(t, repo, userID, "other")
+	lookup(t, repo, backing, "hash")
+	lookup(t, repo, backing, "other")
+
+	if err := repo.BlockSession(ctx, id); err != nil {
+		t.Fatalf("BlockSession: %v", err)
+	}
+	if server.Exists("test:session:hash") {
+		t.Fatal("заблокированная сессия осталась в кеше")
+	}
+	if _, reads, err := lookup(t, repo, backing, "hash"); !errors.Is(err, repository.ErrSessionRevoked) || reads != 1 {
+		t.Fatalf("заблокированная сессия: ошибка %v, чтений хранилища %d", err, reads)
+	}
+	if _, reads, err := lookup(t, repo, backing, "other"); err != nil || reads != 0 {
+		t.Fatalf("другая сессия пользователя: ошибка %v, чтений хранилища %d", err, reads)
+	}
+}
+
+func TestBlockAllUserSessions(t *testing.T) {
+	repo, backing, server := newTestCache(t)
+	ctx := context.Background()
+	userID, otherUserID := uuid.New(), uuid.New()
+	createSession(t, repo, userID, "first")
+	createSession(t, repo, userID, "second")
+	createSession(t, repo, otherUserID, "other")
+	for _, hash := range []string{"first", "second", "other"} {
+		lookup(t, repo, backing, hash)
+	}
+
+	if err := repo.BlockAllUserSessions(ctx, userID); err != nil {
+		t.Fatalf("BlockAllUserSessions: %v", err)
+	}
+	for _, key := range []string{"test:session:first", "test:session:second", "test:user:" + userID.String() + ":sessions"} {
+		if server.Exists(key) {
+			t.Fatalf("ключ %s остался в кеше", key)
+		}
+	}
+	if !server.Exists("test:revoked:user:" + userID.String()) {
+		t.Fatal("не записано время отзыва сессий пользователя")
+	}
+	if _, reads, err := lookup(t, repo, backing, "first"); !errors.Is(err, repository.ErrSessionRevoked) || reads != 1 {
+		t.Fatalf("заблокированная сессия: ошибка %v, чтений хранилища %d", err, reads)
+	}
+	if _, reads, err := lookup(t, repo, backing, "other"); err != nil || reads != 0 {
+		t.Fatalf("сессия другого пользователя: ошибка %v, чтений хранилища %d", err, reads)
+	}
+}
+
+func TestIsAccessTokenRevoked(t *testing.T) {
+	repo, _, _ := newTestCache(t)
+	ctx := context.Background()
+	userID, otherUserID := uuid.New(), uuid.New()
+	blocked := createSession(t, repo, userID, "blocked")
+	active := createSession(t, repo, userID, "active")
+	other := createSession(t, repo, otherUserID, "other")
+	if err := repo.BlockSession(ctx, blocked); err != nil {
+		t.Fatalf("BlockSession: %v", err)
+	}
+
+	// Время отзыва сравнивается с точностью до секунды
+	issuedBefore := time.Now().Add(-time.Second)
+	if err := repo.BlockAllUserSessions(ctx, otherUserID); err != nil {
+		t.Fatalf("BlockAllUserSessions: %v", err)
+	}
+	issuedAfter := time.Now().Add(2 * time.Second)
+
+	tests := []struct {
+		name      string
+		userID    uuid.UUID
+		sessionID int
+		tokenID   string
+		issuedAt  time.Time
+		want      bool
+	}{
+		{"токен заблокированной сессии", userID, blocked, "", issuedBefore, true},
+		{"токен пары заблокированной сессии без ID сессии", userID, 0, "id-blocked", issuedBefore, true},
+		{"токен активной сессии", userID, active, "id-active", issuedBefore, false},
+		{"токен, выпущенный до отзыва всех сессий", otherUserID, other, "id-other", issuedBefore, true},
+		{"токен, выпущенный после отзыва всех сессий", otherUserID, other + 100, "", issuedAfter, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := repo.IsAccessTokenRevoked(ctx, tt.userID, tt.sessionID, tt.tokenID, tt.issuedAt)
+			if err != nil {
+				t.Fatalf("IsAccessTokenRevoked: %v", err)
+			}
+			if revoked != tt.want {
+				t.Fatalf("IsAccessTokenRevoked = %v, ожидалось %v", revoked, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnavailableCache(t *testing.T) {
+	repo, backing, server := newTestCache(t)
+	createSession(t, repo, uuid.New(), "hash")
+	server.Close()
+
+	// Ошибки кеша при чтении не прерывают запрос
+	if _, reads, err := lookup(t, repo, backing, "hash"); err != nil || reads != 1 {
+		t.Fatalf("недоступный кеш: ошибка %v, чтений хранилища %d", err, reads)
+	}
+	if _, err := repo.IsAccessTokenRevoked(context.Background(), uuid.New(), 1, "id", time.Now()); err == nil {
+		t.Fatal("IsAccessTokenRevoked: ожидалась ошибка недоступного кеша")
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// IsAccessTokenRevoked сообщает, отозван ли access токен блокировкой его сессии
+// или всех сессий пользователя. Сначала проверяется хранилище перед кешем, если оно учитывает отзывы.
+// Сессии пользователя отзываются по списку, который ведется при их создании; сессии,
+// созданные при недоступном кеше, отзываются по времени блокировки. Время выпуска токена
+// известно с точностью до секунды, поэтому токен такой сессии, выпущенный в секунду блокировки, не отзывается.
+func (r *Repository) IsAccessTokenRevoked(ctx context.Context, userID uuid.UUID, sessionID int, tokenID string, issuedAt time.Time) (bool, error) {
+	if checker, ok := r.Repository.(repository.RevocationChecker); ok {
+		revoked, err := checker.IsAccessTokenRevoked(ctx, userID, sessionID, tokenID, issuedAt)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	var revokedSession, revokedToken *redis.IntCmd
+	var revokedUser *redis.StringCmd
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		if sessionID > 0 {
+			revokedSession = pipe.Exists(ctx, r.key("revoked", "session", strconv.Itoa(sessionID)))
+		}
+		if tokenID != "" {
+			revokedToken = pipe.Exists(ctx, r.key("revoked", "token", tokenID))
+		}
+		revokedUser = pipe.Get(ctx, r.key("revoked", "user", userID.String()))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return false, err
+	}
+
+	if revokedSession != nil && revokedSession.Val() > 0 {
+		return true, nil
+	}
+	if revokedToken != nil && revokedToken.Val() > 0 {
+		return true, nil
+	}
+
+	revokedAt, err := revokedAtValue(revokedUser)
+	if err != nil {
+		return false, err
+	}
+	return revokedAt > 0 && issuedAt.Unix() < time.Unix(0, revokedAt).Unix(), nil
+}
@@ -0,0 +1,65 @@
+package certs
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"time"
+)
+
+// Identity клиентский сертификат, проверенный при установке TLS соединения
+type Identity struct {
+	Subject      string    `json:"subject"`
+	CommonName   string    `json:"common_name,omitempty"`
+	DNSNames     []string  `json:"dns_names,omitempty"`
+	URIs         []string  `json:"uris,omitempty"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serial_number"`
+	NotAfter     time.Time `json:"not_after"`
+	// Thumbprint SHA-256 отпечаток сертификата в base64url без дополнения (x5t#S256, RFC 8705)
+	Thumbprint string `json:"x5t#S256"`
+
+	Certificate *x509.Certificate `json:"-"`
+}
+
+// PeerIdentity возвращает клиентский сертификат соединения, если он проверен
+// по удостоверяющим центрам клиентов; nil, если сертификат не предъявлен или соединение без TLS
+func PeerIdentity(state *tls.ConnectionState) *Identity {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return NewIdentity(state.VerifiedChains[0][0])
+}
+
+// NewIdentity описывает сертификат certificate
+func NewIdentity(certificate *x509.Certificate) *Identity {
+	identity := &Identity{
+		Subject:      certificate.Subject.String(),
+		CommonName:   certificate.Subject.CommonName,
+		DNSNames:     certificate.DNSNames,
+		Issuer:       certificate.Issuer.String(),
+		SerialNumber: certificate.SerialNumber.String(),
+		NotAfter:     certificate.NotAfter,
+		Thumbprint:   Thumbprint(certificate),
+		Certificate:  certificate,
+	}
+	for _, uri := range certificate.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+	return identity
+}
+
+// Name возвращает имя владельца сертификата: Common Name или полное имя субъекта
+func (i *Identity) Name() string {
+	if i.CommonName != "" {
+		return i.CommonName
+	}
+	return i.Subject
+}
+
+// Thumbprint возвращает SHA-256 отпечаток сертификата в base64url без дополнения
+func Thumbprint(certificate *x509.Certificate) string {
+	hash := sha256.Sum256(certificate.Raw)
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
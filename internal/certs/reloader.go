@@ -0,0 +1,161 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reloader хранит сертификат сервера и сертификаты удостоверяющих центров клиентов
+// и перечитывает их после изменения файлов. Новые соединения используют действующие
+// сертификаты, а установленные соединения не прерываются.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	// caFile сертификаты удостоверяющих центров клиентов; если пуст, клиентские сертификаты не запрашиваются
+	caFile     string
+	clientAuth tls.ClientAuthType
+
+	current atomic.Pointer[bundle]
+
+	// mu упорядочивает перезагрузки
+	mu sync.Mutex
+	// state отпечаток файлов, из которых загружены действующие сертификаты
+	state string
+}
+
+// bundle сертификаты, загруженные из файлов одновременно
+type bundle struct {
+	certificate tls.Certificate
+	clientCAs   *x509.CertPool
+}
+
+// NewReloader загружает сертификат и ключ сервера и, если caFile не пуст, сертификаты
+// удостоверяющих центров, которыми проверяются клиентские сертификаты. Если requireClientCert
+// равен false, соединения без клиентского сертификата также принимаются.
+func NewReloader(certFile, keyFile, caFile string, requireClientCert bool) (*Reloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("не заданы файлы сертификата и ключа TLS")
+	}
+
+	r := &Reloader{
+		certFile:   certFile,
+		keyFile:    keyFile,
+		caFile:     caFile,
+		clientAuth: tls.NoClientCert,
+	}
+	if caFile != "" {
+		r.clientAuth = tls.VerifyClientCertIfGiven
+		if requireClientCert {
+			r.clientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	if _, err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig возвращает конфигурацию TLS, которая при каждом соединении использует
+// действующие сертификаты
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			current := r.current.Load()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{current.certificate},
+				ClientCAs:    current.clientCAs,
+				ClientAuth:   r.clientAuth,
+			}, nil
+		},
+	}
+}
+
+// load перечитывает сертификаты, если их файлы изменились, и сообщает, были ли они заменены.
+// При ошибке продолжают действовать прежние сертификаты.
+func (r *Reloader) load() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.fileState()
+	if state == r.state {
+		return false, nil
+	}
+	// После ошибки файлы не перечитываются, пока они снова не изменятся
+	r.state = state
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("ошибка загрузки сертификата TLS: %w", err)
+	}
+	next := &bundle{certificate: certificate}
+
+	if r.caFile != "" {
+		data, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return false, fmt.Errorf("ошибка чтения сертификатов удостоверяющих центров: %w", err)
+		}
+		next.clientCAs = x509.NewCertPool()
+		if !next.clientCAs.AppendCertsFromPEM(data) {
+			return false, fmt.Errorf("файл %s не содержит сертификатов в формате PEM", r.caFile)
+		}
+	}
+
+	r.current.Store(next)
+	return true, nil
+}
+
+// Watch проверяет изменения файлов сертификатов каждые interval до отмены ctx
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.Reload()
+		}
+	}
+}
+
+// Reload перечитывает сертификаты, если их файлы изменились, и записывает результат в лог.
+// При ошибке продолжают действовать прежние сертификаты.
+func (r *Reloader) Reload() {
+	reloaded, err := r.load()
+	if err != nil {
+		slog.Error("Сертификаты TLS не обновлены", slog.String("cert_file", r.certFile), slog.Any("error", err))
+		return
+	}
+	if reloaded {
+		slog.Info("Сертификаты TLS обновлены", slog.String("cert_file", r.certFile))
+	}
+}
+
+// fileState возвращает отпечаток времени изменения и размера файлов сертификатов
+func (r *Reloader) fileState() string {
+	var state strings.Builder
+	for _, path := range []string{r.certFile, r.keyFile, r.caFile} {
+		if path == "" {
+			continue
+		}
+		state.WriteString(path)
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&state, " %d %d", info.ModTime().UnixNano(), info.Size())
+		}
+		state.WriteString("\n")
+	}
+	return state.String()
+}
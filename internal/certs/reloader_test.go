@@ -0,0 +1,157 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPair самоподписанный сертификат сервера и его ключ в формате PEM
+type testPair struct {
+	commonName string
+	cert       []byte
+	key        []byte
+}
+
+// newTestPair выпускает самоподписанный сертификат с именем commonName
+func newTestPair(t *testing.T, commonName string) testPair {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testPair{
+		commonName: commonName,
+		cert:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:        pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// writeFile записывает файл и сдвигает время его изменения, чтобы изменение
+// было заметно даже при грубом разрешении времени файловой системы
+func writeFile(t *testing.T, path string, data []byte, modified time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// servedName выполняет TLS рукопожатие с конфигурацией Reloader и возвращает
+// Common Name сертификата, который предъявил сервер
+func servedName(t *testing.T, r *Reloader) string {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	go func() {
+		defer serverConn.Close()
+		_ = tls.Server(serverConn, r.TLSConfig()).Handshake()
+	}()
+
+	client := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true})
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("TLS рукопожатие: %v", err)
+	}
+	return client.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	tests := []struct {
+		name string
+		// next возвращает новые содержимое файлов сертификата и ключа
+		next func(t *testing.T, initial testPair) (cert, key []byte)
+		// reloaded новый сертификат должен заменить прежний
+		reloaded bool
+	}{
+		{
+			name: "новый сертификат и ключ",
+			next: func(t *testing.T, initial testPair) ([]byte, []byte) {
+				next := newTestPair(t, "next")
+				return next.cert, next.key
+			},
+			reloaded: true,
+		},
+		{
+			name: "новый сертификат с прежним ключом",
+			next: func(t *testing.T, initial testPair) ([]byte, []byte) {
+				return newTestPair(t, "next").cert, initial.key
+			},
+		},
+		{
+			name: "поврежденный сертификат",
+			next: func(t *testing.T, initial testPair) ([]byte, []byte) {
+				return []byte("not a certificate"), initial.key
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+			initial := newTestPair(t, "initial")
+			created := time.Now().Add(-time.Minute)
+			writeFile(t, certFile, initial.cert, created)
+			writeFile(t, keyFile, initial.key, created)
+
+			r, err := NewReloader(certFile, keyFile, "", false)
+			if err != nil {
+				t.Fatalf("NewReloader: %v", err)
+			}
+			if name := servedName(t, r); name != "initial" {
+				t.Fatalf("сертификат %q, ожидался initial", name)
+			}
+
+			cert, key := tt.next(t, initial)
+			writeFile(t, certFile, cert, created.Add(time.Second))
+			writeFile(t, keyFile, key, created.Add(time.Second))
+			reloaded, err := r.load()
+			if reloaded != tt.reloaded || (err == nil) != tt.reloaded {
+				t.Fatalf("load = %t, %v; ожидалась замена сертификата: %t", reloaded, err, tt.reloaded)
+			}
+
+			want := "initial"
+			if tt.reloaded {
+				want = "next"
+			}
+			if name := servedName(t, r); name != want {
+				t.Fatalf("сертификат %q, ожидался %s", name, want)
+			}
+
+			// Файлы не изменились, поэтому повторная загрузка ничего не делает
+			if reloaded, err := r.load(); reloaded || err != nil {
+				t.Fatalf("повторная загрузка = %t, %v", reloaded, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Заголовки, из которых доверенный прокси передает адрес клиента
+const (
+	HeaderXForwardedFor = "x-forwarded-for"
+	HeaderForwarded     = "forwarded"
+)
+
+// Resolver определяет реальный IP-адрес клиента с учетом доверенных прокси
+type Resolver struct {
+	trusted []*net.IPNet
+	header  string
+}
+
+// NewResolver создает новый экземпляр Resolver.
+// Каждый элемент trustedProxies - это IP-адрес или подсеть в нотации CIDR.
+// header - заголовок, в который доверенные прокси добавляют адрес клиента:
+// HeaderXForwardedFor или HeaderForwarded.
+func NewResolver(trustedProxies []string, header string) (*Resolver, error) {
+	r := &Resolver{header: strings.ToLower(header)}
+	if r.header != HeaderXForwardedFor && r.header != HeaderForwarded {
+		return nil, fmt.Errorf("неизвестный заголовок адреса клиента %q: ожидается %s или %s",
+			header, HeaderXForwardedFor, HeaderForwarded)
+	}
+	for _, item := range trustedProxies {
+		network, err := parseNetwork(item)
+		if err != nil {
+			return nil, err
+		}
+		r.trusted = append(r.trusted, network)
+	}
+	return r, nil
+}
+
+// Resolve возвращает IP-адрес клиента для запроса.
+// Заголовок прокси (X-Forwarded-For или Forwarded, RFC 7239) учитывается только тогда,
+// когда непосредственный отправитель запроса является доверенным прокси. Второй
+// заголовок игнорируется: прокси обычно передает его от клиента без изменений.
+// Цепочка адресов просматривается справа налево, и первым недоверенным
+// адресом считается адрес клиента.
+func (r *Resolver) Resolve(req *http.Request) string {
+	remoteIP := parseHost(req.RemoteAddr)
+	if remoteIP == nil {
+		return req.RemoteAddr
+	}
+
+	if !r.isTrusted(remoteIP) {
+		return remoteIP.String()
+	}
+
+	var chain []string
+	if r.header == HeaderForwarded {
+		chain = forwardedFor(req.Header.Values("Forwarded"))
+	} else {
+		chain = xForwardedFor(req.Header.Values("X-Forwarded-For"))
+	}
+
+	clientIP := remoteIP
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := parseHost(chain[i])
+		if ip == nil {
+			// Дальше невалидного элемента цепочке доверять нельзя
+			break
+		}
+		clientIP = ip
+		if !r.isTrusted(ip) {
+			break
+		}
+	}
+
+	return clientIP.String()
+}
+
+// TrustedProxies возвращает список доверенных подсетей в нотации CIDR
+func (r *Resolver) TrustedProxies() []string {
+	result := make([]string, 0, len(r.trusted))
+	for _, network := range r.trusted {
+		result = append(result, network.String())
+	}
+	return result
+}
+
+func (r *Resolver) isTrusted(ip net.IP) bool {
+	for _, network := range r.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNetwork разбирает IP-адрес или подсеть CIDR
+func parseNetwork(value string) (*net.IPNet, error) {
+	if strings.Contains(value, "/") {
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("некорректная подсеть доверенного прокси %q: %w", value, err)
+		}
+		return network, nil
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("некорректный IP-адрес доверенного прокси %q", value)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// parseHost извлекает IP-адрес из строки вида "ip", "ip:port" или "[ipv6]:port"
+func parseHost(value string) net.IP {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	return net.ParseIP(value)
+}
+
+// xForwardedFor собирает цепочку адресов из заголовков X-Forwarded-For
+func xForwardedFor(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			chain = append(chain, strings.TrimSpace(item))
+		}
+	}
+	return chain
+}
+
+// forwardedFor собирает цепочку адресов из параметров for= заголовков Forwarded (RFC 7239)
+func forwardedFor(values []string) []string {
+	var chain []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				name, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(name, "for") {
+					continue
+				}
+				// Значения "unknown" и обфусцированные идентификаторы
+				// попадают в цепочку и останавливают ее разбор
+				chain = append(chain, strings.Trim(val, `"`))
+			}
+		}
+	}
+	return chain
+}
@@ -0,0 +1,227 @@
+package clientip
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name string
+		// header заголовок прокси; по умолчанию X-Forwarded-For
+		header        string
+		remoteAddr    string
+		xForwardedFor []string
+		forwarded     []string
+		want          string
+	}{
+		{
+			name:       "прямое подключение",
+			remoteAddr: "203.0.113.5:52000",
+			want:       "203.0.113.5",
+		},
+		{
+			name:          "X-Forwarded-For от недоверенного отправителя",
+			remoteAddr:    "203.0.113.5:52000",
+			xForwardedFor: []string{"198.51.100.1"},
+			want:          "203.0.113.5",
+		},
+		{
+			name:       "Forwarded от недоверенного отправителя",
+			header:     HeaderForwarded,
+			remoteAddr: "203.0.113.5:52000",
+			forwarded:  []string{"for=198.51.100.1"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "доверенный прокси без заголовков",
+			remoteAddr: "10.0.0.1:52000",
+			want:       "10.0.0.1",
+		},
+		{
+			name:          "X-Forwarded-For от доверенного прокси",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"203.0.113.5"},
+			want:          "203.0.113.5",
+		},
+		{
+			name:          "доверенный прокси, заданный адресом",
+			remoteAddr:    "192.0.2.10:52000",
+			xForwardedFor: []string{"203.0.113.5"},
+			want:          "203.0.113.5",
+		},
+		{
+			name:          "цепочка доверенных прокси",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"203.0.113.5, 192.0.2.10, 10.0.0.2"},
+			want:          "203.0.113.5",
+		},
+		{
+			name:          "подставленный клиентом адрес левее первого недоверенного",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"198.51.100.1, 203.0.113.5, 10.0.0.2"},
+			want:          "203.0.113.5",
+		},
+		{
+			name:          "цепочка только из доверенных прокси",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"10.0.0.3, 10.0.0.2"},
+			want:          "10.0.0.3",
+		},
+		{
+			name:          "несколько заголовков X-Forwarded-For",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"198.51.100.1", "203.0.113.5, 10.0.0.2"},
+			want:          "203.0.113.5",
+		},
+		{
+			name:          "Forwarded от клиента при X-Forwarded-For игнорируется",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"203.0.113.5"},
+			forwarded:     []string{"for=198.51.100.1"},
+			want:          "203.0.113.5",
+		},
+		{
+			name:          "X-Forwarded-For от клиента при Forwarded игнорируется",
+			header:        HeaderForwarded,
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"198.51.100.1"},
+			forwarded:     []string{"for=203.0.113.5"},
+			want:          "203.0.113.5",
+		},
+		{
+			name:       "Forwarded без X-Forwarded-For не учитывается",
+			remoteAddr: "10.0.0.1:52000",
+			forwarded:  []string{"for=198.51.100.1"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "Forwarded с другими параметрами и регистром",
+			header:     HeaderForwarded,
+			remoteAddr: "10.0.0.1:52000",
+			forwarded:  []string{"proto=https;For=203.0.113.5;by=10.0.0.1, for=10.0.0.2"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "несколько заголовков Forwarded",
+			header:     HeaderForwarded,
+			remoteAddr: "10.0.0.1:52000",
+			forwarded:  []string{"for=198.51.100.1", "for=203.0.113.5, for=10.0.0.2"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "Forwarded с IPv6 и портом",
+			header:     HeaderForwarded,
+			remoteAddr: "10.0.0.1:52000",
+			forwarded:  []string{`for="[2a00:1450:4001::17]:4711"`},
+			want:       "2a00:1450:4001::17",
+		},
+		{
+			name:       "Forwarded с обфусцированным идентификатором",
+			header:     HeaderForwarded,
+			remoteAddr: "10.0.0.1:52000",
+			forwarded:  []string{"for=203.0.113.5, for=_hidden, for=10.0.0.2"},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "Forwarded со значением unknown",
+			header:     HeaderForwarded,
+			remoteAddr: "10.0.0.1:52000",
+			forwarded:  []string{"for=203.0.113.5, for=unknown"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:          "некорректный элемент X-Forwarded-For",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"203.0.113.5, not-an-ip"},
+			want:          "10.0.0.1",
+		},
+		{
+			name:          "пустой элемент X-Forwarded-For",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"203.0.113.5,,10.0.0.2"},
+			want:          "10.0.0.2",
+		},
+		{
+			name:          "X-Forwarded-For с портом",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"203.0.113.5:8080"},
+			want:          "203.0.113.5",
+		},
+		{
+			name:          "X-Forwarded-For с IPv6 и портом",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"[2a00:1450:4001::17]:443"},
+			want:          "2a00:1450:4001::17",
+		},
+		{
+			name:          "X-Forwarded-For с IPv6 без порта",
+			remoteAddr:    "10.0.0.1:52000",
+			xForwardedFor: []string{"2a00:1450:4001::17"},
+			want:          "2a00:1450:4001::17",
+		},
+		{
+			name:          "доверенный прокси IPv6",
+			remoteAddr:    "[2001:db8::1]:52000",
+			xForwardedFor: []string{"203.0.113.5"},
+			want:          "203.0.113.5",
+		},
+		{
+			name:          "недоверенный отправитель IPv6",
+			remoteAddr:    "[2a00:1450:4001::17]:52000",
+			xForwardedFor: []string{"203.0.113.5"},
+			want:          "2a00:1450:4001::17",
+		},
+		{
+			name:       "адрес отправителя не IP",
+			remoteAddr: "@",
+			want:       "@",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := tt.header
+			if header == "" {
+				header = HeaderXForwardedFor
+			}
+			resolver, err := NewResolver([]string{"10.0.0.0/8", "192.0.2.10", "2001:db8::/32"}, header)
+			if err != nil {
+				t.Fatalf("NewResolver: %v", err)
+			}
+			req := &http.Request{RemoteAddr: tt.remoteAddr, Header: http.Header{}}
+			for _, value := range tt.xForwardedFor {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			for _, value := range tt.forwarded {
+				req.Header.Add("Forwarded", value)
+			}
+			if got := resolver.Resolve(req); got != tt.want {
+				t.Fatalf("Resolve = %s, ожидался %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	resolver, err := NewResolver([]string{"10.0.0.0/8", "192.0.2.10", "2001:db8::1"}, "X-Forwarded-For")
+	if err != nil {
+		t.Fatalf("NewResolver: %v", err)
+	}
+	want := []string{"10.0.0.0/8", "192.0.2.10/32", "2001:db8::1/128"}
+	if got := resolver.TrustedProxies(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("TrustedProxies = %v, ожидалось %v", got, want)
+	}
+
+	for _, value := range []string{"10.0.0.0/33", "proxy.local", ""} {
+		if _, err := NewResolver([]string{value}, HeaderXForwardedFor); err == nil {
+			t.Fatalf("NewResolver(%q): ожидалась ошибка", value)
+		}
+	}
+	for _, header := range []string{"x-real-ip", ""} {
+		if _, err := NewResolver(nil, header); err == nil {
+			t.Fatalf("NewResolver с заголовком %q: ожидалась ошибка", header)
+		}
+	}
+}
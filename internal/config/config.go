@@ -2,108 +2,379 @@ package config
 
 import (
 	"fmt"
-	"os"
-	"strconv"
 	"time"
+)
 
-	"github.com/joho/godotenv"
+// Режимы работы сервиса
+const (
+	// EnvironmentDevelopment режим разработки: допускаются ключи подписи по умолчанию
+	EnvironmentDevelopment = "development"
+	// EnvironmentProduction рабочий режим со строгой проверкой секретов
+	EnvironmentProduction = "production"
 )
 
+// DefaultAccessSecret ключ подписи по умолчанию, с которым сервис не запускается в рабочем режиме
+const DefaultAccessSecret = "default_access_secret"
+
 // Config структура содержит все конфигурационные параметры приложения
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Webhook  WebhookConfig
+	// Environment режим работы: development или production
+	Environment string
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Cache       CacheConfig
+	Revocation  RevocationConfig
+	SessionGC   SessionGCConfig
+	JWT         JWTConfig
+	DPoP        DPoPConfig
+	Webhook     WebhookConfig
+	Events      EventsConfig
+	Admin       AdminConfig
+	Tracing     TracingConfig
+	Log         LogConfig
+	Health      HealthConfig
+	Reload      ReloadConfig
+
+	// settings действующие значения параметров и их источники
+	settings []Setting
+	// files файлы, из которых прочитана конфигурация
+	files []string
 }
 
 // ServerConfig содержит конфигурацию веб-сервера
 type ServerConfig struct {
 	Port string
+	// TrustedProxies список IP-адресов и CIDR прокси, которым разрешено передавать адрес клиента
+	TrustedProxies []string
+	// TrustedProxyHeader заголовок, из которого берется адрес клиента: x-forwarded-for или forwarded
+	TrustedProxyHeader string
+	// ProxyProtocol включает разбор заголовка PROXY protocol (v1/v2) на слушающем сокете
+	ProxyProtocol bool
+	// MetricsOnMainPort публикует /metrics на основном порту. Без этого флага метрики
+	// доступны только на административном порту ADMIN_PORT.
+	MetricsOnMainPort bool
+	// ShutdownDelay время между переводом /readyz в состояние отказа и остановкой сервера,
+	// за которое балансировщик успевает исключить экземпляр
+	ShutdownDelay time.Duration
+	// ShutdownTimeout время на завершение обрабатываемых запросов при остановке
+	ShutdownTimeout time.Duration
+	// TLSCertFile и TLSKeyFile сертификат и ключ TLS; если заданы, порт принимает только TLS соединения
+	TLSCertFile string
+	TLSKeyFile  string
+	// ClientCAFile сертификаты удостоверяющих центров, подписавших клиентские сертификаты
+	// сервисных клиентов (mTLS); требует TLS
+	ClientCAFile string
+	// RequireClientCert отклоняет соединения без клиентского сертификата
+	RequireClientCert bool
 }
 
 // DatabaseConfig содержит конфигурацию подключения к базе данных
 type DatabaseConfig struct {
+	// Driver хранилище: postgres, sqlite или memory
+	Driver string
+	// DSN строка подключения; для postgres заменяет Host, Port и остальные параметры
+	DSN      string
 	Host     string
 	Port     string
 	User     string
 	Password string
 	DBName   string
 	SSLMode  string
+	// AutoMigrate применяет миграции схемы при запуске сервиса
+	AutoMigrate bool
+	// QueryTimeout ограничивает время одной операции с базой данных; 0 отключает ограничение
+	QueryTimeout time.Duration
+}
+
+// SessionGCConfig содержит конфигурацию очистки истекших и заблокированных сессий
+type SessionGCConfig struct {
+	// Interval интервал между запусками очистки; 0 отключает фоновую очистку
+	Interval time.Duration
+	// Retention время хранения сессии после истечения срока действия или блокировки
+	Retention time.Duration
+	// BatchSize количество сессий, удаляемых одним запросом
+	BatchSize int
+}
+
+// RevocationConfig содержит конфигурацию распространения блокировок сессий между экземплярами
+type RevocationConfig struct {
+	// NotifyChannel канал Postgres NOTIFY для блокировок сессий; если пуст, уведомления не отправляются
+	NotifyChannel string
+	// ResyncInterval интервал полной загрузки заблокированных сессий из хранилища
+	ResyncInterval time.Duration
+}
+
+// CacheConfig содержит конфигурацию кеша сессий и отозванных токенов
+type CacheConfig struct {
+	// RedisURL адрес сервера, поддерживающего протокол Redis (redis:// или rediss://);
+	// если пуст, кеш отключен
+	RedisURL string
+	// KeyPrefix префикс всех ключей кеша
+	KeyPrefix string
+	// SessionTTL максимальное время хранения сессии в кеше
+	SessionTTL time.Duration
 }
 
 // JWTConfig содержит конфигурацию для JWT токенов
 type JWTConfig struct {
-	AccessSecret  string
-	AccessExpiry  time.Duration
-	RefreshSecret string
-	RefreshExpiry time.Duration
+	AccessSecret string
+	// PreviousAccessSecrets прежние ключи подписи access токенов: выпущенные ими токены
+	// принимаются до истечения срока действия, пока ключи не удалены из конфигурации
+	PreviousAccessSecrets []string
+	AccessExpiry          time.Duration
+	RefreshExpiry         time.Duration
+	// RefreshReuseGrace окно после замены refresh токена, в течение которого повторный
+	// запрос со старым токеном получает ту же новую пару; 0 отключает окно
+	RefreshReuseGrace time.Duration
+}
+
+// DPoPConfig содержит конфигурацию проверки DPoP доказательств (RFC 9449)
+type DPoPConfig struct {
+	// Enabled включает проверку доказательств и привязку токенов к ключам DPoP; требует BaseURL
+	Enabled bool
+	// ProofLifetime допустимое отклонение времени создания доказательства (iat) от текущего времени
+	ProofLifetime time.Duration
+	// BaseURL внешний адрес сервиса, с которым сравнивается htu доказательства. Адрес не берется
+	// из заголовка Host и схемы соединения: их выбирает клиент, а за прокси, завершающим TLS,
+	// схема не совпадает с внешней.
+	BaseURL string
+	// ReplayFailOpen принимает доказательства без проверки повторного использования,
+	// если хранилище идентификаторов недоступно; иначе такие запросы отклоняются
+	ReplayFailOpen bool
 }
 
 // WebhookConfig содержит конфигурацию для webhook
 type WebhookConfig struct {
 	URL string
+	// Secrets активные секреты подписи; во время ротации задаются старый и новый
+	Secrets []string
+	// Timeout ограничивает время одной попытки доставки
+	Timeout time.Duration
+	// Workers количество одновременных доставок
+	Workers int
+	// MaxAttempts количество попыток, после которого сообщение переводится в статус dead
+	MaxAttempts int
+	// BackoffBase задержка перед первой повторной попыткой, далее она удваивается
+	BackoffBase time.Duration
+	// BackoffMax максимальная задержка между попытками
+	BackoffMax time.Duration
+	// PollInterval интервал опроса outbox при отсутствии сообщений
+	PollInterval time.Duration
+}
+
+// EventsConfig содержит конфигурацию шины событий
+type EventsConfig struct {
+	// Source атрибут source событий CloudEvents
+	Source string
+	// Sinks список получателей событий: webhook, stdout, file, pgnotify
+	Sinks []string
+	// FilePath файл для sink-а file (JSON Lines)
+	FilePath string
+	// NotifyChannel канал Postgres NOTIFY для sink-а pgnotify
+	NotifyChannel string
+	// PollInterval интервал опроса журнала при отсутствии новых событий
+	PollInterval time.Duration
 }
 
-// LoadConfig загружает конфигурацию из .env файла и переменных окружения
+// AdminConfig содержит конфигурацию административного API
+type AdminConfig struct {
+	// Token статический токен доступа к /admin
+	Token string
+	// Port порт отдельного слушающего сокета административного API
+	Port string
+	// OnMainPort подключает административный API к основному порту, если ADMIN_PORT не задан.
+	// Без отдельного порта и этого флага административный API отключен.
+	OnMainPort bool
+	// TLSCertFile и TLSKeyFile сертификат и ключ TLS административного порта
+	TLSCertFile string
+	TLSKeyFile  string
+	// ClientCAFile сертификаты удостоверяющих центров, подписавших клиентские сертификаты
+	// администраторов (mTLS); требует TLS на административном порту
+	ClientCAFile string
+}
+
+// TracingConfig содержит конфигурацию трассировки OpenTelemetry
+type TracingConfig struct {
+	// Exporter экспортер span-ов: none, otlp, stdout, file
+	Exporter string
+	// ServiceName имя сервиса в атрибутах ресурса
+	ServiceName string
+	// Endpoint адрес OTLP/HTTP коллектора (host:port)
+	Endpoint string
+	// Insecure отключает TLS при отправке в OTLP коллектор
+	Insecure bool
+	// FilePath файл для экспортера file
+	FilePath string
+	// SampleRatio доля трассируемых запросов от 0 до 1
+	SampleRatio float64
+}
+
+// LogConfig содержит конфигурацию логирования
+type LogConfig struct {
+	// Level минимальный уровень записей: debug, info, warn, error
+	Level string
+	// Format формат записей: json или text
+	Format string
+}
+
+// HealthConfig содержит конфигурацию проверок готовности
+type HealthConfig struct {
+	// Timeout ограничивает время выполнения всех проверок /readyz
+	Timeout time.Duration
+	// WebhookBacklogMax количество просроченных сообщений webhook, при превышении
+	// которого сервис считается неготовым; 0 отключает проверку
+	WebhookBacklogMax int
+	// WebhookBacklogAge задержка отправки, после которой сообщение считается просроченным
+	WebhookBacklogAge time.Duration
+}
+
+// ReloadConfig содержит конфигурацию перезагрузки параметров без перезапуска
+type ReloadConfig struct {
+	// WatchInterval интервал проверки изменений файла конфигурации, .env файла, файлов
+	// секретов и сертификатов TLS; 0 отключает проверку, и они перечитываются только по SIGHUP
+	WatchInterval time.Duration
+}
+
+// LoadConfig загружает конфигурацию из файла CONFIG_FILE (YAML или TOML), .env файла
+// и переменных окружения. Переменные окружения имеют приоритет над файлом.
+// Возвращает ошибку, если параметры некорректны (см. Validate).
 func LoadConfig() (*Config, error) {
-	// Пытаемся загрузить .env файл, если он существует
-	_ = godotenv.Load() // Игнорируем ошибку, если файл не существует
+	l, err := newLoader()
+	if err != nil {
+		return nil, err
+	}
 
 	cfg := &Config{}
+	cfg.Environment = l.getString("APP_ENV", EnvironmentDevelopment)
 
 	// Настройки сервера
-	cfg.Server.Port = getEnv("SERVER_PORT", "8080")
+	cfg.Server.Port = l.getString("SERVER_PORT", "8080")
+	cfg.Server.TrustedProxies = l.getSlice("TRUSTED_PROXIES", nil)
+	cfg.Server.TrustedProxyHeader = l.getString("TRUSTED_PROXY_HEADER", "x-forwarded-for")
+	cfg.Server.ProxyProtocol = l.getBool("PROXY_PROTOCOL", false)
+	cfg.Server.MetricsOnMainPort = l.getBool("METRICS_ON_MAIN_PORT", false)
+	cfg.Server.ShutdownDelay = l.getDuration("SHUTDOWN_DELAY", "5s")
+	cfg.Server.ShutdownTimeout = l.getDuration("SHUTDOWN_TIMEOUT", "5s")
+	cfg.Server.TLSCertFile = l.getString("TLS_CERT_FILE", "")
+	cfg.Server.TLSKeyFile = l.getString("TLS_KEY_FILE", "")
+	cfg.Server.ClientCAFile = l.getString("TLS_CLIENT_CA_FILE", "")
+	cfg.Server.RequireClientCert = l.getBool("TLS_CLIENT_CERT_REQUIRED", false)
 
 	// Настройки базы данных
-	cfg.Database.Host = getEnv("DB_HOST", "localhost")
-	cfg.Database.Port = getEnv("DB_PORT", "5432")
-	cfg.Database.User = getEnv("DB_USER", "postgres")
-	cfg.Database.Password = getEnv("DB_PASSWORD", "postgres")
-	cfg.Database.DBName = getEnv("DB_NAME", "auth_service_db")
-	cfg.Database.SSLMode = getEnv("DB_SSL_MODE", "disable")
+	cfg.Database.Driver = l.getString("DB_DRIVER", "postgres")
+	cfg.Database.DSN = l.getSecret("DB_DSN", "")
+	cfg.Database.Host = l.getString("DB_HOST", "localhost")
+	cfg.Database.Port = l.getString("DB_PORT", "5432")
+	cfg.Database.User = l.getString("DB_USER", "postgres")
+	cfg.Database.Password = l.getSecret("DB_PASSWORD", "postgres")
+	cfg.Database.DBName = l.getString("DB_NAME", "auth_service_db")
+	cfg.Database.SSLMode = l.getString("DB_SSL_MODE", "disable")
+	cfg.Database.AutoMigrate = l.getBool("DB_AUTO_MIGRATE", true)
+	cfg.Database.QueryTimeout = l.getDuration("DB_QUERY_TIMEOUT", "5s")
+
+	// Настройки очистки сессий
+	cfg.SessionGC.Interval = l.getDuration("SESSION_GC_INTERVAL", "1h")
+	cfg.SessionGC.Retention = l.getDuration("SESSION_GC_RETENTION", "168h")
+	cfg.SessionGC.BatchSize = l.getInt("SESSION_GC_BATCH_SIZE", 1000)
+
+	// Настройки распространения блокировок
+	cfg.Revocation.NotifyChannel = l.getString("REVOCATION_NOTIFY_CHANNEL", "auth_revocations")
+	cfg.Revocation.ResyncInterval = l.getDuration("REVOCATION_RESYNC_INTERVAL", "1m")
+
+	// Настройки кеша
+	cfg.Cache.RedisURL = l.getSecret("REDIS_URL", "")
+	cfg.Cache.KeyPrefix = l.getString("CACHE_KEY_PREFIX", "auth-service:")
+	cfg.Cache.SessionTTL = l.getDuration("CACHE_SESSION_TTL", "5m")
 
 	// Настройки JWT
-	cfg.JWT.AccessSecret = getEnv("JWT_ACCESS_SECRET", "default_access_secret")
-	accessExpiry, err := time.ParseDuration(getEnv("JWT_ACCESS_EXPIRY", "15m"))
-	if err != nil {
-		return nil, fmt.Errorf("ошибка парсинга JWT_ACCESS_EXPIRY: %w", err)
-	}
-	cfg.JWT.AccessExpiry = accessExpiry
+	cfg.JWT.AccessSecret = l.getSecret("JWT_ACCESS_SECRET", DefaultAccessSecret)
+	cfg.JWT.PreviousAccessSecrets = l.getSecretSlice("JWT_ACCESS_PREVIOUS_SECRETS")
+	cfg.JWT.AccessExpiry = l.getDuration("JWT_ACCESS_EXPIRY", "15m")
+	cfg.JWT.RefreshExpiry = l.getDuration("JWT_REFRESH_EXPIRY", "720h")
+	cfg.JWT.RefreshReuseGrace = l.getDuration("JWT_REFRESH_REUSE_GRACE", "10s")
 
-	cfg.JWT.RefreshSecret = getEnv("JWT_REFRESH_SECRET", "default_refresh_secret")
-	refreshExpiry, err := time.ParseDuration(getEnv("JWT_REFRESH_EXPIRY", "720h"))
-	if err != nil {
-		return nil, fmt.Errorf("ошибка парсинга JWT_REFRESH_EXPIRY: %w", err)
+	// Настройки DPoP
+	cfg.DPoP.Enabled = l.getBool("DPOP_ENABLED", false)
+	cfg.DPoP.ProofLifetime = l.getDuration("DPOP_PROOF_LIFETIME", "1m")
+	cfg.DPoP.BaseURL = l.getString("DPOP_BASE_URL", "")
+	cfg.DPoP.ReplayFailOpen = l.getBool("DPOP_REPLAY_FAIL_OPEN", false)
+
+	// Настройки webhook
+	cfg.Webhook.URL = l.getString("WEBHOOK_URL", "")
+	cfg.Webhook.Secrets = l.getSecretSlice("WEBHOOK_SECRETS")
+	cfg.Webhook.Timeout = l.getDuration("WEBHOOK_TIMEOUT", "5s")
+	cfg.Webhook.Workers = l.getInt("WEBHOOK_WORKERS", 4)
+	cfg.Webhook.MaxAttempts = l.getInt("WEBHOOK_MAX_ATTEMPTS", 10)
+	cfg.Webhook.BackoffBase = l.getDuration("WEBHOOK_BACKOFF_BASE", "1s")
+	cfg.Webhook.BackoffMax = l.getDuration("WEBHOOK_BACKOFF_MAX", "1h")
+	cfg.Webhook.PollInterval = l.getDuration("WEBHOOK_POLL_INTERVAL", "1s")
+
+	// Настройки шины событий
+	cfg.Events.Source = l.getString("EVENTS_SOURCE", "auth-service")
+	cfg.Events.Sinks = l.getSlice("EVENTS_SINKS", []string{"webhook"})
+	cfg.Events.FilePath = l.getString("EVENTS_FILE", "events.jsonl")
+	cfg.Events.NotifyChannel = l.getString("EVENTS_NOTIFY_CHANNEL", "auth_events")
+	cfg.Events.PollInterval = l.getDuration("EVENTS_POLL_INTERVAL", "1s")
+
+	// Настройки административного API
+	cfg.Admin.Token = l.getSecret("ADMIN_TOKEN", "")
+	cfg.Admin.Port = l.getString("ADMIN_PORT", "")
+	cfg.Admin.OnMainPort = l.getBool("ADMIN_ON_MAIN_PORT", false)
+	cfg.Admin.TLSCertFile = l.getString("ADMIN_TLS_CERT_FILE", "")
+	cfg.Admin.TLSKeyFile = l.getString("ADMIN_TLS_KEY_FILE", "")
+	cfg.Admin.ClientCAFile = l.getString("ADMIN_TLS_CLIENT_CA_FILE", "")
+
+	// Настройки проверок готовности
+	cfg.Health.Timeout = l.getDuration("HEALTH_TIMEOUT", "2s")
+	cfg.Health.WebhookBacklogMax = l.getInt("HEALTH_WEBHOOK_BACKLOG_MAX", 1000)
+	cfg.Health.WebhookBacklogAge = l.getDuration("HEALTH_WEBHOOK_BACKLOG_AGE", "1m")
+
+	// Настройки логирования
+	cfg.Log.Level = l.getString("LOG_LEVEL", "info")
+	cfg.Log.Format = l.getString("LOG_FORMAT", "json")
+
+	// Настройки трассировки
+	cfg.Tracing.Exporter = l.getString("TRACING_EXPORTER", "none")
+	cfg.Tracing.ServiceName = l.getString("TRACING_SERVICE_NAME", "auth-service")
+	cfg.Tracing.Endpoint = l.getString("TRACING_OTLP_ENDPOINT", "localhost:4318")
+	cfg.Tracing.Insecure = l.getBool("TRACING_OTLP_INSECURE", false)
+	cfg.Tracing.FilePath = l.getString("TRACING_FILE", "traces.jsonl")
+	cfg.Tracing.SampleRatio = l.getFloat("TRACING_SAMPLE_RATIO", 1)
+
+	// Настройки перезагрузки конфигурации
+	cfg.Reload.WatchInterval = l.getDuration("CONFIG_WATCH_INTERVAL", "10s")
+
+	if err := l.err(); err != nil {
+		return nil, fmt.Errorf("ошибка загрузки конфигурации: %w", err)
 	}
-	cfg.JWT.RefreshExpiry = refreshExpiry
+	cfg.settings = l.settings
+	cfg.files = l.files
 
-	// Webhook URL
-	cfg.Webhook.URL = getEnv("WEBHOOK_URL", "")
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 
 	return cfg, nil
 }
 
-// GetConnectionString возвращает строку подключения к PostgreSQL
-func (dc *DatabaseConfig) GetConnectionString() string {
-	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		dc.Host, dc.Port, dc.User, dc.Password, dc.DBName, dc.SSLMode)
-}
-
-// getEnv получает значение переменной окружения или возвращает значение по умолчанию
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// Settings возвращает действующие значения параметров в порядке загрузки.
+// Значения секретов скрыты.
+func (c *Config) Settings() []Setting {
+	settings := make([]Setting, len(c.settings))
+	for i, setting := range c.settings {
+		if setting.Secret && setting.Value != "" {
+			setting.Value = redacted
+		}
+		settings[i] = setting
 	}
-	return value
+	return settings
 }
 
-// getEnvAsInt получает численное значение переменной окружения
-func getEnvAsInt(key string, defaultValue int) int {
-	valueStr := getEnv(key, "")
-	if value, err := strconv.Atoi(valueStr); err == nil {
-		return value
+// GetConnectionString возвращает строку подключения к PostgreSQL
+func (dc *DatabaseConfig) GetConnectionString() string {
+	if dc.DSN != "" {
+		return dc.DSN
 	}
-	return defaultValue
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		dc.Host, dc.Port, dc.User, dc.Password, dc.DBName, dc.SSLMode)
 }
@@ -0,0 +1,406 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDir создает рабочий каталог теста с файлами files и очищает переменные окружения
+// всех параметров, чтобы конфигурация зависела только от теста
+func testDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	// Пустая переменная окружения считается незаданной
+	t.Setenv("CONFIG_FILE", "")
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig в пустом каталоге: %v", err)
+	}
+	for _, setting := range cfg.settings {
+		t.Setenv(setting.Key, "")
+		t.Setenv(setting.Key+"_FILE", "")
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// setting возвращает действующее значение параметра key
+func setting(t *testing.T, cfg *Config, key string) Setting {
+	t.Helper()
+	for _, s := range cfg.Settings() {
+		if s.Key == key {
+			return s
+		}
+	}
+	t.Fatalf("параметр %s не загружен", key)
+	return Setting{}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		dotenv string
+		env    string
+		want   string
+		source string
+	}{
+		{name: "значение по умолчанию", want: "8080", source: SourceDefault},
+		{name: "файл конфигурации", file: "8001", want: "8001", source: SourceFile},
+		{name: ".env файл над файлом конфигурации", file: "8001", dotenv: "8002", want: "8002", source: SourceEnv},
+		{name: "окружение над .env файлом", file: "8001", dotenv: "8002", env: "8003", want: "8003", source: SourceEnv},
+		{name: "окружение над файлом конфигурации", file: "8001", env: "8003", want: "8003", source: SourceEnv},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{}
+			if tt.file != "" {
+				files["config.yaml"] = "server:\n  port: " + tt.file + "\n"
+			}
+			if tt.dotenv != "" {
+				files[".env"] = "SERVER_PORT=" + tt.dotenv + "\n"
+			}
+			dir := testDir(t, files)
+			if tt.file != "" {
+				t.Setenv("CONFIG_FILE", filepath.Join(dir, "config.yaml"))
+			}
+			if tt.env != "" {
+				t.Setenv("SERVER_PORT", tt.env)
+			}
+
+			cfg, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if cfg.Server.Port != tt.want {
+				t.Fatalf("SERVER_PORT = %s, ожидалось %s", cfg.Server.Port, tt.want)
+			}
+			if got := setting(t, cfg, "SERVER_PORT").Source; got != tt.source {
+				t.Fatalf("источник SERVER_PORT %s, ожидался %s", got, tt.source)
+			}
+		})
+	}
+
+	t.Run("CONFIG_FILE из .env файла", func(t *testing.T) {
+		testDir(t, map[string]string{
+			".env":        "CONFIG_FILE=config.toml\n",
+			"config.toml": "[server]\nport = 8001\n",
+		})
+		cfg, err := LoadConfig()
+		if err != nil {
+			t.Fatalf("LoadConfig: %v", err)
+		}
+		if cfg.Server.Port != "8001" {
+			t.Fatalf("SERVER_PORT = %s, ожидалось 8001", cfg.Server.Port)
+		}
+	})
+}
+
+func TestLoadConfigSecretFile(t *testing.T) {
+	const secret = "secret-from-file-0123456789abcdef"
+
+	tests := []struct {
+		name string
+		// configFile содержимое config.yaml; путь к файлу секрета подставляется вместо %s
+		configFile string
+		env        map[string]string
+		source     string
+		fail       string
+	}{
+		{
+			name:   "_FILE в окружении",
+			env:    map[string]string{"JWT_ACCESS_SECRET_FILE": "%s"},
+			source: SourceEnv + ":JWT_ACCESS_SECRET_FILE",
+		},
+		{
+			name:       "_FILE в файле конфигурации",
+			configFile: "jwt:\n  access_secret_file: %s\n",
+			source:     SourceFile + ":JWT_ACCESS_SECRET_FILE",
+		},
+		{
+			name:       "значение в окружении над _FILE в файле конфигурации",
+			configFile: "jwt:\n  access_secret_file: %s\n",
+			env:        map[string]string{"JWT_ACCESS_SECRET": "env-secret"},
+			source:     SourceEnv,
+		},
+		{
+			name: "значение и _FILE на одном уровне",
+			env:  map[string]string{"JWT_ACCESS_SECRET": "env-secret", "JWT_ACCESS_SECRET_FILE": "%s"},
+			fail: "заданы одновременно",
+		},
+		{
+			name: "файл секрета не существует",
+			env:  map[string]string{"JWT_ACCESS_SECRET_FILE": "%s.missing"},
+			fail: "ошибка чтения JWT_ACCESS_SECRET_FILE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := testDir(t, map[string]string{"access_secret": secret + "\n"})
+			path := filepath.Join(dir, "access_secret")
+			if tt.configFile != "" {
+				configPath := filepath.Join(dir, "config.yaml")
+				if err := os.WriteFile(configPath, []byte(strings.ReplaceAll(tt.configFile, "%s", path)), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				t.Setenv("CONFIG_FILE", configPath)
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, strings.ReplaceAll(value, "%s", path))
+			}
+
+			cfg, err := LoadConfig()
+			if tt.fail != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.fail) {
+					t.Fatalf("ожидалась ошибка %q, получено %v", tt.fail, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+
+			want := secret
+			if value, ok := tt.env["JWT_ACCESS_SECRET"]; ok {
+				want = value
+			}
+			if cfg.JWT.AccessSecret != want {
+				t.Fatalf("JWT_ACCESS_SECRET = %q, ожидалось %q", cfg.JWT.AccessSecret, want)
+			}
+			s := setting(t, cfg, "JWT_ACCESS_SECRET")
+			if s.Source != tt.source || s.Value != redacted {
+				t.Fatalf("параметр JWT_ACCESS_SECRET: %+v, ожидался источник %s и скрытое значение", s, tt.source)
+			}
+		})
+	}
+}
+
+func TestLoadConfigUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		fail    string
+	}{
+		{
+			name:    "известные параметры YAML",
+			file:    "config.yaml",
+			content: "server:\n  port: 8001\ntrusted_proxies: [10.0.0.0/8, 192.0.2.10]\nlog_level: debug\n",
+		},
+		{
+			name:    "опечатка во вложенном ключе",
+			file:    "config.yaml",
+			content: "server:\n  prot: 8001\n",
+			fail:    "неизвестный параметр файла конфигурации: SERVER_PROT",
+		},
+		{
+			name:    "неизвестный раздел TOML",
+			file:    "config.toml",
+			content: "[server]\nport = 8001\n\n[metrics]\nport = 9090\n",
+			fail:    "неизвестный параметр файла конфигурации: METRICS_PORT",
+		},
+		{
+			name:    "параметр, переопределенный окружением",
+			file:    "config.yaml",
+			content: "server:\n  port: 8001\n",
+			env:     map[string]string{"SERVER_PORT": "8003"},
+		},
+		{
+			name:    "параметр задан дважды",
+			file:    "config.yaml",
+			content: "server_port: 8001\nserver:\n  port: 8002\n",
+			fail:    "параметр SERVER_PORT задан несколько раз",
+		},
+		{
+			name:    "неизвестный формат",
+			file:    "config.json",
+			content: "{}",
+			fail:    "неизвестный формат файла конфигурации",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := testDir(t, map[string]string{tt.file: tt.content})
+			t.Setenv("CONFIG_FILE", filepath.Join(dir, tt.file))
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := LoadConfig()
+			if tt.fail == "" {
+				if err != nil {
+					t.Fatalf("LoadConfig: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.fail) {
+				t.Fatalf("ожидалась ошибка %q, получено %v", tt.fail, err)
+			}
+		})
+	}
+}
+
+func TestValidateProductionSecrets(t *testing.T) {
+	const (
+		accessSecret = "access-secret-0123456789abcdef-0123"
+		adminToken   = "admin-token-0123456789abcdef-012345"
+	)
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		fail   string
+	}{
+		{name: "надежные секреты", modify: func(cfg *Config) {}},
+		{
+			name:   "ключ подписи по умолчанию",
+			modify: func(cfg *Config) { cfg.JWT.AccessSecret = DefaultAccessSecret },
+			fail:   "JWT_ACCESS_SECRET: в рабочем режиме нельзя использовать значение по умолчанию",
+		},
+		{
+			name:   "короткий ключ подписи",
+			modify: func(cfg *Config) { cfg.JWT.AccessSecret = "short" },
+			fail:   "JWT_ACCESS_SECRET: в рабочем режиме требуется не менее 32 символов",
+		},
+		{
+			name:   "короткий предыдущий ключ подписи",
+			modify: func(cfg *Config) { cfg.JWT.PreviousAccessSecrets = []string{accessSecret + "-old", "short"} },
+			fail:   "JWT_ACCESS_PREVIOUS_SECRETS: в рабочем режиме требуется не менее 32 символов",
+		},
+		{
+			name:   "короткий токен администратора",
+			modify: func(cfg *Config) { cfg.Admin.Token = "short" },
+			fail:   "ADMIN_TOKEN: в рабочем режиме требуется не менее 32 символов",
+		},
+		{
+			name:   "пароль базы данных по умолчанию",
+			modify: func(cfg *Config) { cfg.Database.Driver, cfg.Database.Password = "postgres", "postgres" },
+			fail:   "DB_PASSWORD: в рабочем режиме нельзя использовать значение по умолчанию",
+		},
+		{
+			name:   "пароль по умолчанию при строке подключения",
+			modify: func(cfg *Config) { cfg.Database.Driver, cfg.Database.DSN = "postgres", "postgres://auth@db/auth" },
+		},
+		{
+			name: "значения по умолчанию в режиме разработки",
+			modify: func(cfg *Config) {
+				cfg.Environment = EnvironmentDevelopment
+				cfg.JWT.AccessSecret = DefaultAccessSecret
+				cfg.Admin.Token, cfg.Database.Driver = "short", "postgres"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDir(t, nil)
+			t.Setenv("APP_ENV", EnvironmentProduction)
+			t.Setenv("DB_DRIVER", "sqlite")
+			t.Setenv("JWT_ACCESS_SECRET", accessSecret)
+			t.Setenv("ADMIN_TOKEN", adminToken)
+			cfg, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+
+			tt.modify(cfg)
+			err = cfg.Validate()
+			if tt.fail == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.fail) {
+				t.Fatalf("ожидалась ошибка %q, получено %v", tt.fail, err)
+			}
+		})
+	}
+
+	t.Run("значения по умолчанию в рабочем режиме", func(t *testing.T) {
+		testDir(t, nil)
+		t.Setenv("APP_ENV", EnvironmentProduction)
+		_, err := LoadConfig()
+		for _, key := range []string{"JWT_ACCESS_SECRET", "DB_PASSWORD"} {
+			if err == nil || !strings.Contains(err.Error(), key+": в рабочем режиме нельзя использовать значение по умолчанию") {
+				t.Fatalf("ожидалась ошибка для %s, получено %v", key, err)
+			}
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		fail   string
+	}{
+		{name: "значения по умолчанию", modify: func(cfg *Config) {}},
+		{
+			name:   "время хранения сессий меньше времени жизни access токена",
+			modify: func(cfg *Config) { cfg.SessionGC.Retention, cfg.JWT.AccessExpiry = time.Minute, time.Hour },
+			fail:   "SESSION_GC_RETENTION не может быть меньше JWT_ACCESS_EXPIRY",
+		},
+		{
+			name:   "время хранения сессий равно времени жизни access токена",
+			modify: func(cfg *Config) { cfg.SessionGC.Retention, cfg.JWT.AccessExpiry = time.Hour, time.Hour },
+		},
+		{
+			name:   "DPoP с внешним адресом сервиса",
+			modify: func(cfg *Config) { cfg.DPoP.Enabled, cfg.DPoP.BaseURL = true, "https://auth.example.com" },
+		},
+		{
+			name:   "DPoP без внешнего адреса сервиса",
+			modify: func(cfg *Config) { cfg.DPoP.Enabled = true },
+			fail:   "DPOP_ENABLED требует DPOP_BASE_URL",
+		},
+		{
+			name:   "внешний адрес сервиса без схемы",
+			modify: func(cfg *Config) { cfg.DPoP.Enabled, cfg.DPoP.BaseURL = true, "auth.example.com" },
+			fail:   "DPOP_BASE_URL: ожидается адрес вида https://host[/путь]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testDir(t, nil)
+			cfg, err := LoadConfig()
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+
+			tt.modify(cfg)
+			err = cfg.Validate()
+			if tt.fail == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.fail) {
+				t.Fatalf("ожидалась ошибка %q, получено %v", tt.fail, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Источники значений параметров конфигурации
+const (
+	// SourceDefault значение по умолчанию
+	SourceDefault = "default"
+	// SourceFile файл конфигурации CONFIG_FILE
+	SourceFile = "file"
+	// SourceEnv переменная окружения или .env файл
+	SourceEnv = "env"
+)
+
+// dotenvFile файл с переменными окружения, который читается из рабочего каталога
+const dotenvFile = ".env"
+
+// redacted значение секретного параметра при выводе конфигурации
+const redacted = "***"
+
+// Setting действующее значение параметра конфигурации
+type Setting struct {
+	// Key имя переменной окружения параметра
+	Key   string
+	Value string
+	// Source источник значения; для секрета, прочитанного из файла, к нему добавляется
+	// имя переменной с суффиксом _FILE
+	Source string
+	// Secret значение скрывается при выводе
+	Secret bool
+}
+
+// loader читает параметры из переменных окружения, .env файла и файла конфигурации.
+// Переменные окружения имеют приоритет над .env файлом, .env файл — над файлом конфигурации,
+// файл конфигурации — над значениями по умолчанию. Ошибки разбора накапливаются,
+// чтобы сообщить обо всех некорректных параметрах сразу.
+type loader struct {
+	// dotenv значения .env файла
+	dotenv map[string]string
+	// file значения файла конфигурации по именам переменных окружения
+	file map[string]string
+	// used параметры файла, которые запрашивались при загрузке
+	used map[string]bool
+	// files прочитанные файлы: при их изменении конфигурация перезагружается
+	files    []string
+	settings []Setting
+	errs     []error
+}
+
+// newLoader создает загрузчик. Путь к файлу конфигурации задается в CONFIG_FILE;
+// .env файл не изменяет переменные окружения процесса, поэтому его изменения
+// учитываются при повторной загрузке.
+func newLoader() (*loader, error) {
+	dotenv, err := godotenv.Read(dotenvFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", dotenvFile, err)
+	}
+
+	l := &loader{
+		dotenv: dotenv,
+		file:   map[string]string{},
+		used:   map[string]bool{},
+		files:  []string{dotenvFile},
+	}
+
+	path := l.getenv("CONFIG_FILE")
+	if path == "" {
+		return l, nil
+	}
+
+	file, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l.file = file
+	l.files = append(l.files, path)
+	l.settings = append(l.settings, Setting{Key: "CONFIG_FILE", Value: path, Source: SourceEnv})
+	return l, nil
+}
+
+// fail запоминает ошибку разбора параметра
+func (l *loader) fail(err error) {
+	l.errs = append(l.errs, err)
+}
+
+// err возвращает накопленные ошибки и ошибку для параметров файла, которые не относятся ни к одной настройке
+func (l *loader) err() error {
+	var unknown []string
+	for key := range l.file {
+		if !l.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		l.fail(fmt.Errorf("неизвестный параметр файла конфигурации: %s", key))
+	}
+
+	return errors.Join(l.errs...)
+}
+
+// fileValue возвращает значение параметра из файла конфигурации
+func (l *loader) fileValue(key string) string {
+	l.used[key] = true
+	return l.file[key]
+}
+
+// getenv возвращает значение переменной окружения или .env файла
+func (l *loader) getenv(key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return l.dotenv[key]
+}
+
+// lookup ищет значение параметра в переменных окружения, затем в файле
+func (l *loader) lookup(key string) (value, source string, ok bool) {
+	fileValue := l.fileValue(key)
+	if value := l.getenv(key); value != "" {
+		return value, SourceEnv, true
+	}
+	if fileValue != "" {
+		return fileValue, SourceFile, true
+	}
+	return "", "", false
+}
+
+// lookupSecret ищет значение секрета так же, как lookup, но на каждом уровне секрет можно
+// передать и через файл, путь к которому задан в параметре с суффиксом _FILE (Docker и Kubernetes secrets)
+func (l *loader) lookupSecret(key string) (value, source string, ok bool) {
+	fileKey := key + "_FILE"
+	// Параметры файла, переопределенные окружением, также считаются известными
+	l.used[key], l.used[fileKey] = true, true
+
+	layers := []struct {
+		source string
+		get    func(string) string
+	}{
+		{SourceEnv, l.getenv},
+		{SourceFile, l.fileValue},
+	}
+
+	for _, layer := range layers {
+		value, path := layer.get(key), layer.get(fileKey)
+		switch {
+		case value != "" && path != "":
+			l.fail(fmt.Errorf("параметры %s и %s заданы одновременно", key, fileKey))
+			return "", "", false
+		case value != "":
+			return value, layer.source, true
+		case path != "":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				l.fail(fmt.Errorf("ошибка чтения %s: %w", fileKey, err))
+				return "", "", false
+			}
+			l.files = append(l.files, path)
+			// Файлы секретов обычно заканчиваются переводом строки
+			return strings.TrimRight(string(data), "\r\n"), layer.source + ":" + fileKey, true
+		}
+	}
+	return "", "", false
+}
+
+// get возвращает значение параметра или defaultValue и запоминает его источник
+func (l *loader) get(key, defaultValue string, secret bool) string {
+	var value, source string
+	var ok bool
+	if secret {
+		value, source, ok = l.lookupSecret(key)
+	} else {
+		value, source, ok = l.lookup(key)
+	}
+	if !ok {
+		value, source = defaultValue, SourceDefault
+	}
+
+	l.settings = append(l.settings, Setting{Key: key, Value: value, Source: source, Secret: secret})
+	return value
+}
+
+// getString получает строковое значение параметра
+func (l *loader) getString(key, defaultValue string) string {
+	return l.get(key, defaultValue, false)
+}
+
+// getSecret получает значение секретного параметра, который можно передать через файл
+func (l *loader) getSecret(key, defaultValue string) string {
+	return l.get(key, defaultValue, true)
+}
+
+// getInt получает численное значение параметра
+func (l *loader) getInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(l.getString(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		l.fail(fmt.Errorf("ошибка парсинга %s: %w", key, err))
+		return defaultValue
+	}
+	return value
+}
+
+// getBool получает логическое значение параметра
+func (l *loader) getBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(l.getString(key, strconv.FormatBool(defaultValue)))
+	if err != nil {
+		l.fail(fmt.Errorf("ошибка парсинга %s: %w", key, err))
+		return defaultValue
+	}
+	return value
+}
+
+// getDuration получает значение параметра в виде длительности
+func (l *loader) getDuration(key, defaultValue string) time.Duration {
+	value, err := time.ParseDuration(l.getString(key, defaultValue))
+	if err != nil {
+		l.fail(fmt.Errorf("ошибка парсинга %s: %w", key, err))
+		return 0
+	}
+	return value
+}
+
+// getFloat получает дробное значение параметра
+func (l *loader) getFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(l.getString(key, strconv.FormatFloat(defaultValue, 'g', -1, 64)), 64)
+	if err != nil {
+		l.fail(fmt.Errorf("ошибка парсинга %s: %w", key, err))
+		return defaultValue
+	}
+	return value
+}
+
+// getSlice получает список значений, разделенных запятыми
+func (l *loader) getSlice(key string, defaultValue []string) []string {
+	return splitList(l.getString(key, strings.Join(defaultValue, ",")))
+}
+
+// getSecretSlice получает список секретов, разделенных запятыми
+func (l *loader) getSecretSlice(key string) []string {
+	return splitList(l.getSecret(key, ""))
+}
+
+// splitList разбирает список значений, разделенных запятыми, пропуская пустые
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// readConfigFile читает файл конфигурации YAML или TOML. Имя параметра получается
+// соединением вложенных ключей через "_" в верхнем регистре, поэтому server: {port: 8080}
+// и server_port: 8080 задают SERVER_PORT.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла конфигурации: %w", err)
+	}
+
+	tree := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("неизвестный формат файла конфигурации %s: ожидается .yaml, .yml или .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла конфигурации %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flattenConfig("", tree, values); err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла конфигурации %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flattenConfig записывает значения дерева tree в values по именам переменных окружения
+func flattenConfig(prefix string, tree map[string]interface{}, values map[string]string) error {
+	for name, node := range tree {
+		key := strings.ToUpper(name)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		if subtree, ok := node.(map[string]interface{}); ok {
+			if err := flattenConfig(key, subtree, values); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, ok := values[key]; ok {
+			return fmt.Errorf("параметр %s задан несколько раз", key)
+		}
+
+		var value string
+		if list, ok := node.([]interface{}); ok {
+			items := make([]string, 0, len(list))
+			for _, item := range list {
+				text, err := scalarValue(key, item)
+				if err != nil {
+					return err
+				}
+				items = append(items, text)
+			}
+			value = strings.Join(items, ",")
+		} else {
+			var err error
+			if value, err = scalarValue(key, node); err != nil {
+				return err
+			}
+		}
+		values[key] = value
+	}
+	return nil
+}
+
+// scalarValue преобразует скалярное значение файла конфигурации в строку
+func scalarValue(key string, node interface{}) (string, error) {
+	switch value := node.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case time.Time:
+		return value.Format(time.RFC3339), nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(value), nil
+	default:
+		return "", fmt.Errorf("параметр %s: неподдерживаемое значение %v", key, node)
+	}
+}
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Инициаторы перезагрузки конфигурации
+const (
+	// ReloadSignal перезагрузка по сигналу SIGHUP
+	ReloadSignal = "signal"
+	// ReloadFile перезагрузка после изменения файла конфигурации, .env файла или файла секрета
+	ReloadFile = "file"
+	// ReloadKeysRotated перезагрузка по уведомлению о ротации ключей подписи (keys notify)
+	ReloadKeysRotated = "keys_rotated"
+)
+
+// reloadable параметры, новые значения которых применяются без перезапуска процесса.
+// Остальные параметры считываются только при запуске. Поля Config этих параметров
+// переносит в действующую конфигурацию applyReloadable.
+var reloadable = map[string]bool{
+	"JWT_ACCESS_SECRET":           true,
+	"JWT_ACCESS_PREVIOUS_SECRETS": true,
+	"JWT_ACCESS_EXPIRY":           true,
+	"JWT_REFRESH_EXPIRY":          true,
+	"JWT_REFRESH_REUSE_GRACE":     true,
+	"WEBHOOK_URL":                 true,
+	"WEBHOOK_SECRETS":             true,
+	"WEBHOOK_TIMEOUT":             true,
+	"WEBHOOK_MAX_ATTEMPTS":        true,
+	"WEBHOOK_BACKOFF_BASE":        true,
+	"WEBHOOK_BACKOFF_MAX":         true,
+	"WEBHOOK_POLL_INTERVAL":       true,
+	"EVENTS_SOURCE":               true,
+	"ADMIN_TOKEN":                 true,
+	"LOG_LEVEL":                   true,
+	"CONFIG_FILE":                 true,
+}
+
+// Store хранит действующую конфигурацию. При перезагрузке новая конфигурация
+// проверяется целиком и заменяет прежнюю атомарно, поэтому каждый запрос видит
+// согласованный набор параметров.
+type Store struct {
+	current atomic.Pointer[Config]
+
+	// mu упорядочивает перезагрузки
+	mu       sync.Mutex
+	handlers []func(cfg *Config)
+}
+
+// NewStore создает хранилище с конфигурацией cfg
+func NewStore(cfg *Config) *Store {
+	s := &Store{}
+	s.current.Store(cfg)
+	return s
+}
+
+// Load возвращает действующую конфигурацию. Возвращенное значение не изменяется.
+func (s *Store) Load() *Config {
+	return s.current.Load()
+}
+
+// OnReload добавляет обработчик, который вызывается после замены конфигурации
+func (s *Store) OnReload(handler func(cfg *Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append(s.handlers, handler)
+}
+
+// Reload загружает конфигурацию заново и, если она корректна и отличается от действующей,
+// применяет новые значения перезагружаемых параметров и вызывает обработчики OnReload.
+// Остальные параметры сохраняют значения, с которыми запущен процесс, и в изменениях
+// отмечаются как требующие перезапуска. Возвращает список изменений; при ошибке
+// продолжает действовать прежняя конфигурация.
+func (s *Store) Reload() ([]models.ConfigChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loaded, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	current := s.current.Load()
+	cfg := applyReloadable(current, loaded)
+	// Новые значения могут противоречить параметрам, которые изменятся только после перезапуска
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("новые значения несовместимы с действующей конфигурацией: %w", err)
+	}
+
+	changes := Diff(current, loaded)
+	// Значения не изменились, но отпечатки файлов для Watch обновляются
+	s.current.Store(cfg)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	for _, handler := range s.handlers {
+		handler(cfg)
+	}
+	return changes, nil
+}
+
+// applyReloadable возвращает копию действующей конфигурации current, в которой значения
+// перезагружаемых параметров заменены значениями из loaded
+func applyReloadable(current, loaded *Config) *Config {
+	cfg := *current
+
+	cfg.JWT.AccessSecret = loaded.JWT.AccessSecret
+	cfg.JWT.PreviousAccessSecrets = loaded.JWT.PreviousAccessSecrets
+	cfg.JWT.AccessExpiry = loaded.JWT.AccessExpiry
+	cfg.JWT.RefreshExpiry = loaded.JWT.RefreshExpiry
+	cfg.JWT.RefreshReuseGrace = loaded.JWT.RefreshReuseGrace
+	cfg.Webhook.URL = loaded.Webhook.URL
+	cfg.Webhook.Secrets = loaded.Webhook.Secrets
+	cfg.Webhook.Timeout = loaded.Webhook.Timeout
+	cfg.Webhook.MaxAttempts = loaded.Webhook.MaxAttempts
+	cfg.Webhook.BackoffBase = loaded.Webhook.BackoffBase
+	cfg.Webhook.BackoffMax = loaded.Webhook.BackoffMax
+	cfg.Webhook.PollInterval = loaded.Webhook.PollInterval
+	cfg.Events.Source = loaded.Events.Source
+	cfg.Admin.Token = loaded.Admin.Token
+	cfg.Log.Level = loaded.Log.Level
+	// Изменения CONFIG_FILE и файлов секретов отслеживаются по новому списку файлов
+	cfg.files = loaded.files
+
+	previous := make(map[string]Setting, len(current.settings))
+	for _, setting := range current.settings {
+		previous[setting.Key] = setting
+	}
+	cfg.settings = make([]Setting, 0, len(loaded.settings))
+	for _, setting := range loaded.settings {
+		if before, ok := previous[setting.Key]; ok && !reloadable[setting.Key] {
+			setting = before
+		}
+		cfg.settings = append(cfg.settings, setting)
+	}
+	return &cfg
+}
+
+// Watch проверяет изменения файлов, из которых прочитана конфигурация, каждые interval
+// и вызывает reload до отмены ctx
+func (s *Store) Watch(ctx context.Context, interval time.Duration, reload func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	state := fileState(s.Load().files)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if fileState(s.Load().files) == state {
+			continue
+		}
+		reload()
+		// После ошибки перезагрузки файлы не перечитываются, пока они снова не изменятся
+		state = fileState(s.Load().files)
+	}
+}
+
+// Diff возвращает параметры, значения которых различаются в old и cfg
+func Diff(old, cfg *Config) []models.ConfigChange {
+	previous := make(map[string]Setting, len(old.settings))
+	for _, setting := range old.settings {
+		previous[setting.Key] = setting
+	}
+
+	var changes []models.ConfigChange
+	for _, setting := range cfg.settings {
+		before, ok := previous[setting.Key]
+		delete(previous, setting.Key)
+		if ok && before.Value == setting.Value {
+			continue
+		}
+		changes = append(changes, newChange(setting.Key, before, setting))
+	}
+	// Параметры, которые перестали задаваться, например CONFIG_FILE
+	for _, setting := range old.settings {
+		if before, ok := previous[setting.Key]; ok {
+			changes = append(changes, newChange(setting.Key, before, Setting{Secret: before.Secret}))
+		}
+	}
+	return changes
+}
+
+// newChange описывает изменение параметра key, скрывая значения секретов
+func newChange(key string, before, after Setting) models.ConfigChange {
+	change := models.ConfigChange{
+		Key:     key,
+		Old:     before.Value,
+		New:     after.Value,
+		Restart: !reloadable[key],
+	}
+	if before.Secret || after.Secret {
+		change.Old, change.New = redact(change.Old), redact(change.New)
+	}
+	return change
+}
+
+// redact скрывает непустое значение секрета
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redacted
+}
+
+// fileState возвращает отпечаток времени изменения и размера файлов
+func fileState(files []string) string {
+	var state strings.Builder
+	for _, path := range files {
+		state.WriteString(path)
+		if info, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&state, " %d %d", info.ModTime().UnixNano(), info.Size())
+		}
+		state.WriteString("\n")
+	}
+	return state.String()
+}
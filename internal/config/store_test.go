@@ -0,0 +1,205 @@
+package config
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  []Setting
+		new  []Setting
+		want []models.ConfigChange
+	}{
+		{
+			name: "без изменений",
+			old:  []Setting{{Key: "SERVER_PORT", Value: "8080"}},
+			new:  []Setting{{Key: "SERVER_PORT", Value: "8080"}},
+		},
+		{
+			name: "изменение перезагружаемого параметра",
+			old:  []Setting{{Key: "LOG_LEVEL", Value: "info"}},
+			new:  []Setting{{Key: "LOG_LEVEL", Value: "debug"}},
+			want: []models.ConfigChange{{Key: "LOG_LEVEL", Old: "info", New: "debug"}},
+		},
+		{
+			name: "изменение параметра, требующего перезапуска",
+			old:  []Setting{{Key: "SERVER_PORT", Value: "8080"}},
+			new:  []Setting{{Key: "SERVER_PORT", Value: "8081"}},
+			want: []models.ConfigChange{{Key: "SERVER_PORT", Old: "8080", New: "8081", Restart: true}},
+		},
+		{
+			name: "значение секрета скрыто",
+			old:  []Setting{{Key: "ADMIN_TOKEN", Secret: true}},
+			new:  []Setting{{Key: "ADMIN_TOKEN", Value: "token", Secret: true}},
+			want: []models.ConfigChange{{Key: "ADMIN_TOKEN", New: redacted}},
+		},
+		{
+			name: "новый параметр",
+			new:  []Setting{{Key: "CONFIG_FILE", Value: "config.yaml"}},
+			want: []models.ConfigChange{{Key: "CONFIG_FILE", New: "config.yaml"}},
+		},
+		{
+			name: "параметр больше не задается",
+			old:  []Setting{{Key: "CONFIG_FILE", Value: "config.yaml"}},
+			want: []models.ConfigChange{{Key: "CONFIG_FILE", Old: "config.yaml"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(&Config{settings: tt.old}, &Config{settings: tt.new})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Diff = %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReload(t *testing.T) {
+	testDir(t, nil)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	store := NewStore(cfg)
+	var reloaded []*Config
+	store.OnReload(func(cfg *Config) { reloaded = append(reloaded, cfg) })
+
+	t.Run("без изменений", func(t *testing.T) {
+		changes, err := store.Reload()
+		if err != nil || len(changes) != 0 || len(reloaded) != 0 {
+			t.Fatalf("Reload = %+v, %v; обработчик вызван %d раз", changes, err, len(reloaded))
+		}
+	})
+
+	t.Run("параметр, требующий перезапуска, сохраняет действующее значение", func(t *testing.T) {
+		t.Setenv("SERVER_PORT", "8081")
+		t.Setenv("JWT_ACCESS_EXPIRY", "5m")
+		changes, err := store.Reload()
+		if err != nil {
+			t.Fatalf("Reload: %v", err)
+		}
+		want := []models.ConfigChange{
+			{Key: "SERVER_PORT", Old: "8080", New: "8081", Restart: true},
+			{Key: "JWT_ACCESS_EXPIRY", Old: setting(t, cfg, "JWT_ACCESS_EXPIRY").Value, New: "5m"},
+		}
+		if !sameChanges(changes, want) {
+			t.Fatalf("изменения %+v, ожидалось %+v", changes, want)
+		}
+
+		current := store.Load()
+		if current.JWT.AccessExpiry != 5*time.Minute {
+			t.Fatalf("JWT_ACCESS_EXPIRY = %s, ожидалось 5m", current.JWT.AccessExpiry)
+		}
+		if current.Server.Port != "8080" {
+			t.Fatalf("SERVER_PORT = %s, ожидалось действующее значение 8080", current.Server.Port)
+		}
+		if got := setting(t, current, "SERVER_PORT"); got.Value != "8080" || got.Source != SourceDefault {
+			t.Fatalf("параметр SERVER_PORT %+v, ожидалось действующее значение 8080", got)
+		}
+		if got := setting(t, current, "JWT_ACCESS_EXPIRY"); got.Value != "5m" || got.Source != SourceEnv {
+			t.Fatalf("параметр JWT_ACCESS_EXPIRY %+v, ожидалось 5m из окружения", got)
+		}
+		if len(reloaded) != 1 || reloaded[0] != current {
+			t.Fatalf("обработчик вызван %d раз, ожидался один вызов с действующей конфигурацией", len(reloaded))
+		}
+	})
+
+	t.Run("некорректная конфигурация отклоняется", func(t *testing.T) {
+		previous := store.Load()
+		t.Setenv("LOG_LEVEL", "verbose")
+		if _, err := store.Reload(); err == nil {
+			t.Fatal("Reload с некорректным LOG_LEVEL выполнен без ошибки")
+		}
+		if store.Load() != previous {
+			t.Fatal("после ошибки перезагрузки конфигурация заменена")
+		}
+	})
+
+	t.Run("несовместимость с действующими значениями отклоняется", func(t *testing.T) {
+		previous := store.Load()
+		// Новое время хранения сессий применится только после перезапуска, а действующее
+		// меньше нового времени жизни access токенов
+		t.Setenv("SESSION_GC_RETENTION", "1000h")
+		t.Setenv("JWT_ACCESS_EXPIRY", "500h")
+		if _, err := store.Reload(); err == nil {
+			t.Fatal("Reload с JWT_ACCESS_EXPIRY больше действующего SESSION_GC_RETENTION выполнен без ошибки")
+		}
+		if store.Load() != previous {
+			t.Fatal("после ошибки перезагрузки конфигурация заменена")
+		}
+	})
+}
+
+// sameChanges сравнивает списки изменений без учета порядка
+func sameChanges(got, want []models.ConfigChange) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	keys := make(map[models.ConfigChange]bool, len(want))
+	for _, change := range want {
+		keys[change] = true
+	}
+	for _, change := range got {
+		if !keys[change] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWatch(t *testing.T) {
+	dir := testDir(t, map[string]string{"config.yaml": "log:\n  level: info\n"})
+	path := filepath.Join(dir, "config.yaml")
+	t.Setenv("CONFIG_FILE", path)
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	store := NewStore(cfg)
+
+	reloads := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		store.Watch(ctx, 10*time.Millisecond, func() {
+			if _, err := store.Reload(); err != nil {
+				t.Errorf("Reload: %v", err)
+			}
+			select {
+			case reloads <- struct{}{}:
+			default:
+			}
+		})
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case <-reloads:
+		t.Fatal("перезагрузка без изменения файла")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(path, []byte("log:\n  level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reloads:
+	case <-time.After(time.Second):
+		t.Fatal("изменение файла конфигурации не вызвало перезагрузку")
+	}
+	if level := store.Load().Log.Level; level != "debug" {
+		t.Fatalf("LOG_LEVEL = %s, ожидалось debug", level)
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"auth-service/internal/clientip"
+	"auth-service/pkg/webhooksig"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// minSecretLength минимальная длина ключей подписи и токена администратора в рабочем режиме
+const minSecretLength = 32
+
+// Validate проверяет согласованность параметров. В рабочем режиме дополнительно
+// запрещены ключи подписи по умолчанию, короткие секреты и пароль базы данных по умолчанию.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Environment == EnvironmentDevelopment || c.Environment == EnvironmentProduction,
+		"APP_ENV: ожидается %s или %s, получено %q", EnvironmentDevelopment, EnvironmentProduction, c.Environment)
+
+	check(validPort(c.Server.Port), "SERVER_PORT: некорректный порт %q", c.Server.Port)
+	if _, err := clientip.NewResolver(c.Server.TrustedProxies, c.Server.TrustedProxyHeader); err != nil {
+		check(false, "TRUSTED_PROXIES, TRUSTED_PROXY_HEADER: %v", err)
+	}
+	check(c.Server.ShutdownDelay >= 0, "SHUTDOWN_DELAY не может быть отрицательным")
+	check(c.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT должен быть положительным")
+	check((c.Server.TLSCertFile == "") == (c.Server.TLSKeyFile == ""),
+		"TLS_CERT_FILE и TLS_KEY_FILE задаются вместе")
+	check(c.Server.ClientCAFile == "" || c.Server.TLSCertFile != "",
+		"TLS_CLIENT_CA_FILE требует TLS_CERT_FILE и TLS_KEY_FILE")
+	check(!c.Server.RequireClientCert || c.Server.ClientCAFile != "",
+		"TLS_CLIENT_CERT_REQUIRED требует TLS_CLIENT_CA_FILE")
+
+	switch c.Database.Driver {
+	case "postgres", "sqlite", "memory":
+	default:
+		check(false, "DB_DRIVER: неизвестное хранилище %q", c.Database.Driver)
+	}
+	check(c.Database.QueryTimeout >= 0, "DB_QUERY_TIMEOUT не может быть отрицательным")
+
+	check(c.SessionGC.Interval >= 0, "SESSION_GC_INTERVAL не может быть отрицательным")
+	check(c.SessionGC.Retention >= 0, "SESSION_GC_RETENTION не может быть отрицательным")
+	check(c.SessionGC.BatchSize > 0, "SESSION_GC_BATCH_SIZE должен быть положительным")
+	check(c.Revocation.ResyncInterval > 0, "REVOCATION_RESYNC_INTERVAL должен быть положительным")
+	check(c.Cache.RedisURL == "" || c.Cache.SessionTTL > 0, "CACHE_SESSION_TTL должен быть положительным")
+
+	check(c.JWT.AccessSecret != "", "JWT_ACCESS_SECRET не задан")
+	check(c.JWT.AccessExpiry > 0, "JWT_ACCESS_EXPIRY должен быть положительным")
+	check(c.JWT.RefreshExpiry > 0, "JWT_REFRESH_EXPIRY должен быть положительным")
+	check(c.JWT.RefreshReuseGrace >= 0, "JWT_REFRESH_REUSE_GRACE не может быть отрицательным")
+	// Заблокированная сессия хранится, пока действуют выданные по ней access токены:
+	// иначе отзыв пропадет из хранилища и не восстановится при синхронизации
+	check(c.SessionGC.Retention >= c.JWT.AccessExpiry, "SESSION_GC_RETENTION не может быть меньше JWT_ACCESS_EXPIRY")
+
+	check(c.DPoP.ProofLifetime > 0, "DPOP_PROOF_LIFETIME должен быть положительным")
+	check(!c.DPoP.Enabled || c.DPoP.BaseURL != "", "DPOP_ENABLED требует DPOP_BASE_URL - внешний адрес сервиса")
+	if c.DPoP.BaseURL != "" {
+		base, err := url.Parse(c.DPoP.BaseURL)
+		check(err == nil && (base.Scheme == "http" || base.Scheme == "https") && base.Host != "" && base.RawQuery == "" && base.Fragment == "",
+			"DPOP_BASE_URL: ожидается адрес вида https://host[/путь], получено %q", c.DPoP.BaseURL)
+	}
+
+	if _, err := webhooksig.NewSigner(c.Webhook.Secrets); err != nil {
+		check(false, "WEBHOOK_SECRETS: %v", err)
+	}
+	check(c.Webhook.Timeout > 0, "WEBHOOK_TIMEOUT должен быть положительным")
+	check(c.Webhook.Workers > 0, "WEBHOOK_WORKERS должен быть положительным")
+	check(c.Webhook.MaxAttempts > 0, "WEBHOOK_MAX_ATTEMPTS должен быть положительным")
+	check(c.Webhook.BackoffBase > 0, "WEBHOOK_BACKOFF_BASE должен быть положительным")
+	check(c.Webhook.BackoffMax >= c.Webhook.BackoffBase, "WEBHOOK_BACKOFF_MAX не может быть меньше WEBHOOK_BACKOFF_BASE")
+	check(c.Webhook.PollInterval > 0, "WEBHOOK_POLL_INTERVAL должен быть положительным")
+	check(c.Events.PollInterval > 0, "EVENTS_POLL_INTERVAL должен быть положительным")
+
+	if c.Admin.Port != "" {
+		check(validPort(c.Admin.Port), "ADMIN_PORT: некорректный порт %q", c.Admin.Port)
+		check(c.Admin.Port != c.Server.Port, "ADMIN_PORT совпадает с SERVER_PORT")
+		check(!c.Admin.OnMainPort, "ADMIN_ON_MAIN_PORT несовместим с ADMIN_PORT")
+	}
+	check((c.Admin.TLSCertFile == "") == (c.Admin.TLSKeyFile == ""),
+		"ADMIN_TLS_CERT_FILE и ADMIN_TLS_KEY_FILE задаются вместе")
+	check(c.Admin.ClientCAFile == "" || c.Admin.TLSCertFile != "",
+		"ADMIN_TLS_CLIENT_CA_FILE требует ADMIN_TLS_CERT_FILE и ADMIN_TLS_KEY_FILE")
+
+	check(c.Health.Timeout > 0, "HEALTH_TIMEOUT должен быть положительным")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO должен быть от 0 до 1")
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "LOG_LEVEL: неизвестный уровень %q", c.Log.Level)
+	switch strings.ToLower(c.Log.Format) {
+	case "json", "text":
+	default:
+		check(false, "LOG_FORMAT: неизвестный формат %q", c.Log.Format)
+	}
+	check(c.Reload.WatchInterval >= 0, "CONFIG_WATCH_INTERVAL не может быть отрицательным")
+
+	if c.Environment == EnvironmentProduction {
+		errs = append(errs, c.validateProductionSecrets()...)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("некорректная конфигурация: %w", err)
+	}
+	return nil
+}
+
+// validateProductionSecrets проверяет секреты, с которыми нельзя запускать сервис в рабочем режиме
+func (c *Config) validateProductionSecrets() []error {
+	var errs []error
+	secret := func(key, value, defaultValue string) {
+		switch {
+		case value == defaultValue:
+			errs = append(errs, fmt.Errorf("%s: в рабочем режиме нельзя использовать значение по умолчанию", key))
+		case len(value) < minSecretLength:
+			errs = append(errs, fmt.Errorf("%s: в рабочем режиме требуется не менее %d символов", key, minSecretLength))
+		}
+	}
+
+	secret("JWT_ACCESS_SECRET", c.JWT.AccessSecret, DefaultAccessSecret)
+	for _, previous := range c.JWT.PreviousAccessSecrets {
+		secret("JWT_ACCESS_PREVIOUS_SECRETS", previous, DefaultAccessSecret)
+	}
+	if c.Admin.Token != "" {
+		secret("ADMIN_TOKEN", c.Admin.Token, "")
+	}
+	if c.Database.Driver == "postgres" && c.Database.DSN == "" && c.Database.Password == "postgres" {
+		errs = append(errs, errors.New("DB_PASSWORD: в рабочем режиме нельзя использовать значение по умолчанию"))
+	}
+
+	return errs
+}
+
+// validPort проверяет номер TCP порта
+func validPort(port string) bool {
+	number, err := strconv.Atoi(port)
+	return err == nil && number > 0 && number <= 65535
+}
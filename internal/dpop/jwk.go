@@ -0,0 +1,120 @@
+package dpop
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// minRSABits минимальная длина ключа RSA
+const minRSABits = 2048
+
+// jwk открытый ключ в формате JSON Web Key (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	// D закрытая часть ключа EC и OKP; в доказательстве не допускается
+	D string `json:"d,omitempty"`
+	// P закрытая часть ключа RSA; в доказательстве не допускается
+	P string `json:"p,omitempty"`
+}
+
+// ecCurves кривые ключей EC
+var ecCurves = map[string]struct {
+	curve elliptic.Curve
+	ecdh  ecdh.Curve
+}{
+	"P-256": {elliptic.P256(), ecdh.P256()},
+	"P-384": {elliptic.P384(), ecdh.P384()},
+	"P-521": {elliptic.P521(), ecdh.P521()},
+}
+
+// parseJWK читает открытый ключ из заголовка jwk доказательства
+func parseJWK(value interface{}) (*jwk, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный заголовок jwk: %w", err)
+	}
+	var key jwk
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, fmt.Errorf("некорректный заголовок jwk: %w", err)
+	}
+	if key.D != "" || key.P != "" {
+		return nil, errors.New("заголовок jwk содержит закрытый ключ")
+	}
+	return &key, nil
+}
+
+// publicKey возвращает ключ для проверки подписи
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "EC":
+		curve, ok := ecCurves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		size := (curve.curve.Params().BitSize + 7) / 8
+		if errX != nil || errY != nil || len(x) != size || len(y) != size {
+			return nil, errors.New("некорректные координаты ключа EC")
+		}
+		// Несжатая форма точки проверяется на принадлежность кривой
+		if _, err := curve.ecdh.NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("некорректный ключ EC: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve.curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("некорректные параметры ключа RSA")
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSABits || key.E < 3 || key.E%2 == 0 {
+			return nil, fmt.Errorf("ключ RSA должен быть не короче %d бит с нечетной экспонентой", minRSABits)
+		}
+		return key, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("неподдерживаемая кривая %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("некорректный ключ Ed25519")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа %q", k.Kty)
+	}
+}
+
+// thumbprint возвращает SHA-256 отпечаток ключа (RFC 7638) в base64url без дополнения
+func (k *jwk) thumbprint() string {
+	// Отпечаток вычисляется по обязательным членам ключа; json.Marshal упорядочивает ключи map
+	members := map[string]string{"kty": k.Kty}
+	switch k.Kty {
+	case "EC":
+		members["crv"], members["x"], members["y"] = k.Crv, k.X, k.Y
+	case "RSA":
+		members["n"], members["e"] = k.N, k.E
+	case "OKP":
+		members["crv"], members["x"] = k.Crv, k.X
+	}
+	data, _ := json.Marshal(members)
+	hash := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
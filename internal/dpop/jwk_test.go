@@ -0,0 +1,119 @@
+package dpop
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func TestThumbprint(t *testing.T) {
+	// Пример из RFC 7638, раздел 3.1: члены, не входящие в отпечаток, не учитываются
+	const example = `{
+		"kty": "RSA",
+		"n": "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		"e": "AQAB",
+		"alg": "RS256",
+		"kid": "2011-04-29"
+	}`
+	const want = "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+
+	var header interface{}
+	if err := json.Unmarshal([]byte(example), &header); err != nil {
+		t.Fatal(err)
+	}
+	key, err := parseJWK(header)
+	if err != nil {
+		t.Fatalf("parseJWK: %v", err)
+	}
+	if _, err := key.publicKey(); err != nil {
+		t.Fatalf("publicKey: %v", err)
+	}
+	if got := key.thumbprint(); got != want {
+		t.Fatalf("thumbprint = %s, ожидался %s", got, want)
+	}
+}
+
+func TestPublicKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		jwk   map[string]interface{}
+		valid bool
+	}{
+		{
+			name:  "EC P-256",
+			jwk:   map[string]interface{}{"kty": "EC", "crv": "P-256", "x": "f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU", "y": "x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5a0"},
+			valid: true,
+		},
+		{
+			name: "EC точка вне кривой",
+			jwk:  map[string]interface{}{"kty": "EC", "crv": "P-256", "x": "f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU", "y": "x_FEzRu9m36HLN_tue659LNpXW6pCyStikYjKIWI5b0"},
+		},
+		{
+			name: "EC неизвестная кривая",
+			jwk:  map[string]interface{}{"kty": "EC", "crv": "secp256k1", "x": "AA", "y": "AA"},
+		},
+		{
+			name:  "Ed25519",
+			jwk:   map[string]interface{}{"kty": "OKP", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+			valid: true,
+		},
+		{
+			name: "X25519",
+			jwk:  map[string]interface{}{"kty": "OKP", "crv": "X25519", "x": "hSDwCYkwp1R0i33ctD73Wg2_Og0mOBr066SpjqqbTmo"},
+		},
+		{
+			name: "RSA короче 2048 бит",
+			jwk:  map[string]interface{}{"kty": "RSA", "n": shortModulus(t), "e": "AQAB"},
+		},
+		{
+			name: "RSA с четной экспонентой",
+			jwk:  map[string]interface{}{"kty": "RSA", "n": "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw", "e": "AQAA"},
+		},
+		{
+			name: "симметричный ключ",
+			jwk:  map[string]interface{}{"kty": "oct", "k": "c2VjcmV0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := parseJWK(tt.jwk)
+			if err == nil {
+				_, err = key.publicKey()
+			}
+			if (err == nil) != tt.valid {
+				t.Fatalf("ошибка %v, ожидался корректный ключ: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestParseJWKPrivateKey(t *testing.T) {
+	tests := []struct {
+		name string
+		jwk  map[string]interface{}
+	}{
+		{"EC с d", map[string]interface{}{"kty": "EC", "crv": "P-256", "x": "AA", "y": "AA", "d": "AA"}},
+		{"OKP с d", map[string]interface{}{"kty": "OKP", "crv": "Ed25519", "x": "AA", "d": "AA"}},
+		{"RSA с p", map[string]interface{}{"kty": "RSA", "n": "AA", "e": "AQAB", "p": "AA"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseJWK(tt.jwk); err == nil {
+				t.Fatal("ключ с закрытой частью принят")
+			}
+		})
+	}
+}
+
+// shortModulus возвращает модуль ключа RSA длиной 1024 бита в base64url
+func shortModulus(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+}
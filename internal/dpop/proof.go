@@ -0,0 +1,167 @@
+package dpop
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// HeaderName заголовок запроса с доказательством владения ключом
+const HeaderName = "DPoP"
+
+// Scheme схема заголовка Authorization, в которой предъявляются привязанные access токены
+const Scheme = "DPoP"
+
+// proofType значение заголовка typ доказательства
+const proofType = "dpop+jwt"
+
+// Algorithms алгоритмы подписи доказательств; симметричные алгоритмы не допускаются
+var Algorithms = []string{"ES256", "ES384", "ES512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "EdDSA"}
+
+// ErrInvalidProof возвращается, если доказательство некорректно или не соответствует запросу
+var ErrInvalidProof = errors.New("невалидное DPoP доказательство")
+
+// ErrReplayCheckUnavailable возвращается, если повторное использование доказательства
+// невозможно проверить из-за недоступности хранилища идентификаторов
+var ErrReplayCheckUnavailable = errors.New("проверка повторного использования DPoP доказательства недоступна")
+
+// proofClaims claims доказательства
+type proofClaims struct {
+	// Method HTTP метод запроса (htm)
+	Method string `json:"htm"`
+	// URI адрес запроса без параметров (htu)
+	URI string `json:"htu"`
+	// AccessTokenHash SHA-256 хеш предъявленного access токена (ath)
+	AccessTokenHash string `json:"ath,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Verifier проверяет DPoP доказательства (RFC 9449): подпись ключом из заголовка jwk,
+// соответствие запросу, время создания и однократность использования
+type Verifier struct {
+	// lifetime допустимое отклонение iat от текущего времени
+	lifetime time.Duration
+	replay   ReplayCache
+	// failOpen принимает доказательства при недоступности replay
+	failOpen bool
+	parser   *jwt.Parser
+}
+
+// NewVerifier создает проверку доказательств, созданных не дальше lifetime от текущего времени.
+// Идентификаторы доказательств запоминаются в replay. Если replay недоступен, доказательства
+// отклоняются с ErrReplayCheckUnavailable или, при failOpen, принимаются без проверки.
+func NewVerifier(lifetime time.Duration, replay ReplayCache, failOpen bool) *Verifier {
+	return &Verifier{
+		lifetime: lifetime,
+		replay:   replay,
+		failOpen: failOpen,
+		// Время создания проверяется отдельно, а exp в доказательстве не используется
+		parser: jwt.NewParser(jwt.WithValidMethods(Algorithms), jwt.WithoutClaimsValidation()),
+	}
+}
+
+// Verify проверяет доказательство proof запроса method к uri и возвращает SHA-256 отпечаток
+// ключа, которым оно подписано (jkt). Если accessToken не пуст, доказательство должно
+// содержать его хеш. Возвращает ошибку, совместимую с ErrInvalidProof или
+// ErrReplayCheckUnavailable через errors.Is.
+func (v *Verifier) Verify(ctx context.Context, proof, method, uri, accessToken string) (string, error) {
+	var key *jwk
+	claims := &proofClaims{}
+	_, err := v.parser.ParseWithClaims(proof, claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != proofType {
+			return nil, fmt.Errorf("заголовок typ должен быть %s", proofType)
+		}
+		var err error
+		if key, err = parseJWK(token.Header["jwk"]); err != nil {
+			return nil, err
+		}
+		return key.publicKey()
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+
+	if claims.ID == "" {
+		return "", fmt.Errorf("%w: отсутствует jti", ErrInvalidProof)
+	}
+	if claims.Method != method {
+		return "", fmt.Errorf("%w: htm не совпадает с методом запроса", ErrInvalidProof)
+	}
+	if expected, err := normalizeURI(uri); err != nil || !sameURI(claims.URI, expected) {
+		return "", fmt.Errorf("%w: htu не совпадает с адресом запроса", ErrInvalidProof)
+	}
+	if claims.IssuedAt == nil {
+		return "", fmt.Errorf("%w: отсутствует iat", ErrInvalidProof)
+	}
+	if age := time.Since(claims.IssuedAt.Time); age > v.lifetime || age < -v.lifetime {
+		return "", fmt.Errorf("%w: iat отличается от текущего времени больше чем на %s", ErrInvalidProof, v.lifetime)
+	}
+	if accessToken != "" && claims.AccessTokenHash != hash(accessToken) {
+		return "", fmt.Errorf("%w: ath не совпадает с access токеном", ErrInvalidProof)
+	}
+
+	jkt := key.thumbprint()
+
+	// Доказательство с принятым iat хранится, пока iat не выйдет из допустимого окна
+	fresh, err := v.replay.Use(ctx, jkt+":"+hash(claims.ID), 2*v.lifetime)
+	if err != nil {
+		if !v.failOpen {
+			return "", fmt.Errorf("%w: %v", ErrReplayCheckUnavailable, err)
+		}
+		slog.WarnContext(ctx, "Ошибка проверки повторного использования DPoP доказательства", slog.Any("error", err))
+	} else if !fresh {
+		return "", fmt.Errorf("%w: доказательство уже использовано", ErrInvalidProof)
+	}
+
+	return jkt, nil
+}
+
+// sameURI сравнивает htu доказательства с нормализованным адресом запроса expected
+func sameURI(htu, expected string) bool {
+	normalized, err := normalizeURI(htu)
+	return err == nil && normalized == expected
+}
+
+// normalizeURI приводит адрес к виду для сравнения (RFC 9449, раздел 4.3): схема и хост
+// в нижнем регистре, без порта по умолчанию, параметров и фрагмента
+func normalizeURI(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if (scheme != "http" && scheme != "https") || u.Host == "" {
+		return "", errors.New("ожидается абсолютный адрес http или https")
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return scheme + "://" + host + path, nil
+}
+
+// hash возвращает SHA-256 хеш значения в base64url без дополнения
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
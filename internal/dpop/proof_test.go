@@ -0,0 +1,344 @@
+package dpop
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testMethod   = "POST"
+	testURI      = "https://auth.example.com/api/v1/refresh"
+	testLifetime = time.Minute
+)
+
+// testKey ключ клиента, которым подписываются доказательства
+type testKey struct {
+	private *ecdsa.PrivateKey
+	jwk     map[string]interface{}
+}
+
+func newTestKey(t *testing.T) *testKey {
+	t.Helper()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coordinate := func(value []byte) string { return base64.RawURLEncoding.EncodeToString(value) }
+	return &testKey{
+		private: private,
+		jwk: map[string]interface{}{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   coordinate(private.X.FillBytes(make([]byte, 32))),
+			"y":   coordinate(private.Y.FillBytes(make([]byte, 32))),
+		},
+	}
+}
+
+// jkt возвращает ожидаемый отпечаток ключа
+func (k *testKey) jkt(t *testing.T) string {
+	t.Helper()
+	key, err := parseJWK(k.jwk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key.thumbprint()
+}
+
+var proofCounter atomic.Int64
+
+// proofClaimsFor возвращает claims корректного доказательства для testMethod и testURI
+func proofClaimsFor() jwt.MapClaims {
+	return jwt.MapClaims{
+		"htm": testMethod,
+		"htu": testURI,
+		"iat": time.Now().Unix(),
+		"jti": "proof-" + strconv.FormatInt(proofCounter.Add(1), 10),
+	}
+}
+
+// sign подписывает доказательство ключом k после изменения заголовка и claims в modify
+func (k *testKey) sign(t *testing.T, modify func(header map[string]interface{}, claims jwt.MapClaims)) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, proofClaimsFor())
+	token.Header["typ"] = proofType
+	token.Header["jwk"] = k.jwk
+	if modify != nil {
+		modify(token.Header, token.Claims.(jwt.MapClaims))
+	}
+	proof, err := token.SignedString(k.private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+// failingReplayCache имитирует недоступное хранилище идентификаторов
+type failingReplayCache struct{}
+
+func (failingReplayCache) Use(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return false, errors.New("хранилище недоступно")
+}
+
+func TestVerify(t *testing.T) {
+	key, other := newTestKey(t), newTestKey(t)
+
+	tests := []struct {
+		name        string
+		proof       func(t *testing.T) string
+		method      string
+		uri         string
+		accessToken string
+		valid       bool
+	}{
+		{
+			name:  "корректное доказательство",
+			proof: func(t *testing.T) string { return key.sign(t, nil) },
+			valid: true,
+		},
+		{
+			name: "typ отсутствует",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(header map[string]interface{}, _ jwt.MapClaims) { delete(header, "typ") })
+			},
+		},
+		{
+			name: "typ JWT",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(header map[string]interface{}, _ jwt.MapClaims) { header["typ"] = "JWT" })
+			},
+		},
+		{
+			name: "alg HS256",
+			proof: func(t *testing.T) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, proofClaimsFor())
+				token.Header["typ"] = proofType
+				token.Header["jwk"] = map[string]interface{}{"kty": "oct", "k": "c2VjcmV0"}
+				proof, err := token.SignedString([]byte("secret"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return proof
+			},
+		},
+		{
+			name: "alg none",
+			proof: func(t *testing.T) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodNone, proofClaimsFor())
+				token.Header["typ"] = proofType
+				token.Header["jwk"] = key.jwk
+				proof, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return proof
+			},
+		},
+		{
+			name: "jwk отсутствует",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(header map[string]interface{}, _ jwt.MapClaims) { delete(header, "jwk") })
+			},
+		},
+		{
+			name: "jwk с закрытой частью",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(header map[string]interface{}, _ jwt.MapClaims) {
+					private := map[string]interface{}{"d": base64.RawURLEncoding.EncodeToString(key.private.D.Bytes())}
+					for name, value := range key.jwk {
+						private[name] = value
+					}
+					header["jwk"] = private
+				})
+			},
+		},
+		{
+			name: "подпись другим ключом",
+			proof: func(t *testing.T) string {
+				return other.sign(t, func(header map[string]interface{}, _ jwt.MapClaims) { header["jwk"] = key.jwk })
+			},
+		},
+		{
+			name:   "htm не совпадает",
+			proof:  func(t *testing.T) string { return key.sign(t, nil) },
+			method: "GET",
+		},
+		{
+			name:  "htu с другим путем",
+			proof: func(t *testing.T) string { return key.sign(t, nil) },
+			uri:   "https://auth.example.com/api/v1/login",
+		},
+		{
+			name:  "htu с другой схемой",
+			proof: func(t *testing.T) string { return key.sign(t, nil) },
+			uri:   "http://auth.example.com/api/v1/refresh",
+		},
+		{
+			name:  "htu с другим портом",
+			proof: func(t *testing.T) string { return key.sign(t, nil) },
+			uri:   "https://auth.example.com:8443/api/v1/refresh",
+		},
+		{
+			name: "htu с портом по умолчанию и хостом в верхнем регистре",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(_ map[string]interface{}, claims jwt.MapClaims) {
+					claims["htu"] = "https://AUTH.example.com:443/api/v1/refresh"
+				})
+			},
+			valid: true,
+		},
+		{
+			name:  "адрес запроса с портом по умолчанию",
+			proof: func(t *testing.T) string { return key.sign(t, nil) },
+			uri:   "https://auth.example.com:443/api/v1/refresh",
+			valid: true,
+		},
+		{
+			name: "htu с параметрами и фрагментом",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(_ map[string]interface{}, claims jwt.MapClaims) {
+					claims["htu"] = testURI + "?state=1#top"
+				})
+			},
+			valid: true,
+		},
+		{
+			name: "htu относительный",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(_ map[string]interface{}, claims jwt.MapClaims) { claims["htu"] = "/api/v1/refresh" })
+			},
+		},
+		{
+			name: "iat в прошлом за пределами окна",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(_ map[string]interface{}, claims jwt.MapClaims) {
+					claims["iat"] = time.Now().Add(-2 * testLifetime).Unix()
+				})
+			},
+		},
+		{
+			name: "iat в будущем за пределами окна",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(_ map[string]interface{}, claims jwt.MapClaims) {
+					claims["iat"] = time.Now().Add(2 * testLifetime).Unix()
+				})
+			},
+		},
+		{
+			name: "iat отсутствует",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(_ map[string]interface{}, claims jwt.MapClaims) { delete(claims, "iat") })
+			},
+		},
+		{
+			name: "jti отсутствует",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(_ map[string]interface{}, claims jwt.MapClaims) { delete(claims, "jti") })
+			},
+		},
+		{
+			name: "ath совпадает с access токеном",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(_ map[string]interface{}, claims jwt.MapClaims) { claims["ath"] = hash("access-token") })
+			},
+			accessToken: "access-token",
+			valid:       true,
+		},
+		{
+			name: "ath другого access токена",
+			proof: func(t *testing.T) string {
+				return key.sign(t, func(_ map[string]interface{}, claims jwt.MapClaims) { claims["ath"] = hash("other-token") })
+			},
+			accessToken: "access-token",
+		},
+		{
+			name:        "ath отсутствует",
+			proof:       func(t *testing.T) string { return key.sign(t, nil) },
+			accessToken: "access-token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewVerifier(testLifetime, NewMemoryReplayCache(), false)
+			method, uri := testMethod, testURI
+			if tt.method != "" {
+				method = tt.method
+			}
+			if tt.uri != "" {
+				uri = tt.uri
+			}
+
+			jkt, err := verifier.Verify(context.Background(), tt.proof(t), method, uri, tt.accessToken)
+			if !tt.valid {
+				if !errors.Is(err, ErrInvalidProof) {
+					t.Fatalf("ожидалась ErrInvalidProof, получено %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if want := key.jkt(t); jkt != want {
+				t.Fatalf("jkt = %s, ожидался %s", jkt, want)
+			}
+		})
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	key := newTestKey(t)
+	verifier := NewVerifier(testLifetime, NewMemoryReplayCache(), false)
+	ctx := context.Background()
+	withID := func(_ map[string]interface{}, claims jwt.MapClaims) { claims["jti"] = "replayed" }
+	proof := key.sign(t, withID)
+
+	if _, err := verifier.Verify(ctx, proof, testMethod, testURI, ""); err != nil {
+		t.Fatalf("первое предъявление: %v", err)
+	}
+	if _, err := verifier.Verify(ctx, proof, testMethod, testURI, ""); !errors.Is(err, ErrInvalidProof) {
+		t.Fatalf("повторное предъявление: ожидалась ErrInvalidProof, получено %v", err)
+	}
+
+	// Тот же jti в доказательстве другого ключа не считается повтором
+	if _, err := verifier.Verify(ctx, newTestKey(t).sign(t, withID), testMethod, testURI, ""); err != nil {
+		t.Fatalf("jti другого ключа: %v", err)
+	}
+}
+
+func TestVerifyReplayUnavailable(t *testing.T) {
+	key := newTestKey(t)
+
+	tests := []struct {
+		name     string
+		failOpen bool
+	}{
+		{name: "отказ при недоступном хранилище", failOpen: false},
+		{name: "прием при недоступном хранилище", failOpen: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewVerifier(testLifetime, failingReplayCache{}, tt.failOpen)
+			_, err := verifier.Verify(context.Background(), key.sign(t, nil), testMethod, testURI, "")
+			if tt.failOpen {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrReplayCheckUnavailable) {
+				t.Fatalf("ожидалась ErrReplayCheckUnavailable, получено %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package dpop
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ReplayCache хранилище идентификаторов (jti) предъявленных доказательств
+type ReplayCache interface {
+	// Use запоминает key на время ttl и сообщает false, если key уже использован
+	Use(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// pruneInterval интервал удаления устаревших записей MemoryReplayCache
+const pruneInterval = time.Minute
+
+// MemoryReplayCache хранит идентификаторы доказательств в памяти процесса.
+// Доказательство, предъявленное другому экземпляру сервиса, не обнаруживается.
+type MemoryReplayCache struct {
+	mu     sync.Mutex
+	seen   map[string]time.Time
+	pruned time.Time
+}
+
+// NewMemoryReplayCache создает хранилище идентификаторов в памяти
+func NewMemoryReplayCache() *MemoryReplayCache {
+	return &MemoryReplayCache{seen: make(map[string]time.Time)}
+}
+
+// Use запоминает key на время ttl и сообщает false, если key уже использован
+func (c *MemoryReplayCache) Use(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.pruned) >= pruneInterval {
+		for seen, expiresAt := range c.seen {
+			if !expiresAt.After(now) {
+				delete(c.seen, seen)
+			}
+		}
+		c.pruned = now
+	}
+
+	if expiresAt, ok := c.seen[key]; ok && expiresAt.After(now) {
+		return false, nil
+	}
+	c.seen[key] = now.Add(ttl)
+	return true, nil
+}
+
+// RedisReplayCache хранит идентификаторы доказательств в Redis, общем для всех экземпляров сервиса
+type RedisReplayCache struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisReplayCache создает хранилище идентификаторов с ключами, начинающимися с prefix
+func NewRedisReplayCache(client redis.Cmdable, prefix string) *RedisReplayCache {
+	return &RedisReplayCache{client: client, prefix: prefix}
+}
+
+// Use запоминает key на время ttl и сообщает false, если key уже использован
+func (c *RedisReplayCache) Use(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, c.prefix+"dpop:"+key, 1, ttl).Result()
+}
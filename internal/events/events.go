@@ -0,0 +1,58 @@
+package events
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Sink получатель опубликованных событий.
+// События доставляются как минимум один раз: при ошибке любого sink-а
+// пачка публикуется повторно, поэтому получатели должны отбрасывать
+// повторы по идентификатору события.
+type Sink interface {
+	// Name возвращает имя sink-а для журнала
+	Name() string
+
+	// Write принимает пачку событий в порядке их записи в журнал
+	Write(ctx context.Context, events []models.Event) error
+}
+
+// New создает событие в формате CloudEvents 1.0.
+// Контекст трассировки из ctx сохраняется в атрибутах traceparent и tracestate.
+func New(ctx context.Context, source, eventType, subject string, data interface{}) (models.Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return models.Event{}, fmt.Errorf("ошибка сериализации данных события %s: %w", eventType, err)
+	}
+
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	return models.Event{
+		SpecVersion:     models.CloudEventsSpecVersion,
+		ID:              uuid.New().String(),
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		TraceParent:     carrier.Get("traceparent"),
+		TraceState:      carrier.Get("tracestate"),
+		Data:            payload,
+	}, nil
+}
+
+// TraceContext возвращает ctx с контекстом трассировки, сохраненным в событии
+func TraceContext(ctx context.Context, event models.Event) context.Context {
+	carrier := propagation.MapCarrier{
+		"traceparent": event.TraceParent,
+		"tracestate":  event.TraceState,
+	}
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
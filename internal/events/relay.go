@@ -0,0 +1,139 @@
+package events
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	// relayBatchSize количество событий, публикуемых за один проход
+	relayBatchSize = 100
+	// relayLease время, на которое захватывается пачка событий
+	relayLease = 30 * time.Second
+	// relayProgressTTL время, в течение которого помнится, какие sink-и приняли
+	// неопубликованное событие; событие, которое столько не захватывалось,
+	// скорее всего опубликовано другим экземпляром
+	relayProgressTTL = 4 * relayLease
+)
+
+// Relay публикует события из журнала во все sink-и
+type Relay struct {
+	log          repository.EventLog
+	sinks        []Sink
+	pollInterval time.Duration
+
+	// progress sink-и, принявшие захваченные, но еще не опубликованные события, по ID записи журнала.
+	// Используется только из Run.
+	progress map[int64]*sinkProgress
+}
+
+// sinkProgress номера sink-ов, принявших событие
+type sinkProgress struct {
+	accepted map[int]bool
+	claimed  time.Time
+}
+
+// NewRelay создает новый экземпляр Relay
+func NewRelay(eventLog repository.EventLog, sinks []Sink, pollInterval time.Duration) *Relay {
+	return &Relay{
+		log:          eventLog,
+		sinks:        sinks,
+		pollInterval: pollInterval,
+		progress:     make(map[int64]*sinkProgress),
+	}
+}
+
+// Run публикует события до отмены ctx
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		published, err := r.publishBatch(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка публикации событий", slog.Any("error", err))
+		}
+
+		// Если журнал не пуст, сразу забираем следующую порцию
+		if err == nil && published == relayBatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// publishBatch публикует одну пачку событий и возвращает ее размер.
+// Пачка отмечается опубликованной, только если ее приняли все sink-и, иначе после
+// истечения захвата она будет опубликована повторно, но только в те sink-и, которые
+// еще не приняли ее события.
+func (r *Relay) publishBatch(ctx context.Context) (int, error) {
+	records, err := r.log.ClaimEvents(ctx, relayBatchSize, relayLease)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+
+	now := time.Now()
+	r.pruneProgress(now)
+	ids := make([]int64, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+		progress := r.progress[record.ID]
+		if progress == nil {
+			progress = &sinkProgress{accepted: make(map[int]bool)}
+			r.progress[record.ID] = progress
+		}
+		progress.claimed = now
+	}
+
+	failed := false
+	for i, sink := range r.sinks {
+		var pending []*models.EventRecord
+		for _, record := range records {
+			if !r.progress[record.ID].accepted[i] {
+				pending = append(pending, record)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		batch := make([]models.Event, 0, len(pending))
+		for _, record := range pending {
+			batch = append(batch, record.Event)
+		}
+		if err := sink.Write(ctx, batch); err != nil {
+			slog.WarnContext(ctx, "Sink не принял события", slog.String("sink", sink.Name()), slog.Any("error", err))
+			failed = true
+			continue
+		}
+		for _, record := range pending {
+			r.progress[record.ID].accepted[i] = true
+		}
+	}
+	if failed {
+		return 0, nil
+	}
+
+	if err := r.log.MarkEventsPublished(ctx, ids...); err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		delete(r.progress, id)
+	}
+
+	return len(records), nil
+}
+
+// pruneProgress забывает события, которые не захватывались дольше relayProgressTTL
+func (r *Relay) pruneProgress(now time.Time) {
+	for id, progress := range r.progress {
+		if now.Sub(progress.claimed) > relayProgressTTL {
+			delete(r.progress, id)
+		}
+	}
+}
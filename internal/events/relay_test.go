@@ -0,0 +1,117 @@
+package events
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testEventLog журнал, который отдает все неопубликованные события при каждом захвате,
+// как после истечения захвата
+type testEventLog struct {
+	records   []*models.EventRecord
+	published map[int64]bool
+}
+
+func newTestEventLog(n int) *testEventLog {
+	log := &testEventLog{published: make(map[int64]bool)}
+	for i := 1; i <= n; i++ {
+		log.records = append(log.records, &models.EventRecord{ID: int64(i), Event: models.Event{ID: "event-" + strconv.Itoa(i)}})
+	}
+	return log
+}
+
+func (l *testEventLog) AppendEvents(ctx context.Context, events ...models.Event) error {
+	return errors.New("не поддерживается")
+}
+
+func (l *testEventLog) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]*models.EventRecord, error) {
+	var records []*models.EventRecord
+	for _, record := range l.records {
+		if !l.published[record.ID] && len(records) < limit {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (l *testEventLog) MarkEventsPublished(ctx context.Context, ids ...int64) error {
+	for _, id := range ids {
+		l.published[id] = true
+	}
+	return nil
+}
+
+// testSink запоминает принятые события и отклоняет первые failures записей
+type testSink struct {
+	name     string
+	failures int
+	received []string
+}
+
+func (s *testSink) Name() string {
+	return s.name
+}
+
+func (s *testSink) Write(ctx context.Context, events []models.Event) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink недоступен")
+	}
+	for _, event := range events {
+		s.received = append(s.received, event.ID)
+	}
+	return nil
+}
+
+func TestPublishBatchRetriesFailedSinkOnly(t *testing.T) {
+	log := newTestEventLog(3)
+	healthy, flaky := &testSink{name: "healthy"}, &testSink{name: "flaky", failures: 2}
+	relay := NewRelay(log, []Sink{healthy, flaky}, time.Second)
+	ctx := context.Background()
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if published, err := relay.publishBatch(ctx); err != nil || published != 0 {
+			t.Fatalf("попытка %d: опубликовано %d, ошибка %v", attempt, published, err)
+		}
+	}
+	if published, err := relay.publishBatch(ctx); err != nil || published != 3 {
+		t.Fatalf("последняя попытка: опубликовано %d, ошибка %v", published, err)
+	}
+
+	want := []string{"event-1", "event-2", "event-3"}
+	for _, sink := range []*testSink{healthy, flaky} {
+		if len(sink.received) != len(want) {
+			t.Fatalf("sink %s получил %v, ожидалось %v", sink.name, sink.received, want)
+		}
+		for i, id := range want {
+			if sink.received[i] != id {
+				t.Fatalf("sink %s получил %v, ожидалось %v", sink.name, sink.received, want)
+			}
+		}
+	}
+	if len(relay.progress) != 0 {
+		t.Fatalf("после публикации остались отметки о приеме: %d", len(relay.progress))
+	}
+	if published, err := relay.publishBatch(ctx); err != nil || published != 0 {
+		t.Fatalf("пустой журнал: опубликовано %d, ошибка %v", published, err)
+	}
+}
+
+func TestPruneProgress(t *testing.T) {
+	relay := NewRelay(newTestEventLog(0), nil, time.Second)
+	now := time.Now()
+	relay.progress[1] = &sinkProgress{accepted: map[int]bool{0: true}, claimed: now.Add(-relayProgressTTL - time.Second)}
+	relay.progress[2] = &sinkProgress{accepted: map[int]bool{0: true}, claimed: now.Add(-relayLease)}
+
+	relay.pruneProgress(now)
+	if _, ok := relay.progress[1]; ok {
+		t.Fatal("не забыто событие, которое давно не захватывалось")
+	}
+	if _, ok := relay.progress[2]; !ok {
+		t.Fatal("забыто событие, захваченное недавно")
+	}
+}
@@ -0,0 +1,104 @@
+package events
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// maxNotifyPayload ограничение Postgres на размер уведомления NOTIFY
+const maxNotifyPayload = 8000
+
+// JSONLinesSink записывает события в формате JSON Lines
+type JSONLinesSink struct {
+	name string
+	mu   sync.Mutex
+	w    io.Writer
+}
+
+// NewStdoutSink создает sink, печатающий события в stdout
+func NewStdoutSink() *JSONLinesSink {
+	return &JSONLinesSink{name: "stdout", w: os.Stdout}
+}
+
+// NewFileSink создает sink, дописывающий события в файл
+func NewFileSink(path string) (*JSONLinesSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть файл событий: %w", err)
+	}
+	return &JSONLinesSink{name: "file", w: file}, nil
+}
+
+// Name возвращает имя sink-а
+func (s *JSONLinesSink) Name() string {
+	return s.name
+}
+
+// Write записывает события, по одному JSON объекту на строку
+func (s *JSONLinesSink) Write(_ context.Context, events []models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	encoder := json.NewEncoder(s.w)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close закрывает файл, если sink пишет в файл
+func (s *JSONLinesSink) Close() error {
+	if closer, ok := s.w.(io.Closer); ok && s.w != os.Stdout {
+		return closer.Close()
+	}
+	return nil
+}
+
+// Notifier отправляет уведомления Postgres NOTIFY
+type Notifier interface {
+	Notify(ctx context.Context, channel, payload string) error
+}
+
+// NotifySink публикует события в канал Postgres LISTEN/NOTIFY
+type NotifySink struct {
+	notifier Notifier
+	channel  string
+}
+
+// NewNotifySink создает новый экземпляр NotifySink
+func NewNotifySink(notifier Notifier, channel string) *NotifySink {
+	return &NotifySink{notifier: notifier, channel: channel}
+}
+
+// Name возвращает имя sink-а
+func (s *NotifySink) Name() string {
+	return "pgnotify"
+}
+
+// Write отправляет каждое событие отдельным уведомлением.
+// Если событие не помещается в ограничение NOTIFY, оно отправляется без data.
+func (s *NotifySink) Write(ctx context.Context, events []models.Event) error {
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if len(payload) >= maxNotifyPayload {
+			event.Data = nil
+			if payload, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+		if err := s.notifier.Notify(ctx, s.channel, string(payload)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
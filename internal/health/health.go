@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check проверка зависимости; nil означает, что зависимость доступна
+type Check func(ctx context.Context) error
+
+// namedCheck проверка с именем для ответа readiness
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker выполняет проверки готовности сервиса
+type Checker struct {
+	timeout      time.Duration
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+// NewChecker создает новый экземпляр Checker; timeout ограничивает время всех проверок
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add добавляет проверку готовности
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown переводит readiness в состояние отказа перед остановкой сервиса,
+// чтобы балансировщик перестал направлять новые запросы
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Run выполняет все проверки параллельно и возвращает их результаты
+func (c *Checker) Run(ctx context.Context) (map[string]string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make(map[string]string, len(c.checks))
+	ready := true
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check namedCheck) {
+			defer wg.Done()
+			result := "ok"
+			err := check.check(ctx)
+			if err != nil {
+				result = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.name] = result
+			if err != nil {
+				ready = false
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return results, ready
+}
+
+// @Summary Проверка живости
+// @Description Отвечает 200, пока процесс способен обрабатывать запросы
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string "Процесс работает"
+// @Router /healthz [get]
+func (c *Checker) Liveness(ctx *gin.Context) {
+	// Зависимости не проверяются, чтобы сбой базы данных не приводил к перезапуску процесса
+	ctx.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+	})
+}
+
+// @Summary Проверка готовности
+// @Description Проверяет базу данных, схему, ключ подписи и очередь webhook. Во время остановки сервиса возвращает 503.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Сервис готов"
+// @Failure 503 {object} map[string]interface{} "Сервис не готов"
+// @Router /readyz [get]
+func (c *Checker) Readiness(ctx *gin.Context) {
+	if c.shuttingDown.Load() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "shutting_down",
+		})
+		return
+	}
+
+	results, ready := c.Run(ctx.Request.Context())
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "error", http.StatusServiceUnavailable
+	}
+
+	ctx.JSON(code, gin.H{
+		"status": status,
+		"checks": results,
+	})
+}
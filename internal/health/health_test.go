@@ -0,0 +1,169 @@
+package health
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newSQLite создает хранилище SQLite; если migrate равен true, к нему применяются миграции
+func newSQLite(t *testing.T, migrate bool) *repository.SQLiteRepository {
+	t.Helper()
+	repo, err := repository.NewSQLiteRepository(config.DatabaseConfig{
+		DSN: filepath.Join(t.TempDir(), "auth.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	if migrate {
+		if err := repo.Migrate(context.Background()); err != nil {
+			t.Fatalf("Migrate: %v", err)
+		}
+	}
+	return repo
+}
+
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		// setup добавляет проверки в checker
+		setup  func(t *testing.T, checker *Checker)
+		status int
+		// failed проверки, которые должны завершиться ошибкой, с фрагментом сообщения
+		failed map[string]string
+	}{
+		{
+			name: "все зависимости доступны",
+			setup: func(t *testing.T, checker *Checker) {
+				repo := newSQLite(t, true)
+				checker.Add("database", repo.Ping)
+				checker.Add("schema", repo.CheckSchema)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "миграции не применены",
+			setup: func(t *testing.T, checker *Checker) {
+				repo := newSQLite(t, false)
+				checker.Add("database", repo.Ping)
+				checker.Add("schema", repo.CheckSchema)
+			},
+			status: http.StatusServiceUnavailable,
+			failed: map[string]string{"schema": "не применены миграции схемы"},
+		},
+		{
+			name: "база данных недоступна",
+			setup: func(t *testing.T, checker *Checker) {
+				repo := newSQLite(t, true)
+				if err := repo.Close(); err != nil {
+					t.Fatalf("Close: %v", err)
+				}
+				checker.Add("database", repo.Ping)
+				checker.Add("signing_key", func(ctx context.Context) error { return nil })
+			},
+			status: http.StatusServiceUnavailable,
+			failed: map[string]string{"database": "closed"},
+		},
+		{
+			name: "проверка не уложилась в таймаут",
+			setup: func(t *testing.T, checker *Checker) {
+				checker.Add("webhook_backlog", func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				})
+				checker.Add("signing_key", func(ctx context.Context) error { return nil })
+			},
+			status: http.StatusServiceUnavailable,
+			failed: map[string]string{"webhook_backlog": context.DeadlineExceeded.Error()},
+		},
+		{
+			name: "несколько зависимостей недоступны",
+			setup: func(t *testing.T, checker *Checker) {
+				checker.Add("database", func(ctx context.Context) error { return errors.New("connection refused") })
+				checker.Add("signing_key", func(ctx context.Context) error { return errors.New("ключ не задан") })
+			},
+			status: http.StatusServiceUnavailable,
+			failed: map[string]string{"database": "connection refused", "signing_key": "ключ не задан"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(100 * time.Millisecond)
+			tt.setup(t, checker)
+
+			router := gin.New()
+			router.GET("/readyz", checker.Readiness)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if recorder.Code != tt.status {
+				t.Fatalf("статус %d, ожидался %d: %s", recorder.Code, tt.status, recorder.Body)
+			}
+
+			var body struct {
+				Status string            `json:"status"`
+				Checks map[string]string `json:"checks"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("тело ответа %s: %v", recorder.Body, err)
+			}
+			want := "ok"
+			if len(tt.failed) > 0 {
+				want = "error"
+			}
+			if body.Status != want {
+				t.Fatalf("status %q, ожидался %q", body.Status, want)
+			}
+			if len(body.Checks) != len(checker.checks) {
+				t.Fatalf("результаты проверок %v, ожидалось %d", body.Checks, len(checker.checks))
+			}
+			for name, result := range body.Checks {
+				fragment, failed := tt.failed[name]
+				if !failed && result != "ok" {
+					t.Fatalf("проверка %s: %q, ожидалось ok", name, result)
+				}
+				if failed && (result == "ok" || !strings.Contains(result, fragment)) {
+					t.Fatalf("проверка %s: %q, ожидалась ошибка %q", name, result, fragment)
+				}
+			}
+		})
+	}
+
+	t.Run("остановка сервиса", func(t *testing.T) {
+		checker := NewChecker(100 * time.Millisecond)
+		checker.Add("database", func(ctx context.Context) error {
+			t.Error("проверки выполняются во время остановки")
+			return nil
+		})
+		checker.SetShuttingDown()
+
+		router := gin.New()
+		router.GET("/readyz", checker.Readiness)
+		router.GET("/healthz", checker.Liveness)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		if recorder.Code != http.StatusServiceUnavailable || !strings.Contains(recorder.Body.String(), "shutting_down") {
+			t.Fatalf("readiness: статус %d: %s", recorder.Code, recorder.Body)
+		}
+
+		// Живость не зависит от остановки и зависимостей
+		recorder = httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("liveness: статус %d", recorder.Code)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package janitor
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/metrics"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// lockName имя блокировки, под которой очистка выполняется только на одном экземпляре
+const lockName = "session-gc"
+
+// ErrLocked очистку сессий в этот момент выполняет другой экземпляр сервиса
+var ErrLocked = errors.New("очистку сессий выполняет другой экземпляр")
+
+// Janitor удаляет сессии, срок действия которых истек или которые заблокированы,
+// по прошествии времени хранения
+type Janitor struct {
+	repo      repository.Repository
+	interval  time.Duration
+	retention time.Duration
+	batchSize int
+}
+
+// NewJanitor создает новый экземпляр Janitor
+func NewJanitor(repo repository.Repository, cfg config.SessionGCConfig) *Janitor {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	return &Janitor{
+		repo:      repo,
+		interval:  cfg.Interval,
+		retention: cfg.Retention,
+		batchSize: batchSize,
+	}
+}
+
+// Run запускает очистку сразу и затем каждые interval до отмены ctx
+func (j *Janitor) Run(ctx context.Context) {
+	for {
+		deleted, err := j.RunOnce(ctx)
+		switch {
+		case errors.Is(err, ErrLocked):
+			slog.DebugContext(ctx, "Очистка сессий пропущена", slog.Any("error", err))
+		case err != nil && ctx.Err() == nil:
+			slog.ErrorContext(ctx, "Ошибка очистки сессий", slog.Int("deleted", deleted), slog.Any("error", err))
+		case deleted > 0:
+			slog.InfoContext(ctx, "Удалены устаревшие сессии", slog.Int("deleted", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(j.interval):
+		}
+	}
+}
+
+// RunOnce удаляет устаревшие сессии пачками по batchSize и возвращает их количество.
+// Если хранилище поддерживает блокировки и очистку уже выполняет другой экземпляр, возвращает ErrLocked.
+func (j *Janitor) RunOnce(ctx context.Context) (int, error) {
+	if locker, ok := j.repo.(repository.Locker); ok {
+		unlock, locked, err := locker.TryLock(ctx, lockName)
+		if err != nil {
+			metrics.SessionGCRun(metrics.OutcomeFailure)
+			return 0, err
+		}
+		if !locked {
+			metrics.SessionGCRun(metrics.OutcomeSkipped)
+			return 0, ErrLocked
+		}
+		defer unlock()
+	}
+
+	before := time.Now().Add(-j.retention)
+	total := 0
+	for {
+		deleted, err := j.repo.DeleteStaleSessions(ctx, before, j.batchSize)
+		total += deleted
+		metrics.SessionsPurged(deleted)
+		if err != nil {
+			metrics.SessionGCRun(metrics.OutcomeFailure)
+			return total, err
+		}
+
+		// Пачка меньше максимальной означает, что устаревших сессий не осталось
+		if deleted < j.batchSize {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			metrics.SessionGCRun(metrics.OutcomeFailure)
+			return total, err
+		}
+	}
+
+	metrics.SessionGCRun(metrics.OutcomeSuccess)
+	return total, nil
+}
@@ -0,0 +1,144 @@
+package janitor
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// errLockUnavailable ошибка получения блокировки очистки
+var errLockUnavailable = errors.New("хранилище блокировок недоступно")
+
+// lockedRepository хранилище, блокировку очистки в котором удерживает другой экземпляр
+type lockedRepository struct {
+	*repository.MemoryRepository
+	err error
+}
+
+func (r *lockedRepository) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	return nil, false, r.err
+}
+
+func TestRunOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		retention time.Duration
+		batchSize int
+		// expiresAt сроки действия сессий; blocked сессии с этими номерами блокируются
+		expiresAt []time.Time
+		blocked   []int
+		lock      func(repo *repository.MemoryRepository) repository.Repository
+		deleted   int
+		remaining int
+		want      error
+	}{
+		{
+			name:      "действующие сессии не удаляются",
+			retention: time.Hour,
+			expiresAt: []time.Time{now.Add(time.Hour), now.Add(24 * time.Hour)},
+			remaining: 2,
+		},
+		{
+			name:      "истекшие раньше времени хранения",
+			retention: time.Hour,
+			expiresAt: []time.Time{now.Add(-2 * time.Hour), now.Add(-30 * time.Minute), now.Add(time.Hour)},
+			deleted:   1,
+			remaining: 2,
+		},
+		{
+			name:      "заблокированные в пределах времени хранения сохраняются",
+			retention: time.Hour,
+			expiresAt: []time.Time{now.Add(time.Hour), now.Add(time.Hour)},
+			blocked:   []int{0},
+			remaining: 2,
+		},
+		{
+			name:      "заблокированные раньше времени хранения",
+			expiresAt: []time.Time{now.Add(time.Hour), now.Add(time.Hour)},
+			blocked:   []int{0},
+			deleted:   1,
+			remaining: 1,
+		},
+		{
+			name:      "несколько пачек",
+			retention: time.Hour,
+			batchSize: 2,
+			expiresAt: []time.Time{now.Add(-2 * time.Hour), now.Add(-3 * time.Hour), now.Add(-4 * time.Hour), now.Add(-5 * time.Hour), now.Add(-6 * time.Hour)},
+			deleted:   5,
+		},
+		{
+			name:      "очистку выполняет другой экземпляр",
+			retention: time.Hour,
+			expiresAt: []time.Time{now.Add(-2 * time.Hour)},
+			lock: func(repo *repository.MemoryRepository) repository.Repository {
+				return &lockedRepository{MemoryRepository: repo}
+			},
+			remaining: 1,
+			want:      ErrLocked,
+		},
+		{
+			name:      "ошибка блокировки",
+			retention: time.Hour,
+			expiresAt: []time.Time{now.Add(-2 * time.Hour)},
+			lock: func(repo *repository.MemoryRepository) repository.Repository {
+				return &lockedRepository{MemoryRepository: repo, err: errLockUnavailable}
+			},
+			remaining: 1,
+			want:      errLockUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := repository.NewMemoryRepository()
+			t.Cleanup(func() { _ = repo.Close() })
+
+			userID := uuid.New()
+			for i, expiresAt := range tt.expiresAt {
+				token := "token-" + strconv.Itoa(i)
+				id, err := repo.CreateSession(ctx, userID, token, "id-"+token, "agent", "192.0.2.1", models.SessionKindUser, models.TokenBinding{}, expiresAt.Unix())
+				if err != nil {
+					t.Fatalf("CreateSession: %v", err)
+				}
+				for _, blocked := range tt.blocked {
+					if blocked == i {
+						if err := repo.BlockSession(ctx, id); err != nil {
+							t.Fatalf("BlockSession: %v", err)
+						}
+					}
+				}
+			}
+
+			var target repository.Repository = repo
+			if tt.lock != nil {
+				target = tt.lock(repo)
+			}
+			j := NewJanitor(target, config.SessionGCConfig{Retention: tt.retention, BatchSize: tt.batchSize})
+			deleted, err := j.RunOnce(ctx)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("RunOnce = %v, ожидалось %v", err, tt.want)
+			}
+			if deleted != tt.deleted {
+				t.Fatalf("удалено %d, ожидалось %d", deleted, tt.deleted)
+			}
+
+			sessions, err := repo.ListSessions(ctx, models.SessionFilter{UserID: userID})
+			if err != nil {
+				t.Fatalf("ListSessions: %v", err)
+			}
+			if len(sessions) != tt.remaining {
+				t.Fatalf("осталось сессий %d, ожидалось %d", len(sessions), tt.remaining)
+			}
+		})
+	}
+}
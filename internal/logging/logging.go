@@ -0,0 +1,94 @@
+package logging
+
+import (
+	"auth-service/internal/config"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestIDKey ключ идентификатора запроса в контексте
+type requestIDKey struct{}
+
+// level минимальный уровень записей логгеров, созданных New
+var level slog.LevelVar
+
+// New создает логгер с уровнем и форматом из конфигурации.
+// Записи дополняются идентификатором запроса и трассы из контекста,
+// а значения чувствительных атрибутов скрываются.
+func New(cfg config.LogConfig, w io.Writer) (*slog.Logger, error) {
+	if err := SetLevel(cfg.Level); err != nil {
+		return nil, err
+	}
+
+	options := &slog.HandlerOptions{
+		Level:       &level,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, options)
+	case "text":
+		handler = slog.NewTextHandler(w, options)
+	default:
+		return nil, fmt.Errorf("неизвестный формат логирования: %s", cfg.Format)
+	}
+
+	return slog.New(contextHandler{Handler: handler}), nil
+}
+
+// SetLevel изменяет минимальный уровень записей логгеров, созданных New
+func SetLevel(value string) error {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(value)); err != nil {
+		return fmt.Errorf("неизвестный уровень логирования: %s", value)
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// WithRequestID возвращает контекст с идентификатором запроса
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID возвращает идентификатор запроса из контекста
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// contextHandler добавляет к записям идентификаторы запроса и трассы из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle дополняет запись атрибутами контекста и передает ее дальше
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs возвращает обработчик с дополнительными атрибутами
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup возвращает обработчик, помещающий атрибуты в группу
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
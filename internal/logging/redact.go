@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted значение, которым заменяются скрытые данные
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys фрагменты имен атрибутов, заголовков и параметров, значения которых не попадают в логи
+var sensitiveKeys = []string{"authorization", "token", "secret", "password", "cookie", "dpop"}
+
+// sensitiveParams имена параметров запроса, значения которых не попадают в логи, помимо
+// sensitiveKeys. Они сравниваются целиком: фрагмент "code" есть и в безобидных именах.
+var sensitiveParams = []string{"code"}
+
+// jwtPattern значение, похожее на JWT
+var jwtPattern = regexp.MustCompile(`^eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`)
+
+// IsSensitive сообщает, может ли атрибут с таким именем содержать секрет
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// Header возвращает заголовки для записи в лог со скрытыми значениями чувствительных заголовков
+func Header(header http.Header) slog.Value {
+	attrs := make([]slog.Attr, 0, len(header))
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		if IsSensitive(name) {
+			value = Redacted
+		}
+		attrs = append(attrs, slog.String(name, value))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// Query возвращает строку запроса со скрытыми значениями чувствительных параметров
+func Query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+		if IsSensitive(name) || isSensitiveParam(name) {
+			params[i] = url.QueryEscape(name) + "=" + Redacted
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// isSensitiveParam сообщает, что параметр запроса содержит код авторизации или другой секрет
+func isSensitiveParam(name string) bool {
+	for _, param := range sensitiveParams {
+		if strings.EqualFold(name, param) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr скрывает значения чувствительных атрибутов, токены Bearer и JWT
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindGroup {
+		return attr
+	}
+	if IsSensitive(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	if attr.Value.Kind() == slog.KindString {
+		value := attr.Value.String()
+		if strings.HasPrefix(strings.ToLower(value), "bearer ") || jwtPattern.MatchString(value) {
+			return slog.String(attr.Key, Redacted)
+		}
+	}
+	return attr
+}
@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "auth_service"
+
+// Результаты операций
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Причины отказа в проверке токенов
+const (
+	// ReasonExpired срок действия токена истек
+	ReasonExpired = "expired"
+	// ReasonBadSignature подпись токена неверна
+	ReasonBadSignature = "bad_signature"
+	// ReasonMalformed токен имеет неверный формат
+	ReasonMalformed = "malformed"
+	// ReasonRevoked сессия токена отозвана
+	ReasonRevoked = "revoked"
+	// ReasonNotFound сессия токена не найдена
+	ReasonNotFound = "not_found"
+	// ReasonUAMismatch токен предъявлен с другого устройства
+	ReasonUAMismatch = "ua_mismatch"
+	// ReasonReused предъявлен уже замененный refresh токен
+	ReasonReused = "reused"
+	// ReasonBindingMismatch токен предъявлен без ключа клиента, к которому он привязан
+	ReasonBindingMismatch = "binding_mismatch"
+)
+
+// Результаты поиска в кеше сессий
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// OutcomeSkipped запуск очистки сессий пропущен: ее выполняет другой экземпляр
+const OutcomeSkipped = "skipped"
+
+// Результаты попыток доставки webhook
+const (
+	WebhookDelivered = "delivered"
+	WebhookRetry     = "retry"
+	WebhookDead      = "dead"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Количество попыток входа по результату.",
+	}, []string{"outcome"})
+
+	refreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refreshes_total",
+		Help:      "Количество попыток обновления токенов по результату.",
+	}, []string{"outcome"})
+
+	logouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logouts_total",
+		Help:      "Количество попыток выхода по результату.",
+	}, []string{"outcome"})
+
+	validationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_validation_failures_total",
+		Help:      "Количество отказов в проверке токенов по типу токена и причине.",
+	}, []string{"token", "reason"})
+
+	webhookDeliveries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Количество попыток доставки webhook по результату.",
+	}, []string{"outcome"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "session_cache_lookups_total",
+		Help:      "Количество поисков сессий в кеше по результату.",
+	}, []string{"result"})
+
+	sessionsPurged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sessions_purged_total",
+		Help:      "Количество удаленных истекших и заблокированных сессий.",
+	})
+
+	sessionGCRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "session_gc_runs_total",
+		Help:      "Количество запусков очистки сессий по результату.",
+	}, []string{"outcome"})
+
+	sessionGCLastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "session_gc_last_success_timestamp_seconds",
+		Help:      "Время последней успешной очистки сессий.",
+	})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Время обработки HTTP запросов.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	repositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_duration_seconds",
+		Help:      "Время выполнения операций репозитория.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		logins,
+		refreshes,
+		logouts,
+		validationFailures,
+		webhookDeliveries,
+		cacheLookups,
+		sessionsPurged,
+		sessionGCRuns,
+		sessionGCLastSuccess,
+		httpDuration,
+		repositoryDuration,
+	)
+}
+
+// Handler возвращает HTTP обработчик, отдающий метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB подключает метрики пула соединений с базой данных
+// и количество активных сессий, которое запрашивается при каждом сборе метрик
+func RegisterDB(db *sql.DB, activeSessions func() (int, error)) error {
+	if err := registry.Register(collectors.NewDBStatsCollector(db, "auth_service")); err != nil {
+		return err
+	}
+
+	return registry.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_sessions",
+		Help:      "Количество незаблокированных сессий с действующим refresh токеном.",
+	}, func() float64 {
+		count, err := activeSessions()
+		if err != nil {
+			return -1
+		}
+		return float64(count)
+	}))
+}
+
+// Login учитывает попытку входа
+func Login(outcome string) {
+	logins.WithLabelValues(outcome).Inc()
+}
+
+// Refresh учитывает попытку обновления токенов
+func Refresh(outcome string) {
+	refreshes.WithLabelValues(outcome).Inc()
+}
+
+// Logout учитывает попытку выхода
+func Logout(outcome string) {
+	logouts.WithLabelValues(outcome).Inc()
+}
+
+// ValidationFailure учитывает отказ в проверке access или refresh токена
+func ValidationFailure(token, reason string) {
+	validationFailures.WithLabelValues(token, reason).Inc()
+}
+
+// WebhookDelivery учитывает попытку доставки webhook
+func WebhookDelivery(outcome string) {
+	webhookDeliveries.WithLabelValues(outcome).Inc()
+}
+
+// CacheLookup учитывает поиск сессии в кеше
+func CacheLookup(result string) {
+	cacheLookups.WithLabelValues(result).Inc()
+}
+
+// SessionsPurged учитывает удаленные сессии
+func SessionsPurged(count int) {
+	sessionsPurged.Add(float64(count))
+}
+
+// SessionGCRun учитывает запуск очистки сессий
+func SessionGCRun(outcome string) {
+	sessionGCRuns.WithLabelValues(outcome).Inc()
+	if outcome == OutcomeSuccess {
+		sessionGCLastSuccess.SetToCurrentTime()
+	}
+}
+
+// ObserveHTTP учитывает время обработки HTTP запроса
+func ObserveHTTP(method, route, status string, duration time.Duration) {
+	httpDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
+
+// ObserveRepository учитывает время выполнения операции репозитория, начатой в start.
+// Вызывается через defer в начале операции.
+func ObserveRepository(operation string, start time.Time) {
+	repositoryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
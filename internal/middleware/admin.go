@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/dpop"
+	"auth-service/internal/service"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const adminActorKey = "adminActor"
+
+// AdminAuth проверяет доступ к административному API. Администратор предъявляет клиентский
+// сертификат, проверенный при установке TLS соединения, статический токен ADMIN_TOKEN
+// из действующей конфигурации или access токен сервисного клиента с областью доступа admin.
+// Клиентский сертификат принимается, только если trustClientCert равен true: на основном
+// порту сертификаты проверяются по удостоверяющим центрам сервисных клиентов, а не администраторов.
+func AdminAuth(cfg *config.Store, admin service.Admin, trustClientCert bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Сертификат проверен по удостоверяющим центрам администраторов при установке соединения
+		if identity := ClientCertificate(c); identity != nil && trustClientCert {
+			c.Set(adminActorKey, "cert:"+identity.Name())
+			c.Next()
+			return
+		}
+
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != dpop.Scheme) {
+			abortAdmin(c, http.StatusUnauthorized)
+			return
+		}
+
+		token := cfg.Load().Admin.Token
+		if parts[0] == "Bearer" && token != "" && subtle.ConstantTimeCompare([]byte(parts[1]), []byte(token)) == 1 {
+			c.Set(adminActorKey, "admin-token")
+			c.Next()
+			return
+		}
+
+		binding, ok := presentedBinding(c, parts[0])
+		if !ok {
+			abortAdmin(c, http.StatusUnauthorized)
+			return
+		}
+		client, err := admin.AuthorizeAdmin(c.Request.Context(), parts[1], binding)
+		if err != nil {
+			status := http.StatusUnauthorized
+			if errors.Is(err, service.ErrAdminForbidden) {
+				status = http.StatusForbidden
+			}
+			abortAdmin(c, status)
+			return
+		}
+
+		c.Set(adminActorKey, "client:"+client.ID.String())
+		c.Next()
+	}
+}
+
+// AdminActor возвращает инициатора административного действия, определенного AdminAuth,
+// для записи в журнал аудита
+func AdminActor(c *gin.Context) string {
+	return c.GetString(adminActorKey)
+}
+
+// abortAdmin отклоняет запрос к административному API
+func abortAdmin(c *gin.Context, status int) {
+	c.JSON(status, gin.H{
+		"status": "error",
+		"error":  "доступ к административному API запрещен",
+	})
+	c.Abort()
+}
@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const testAdminToken = "admin-token-0123456789abcdef0123"
+
+// newAdminTest возвращает сервис и хранилище конфигурации, загруженной из окружения
+// с токеном администратора adminToken
+func newAdminTest(t *testing.T, adminToken string) (*service.AuthService, *config.Store) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("ADMIN_TOKEN", adminToken)
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	repo := repository.NewMemoryRepository()
+	t.Cleanup(func() { _ = repo.Close() })
+	store := config.NewStore(cfg)
+	return service.NewAuthService(repo, store), store
+}
+
+// newAdminRouter возвращает маршрутизатор, который отвечает инициатором из AdminActor
+func newAdminRouter(store *config.Store, admin service.Admin) *gin.Engine {
+	router := gin.New()
+	router.GET("/admin/ping", AdminAuth(store, admin, false), func(c *gin.Context) {
+		c.String(http.StatusOK, AdminActor(c))
+	})
+	return router
+}
+
+// adminRequest выполняет запрос к административному API с заголовком authorization
+func adminRequest(router *gin.Engine, authorization string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodGet, "/admin/ping", nil)
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// adminClientToken регистрирует сервисного клиента с областями доступа scopes и возвращает
+// его ID и access токен
+func adminClientToken(t *testing.T, s *service.AuthService, scopes []string) (uuid.UUID, string) {
+	t.Helper()
+	ctx := context.Background()
+	client, secret, err := s.CreateClient(ctx, "client", scopes, "test")
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+	pair, err := s.ClientCredentials(ctx, client.ID, secret, "agent", "192.0.2.1", models.TokenBinding{})
+	if err != nil {
+		t.Fatalf("ClientCredentials: %v", err)
+	}
+	return client.ID, pair.AccessToken
+}
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		adminToken string
+		// authorization возвращает заголовок Authorization и ожидаемого инициатора
+		authorization func(t *testing.T, s *service.AuthService) (string, string)
+		status        int
+	}{
+		{
+			name:       "статический токен",
+			adminToken: testAdminToken,
+			authorization: func(t *testing.T, s *service.AuthService) (string, string) {
+				return "Bearer " + testAdminToken, "admin-token"
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:       "неверный статический токен",
+			adminToken: testAdminToken,
+			authorization: func(t *testing.T, s *service.AuthService) (string, string) {
+				return "Bearer " + testAdminToken + "x", ""
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:       "статический токен в схеме DPoP",
+			adminToken: testAdminToken,
+			authorization: func(t *testing.T, s *service.AuthService) (string, string) {
+				return "DPoP " + testAdminToken, ""
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:       "без заголовка Authorization",
+			adminToken: testAdminToken,
+			authorization: func(t *testing.T, s *service.AuthService) (string, string) {
+				return "", ""
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:       "заголовок без схемы",
+			adminToken: testAdminToken,
+			authorization: func(t *testing.T, s *service.AuthService) (string, string) {
+				return testAdminToken, ""
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "пустой токен при незаданном ADMIN_TOKEN",
+			authorization: func(t *testing.T, s *service.AuthService) (string, string) {
+				return "Bearer ", ""
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "токен клиента с областью admin",
+			authorization: func(t *testing.T, s *service.AuthService) (string, string) {
+				clientID, token := adminClientToken(t, s, []string{models.ScopeAdmin})
+				return "Bearer " + token, "client:" + clientID.String()
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "токен клиента без области admin",
+			authorization: func(t *testing.T, s *service.AuthService) (string, string) {
+				_, token := adminClientToken(t, s, nil)
+				return "Bearer " + token, ""
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name: "токен пользователя",
+			authorization: func(t *testing.T, s *service.AuthService) (string, string) {
+				pair, err := s.Login(context.Background(), uuid.New(), "agent", "192.0.2.1", models.TokenBinding{})
+				if err != nil {
+					t.Fatalf("Login: %v", err)
+				}
+				return "Bearer " + pair.AccessToken, ""
+			},
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store := newAdminTest(t, tt.adminToken)
+			authorization, actor := tt.authorization(t, s)
+
+			recorder := adminRequest(newAdminRouter(store, s), authorization)
+			if recorder.Code != tt.status {
+				t.Fatalf("статус %d, ожидался %d: %s", recorder.Code, tt.status, recorder.Body)
+			}
+			if tt.status == http.StatusOK && recorder.Body.String() != actor {
+				t.Fatalf("инициатор %q, ожидался %q", recorder.Body, actor)
+			}
+			if tt.status != http.StatusOK && !strings.Contains(recorder.Body.String(), `"status":"error"`) {
+				t.Fatalf("тело ответа %s", recorder.Body)
+			}
+		})
+	}
+}
+
+func TestAdminAuthTokenReload(t *testing.T) {
+	s, store := newAdminTest(t, testAdminToken)
+	router := newAdminRouter(store, s)
+
+	// Новый ADMIN_TOKEN вступает в силу после перезагрузки конфигурации
+	const rotated = "rotated-admin-token-0123456789abcdef"
+	t.Setenv("ADMIN_TOKEN", rotated)
+	if recorder := adminRequest(router, "Bearer "+rotated); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("новый токен до перезагрузки: статус %d", recorder.Code)
+	}
+	changes, err := store.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Key != "ADMIN_TOKEN" || changes[0].Restart {
+		t.Fatalf("изменения %+v, ожидалось изменение ADMIN_TOKEN без перезапуска", changes)
+	}
+
+	if recorder := adminRequest(router, "Bearer "+rotated); recorder.Code != http.StatusOK {
+		t.Fatalf("новый токен: статус %d", recorder.Code)
+	}
+	if recorder := adminRequest(router, "Bearer "+testAdminToken); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("прежний токен: статус %d", recorder.Code)
+	}
+}
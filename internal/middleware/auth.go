@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"auth-service/internal/dpop"
 	"auth-service/internal/service"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -19,7 +20,7 @@ func NewAuthMiddleware(service service.Service) *AuthMiddleware {
 	}
 }
 
-// CheckAuth проверяет валидность access токена
+// CheckAuth проверяет валидность access токена, предъявленного в схеме Bearer или DPoP
 func (m *AuthMiddleware) CheckAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Получаем заголовок Authorization
@@ -35,7 +36,7 @@ func (m *AuthMiddleware) CheckAuth() gin.HandlerFunc {
 
 		// Проверяем формат заголовка
 		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != dpop.Scheme) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status": "error",
 				"error":  "неверный формат заголовка Authorization",
@@ -47,8 +48,19 @@ func (m *AuthMiddleware) CheckAuth() gin.HandlerFunc {
 		// Получаем токен
 		tokenString := parts[1]
 
-		// Проверяем токен
-		userID, err := m.service.Validate(tokenString)
+		// Токен в схеме DPoP предъявляется вместе с доказательством владения ключом
+		binding, ok := presentedBinding(c, parts[0])
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"status": "error",
+				"error":  "отсутствует DPoP доказательство",
+			})
+			c.Abort()
+			return
+		}
+
+		// Проверяем токен; привязанный токен должен быть предъявлен с тем же ключом клиента
+		userID, err := m.service.Validate(c.Request.Context(), tokenString, binding)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"status": "error",
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"auth-service/internal/certs"
+	"auth-service/internal/clientip"
+	"auth-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	clientIPKey   = "clientIP"
+	userAgentKey  = "userAgent"
+	clientCertKey = "clientCert"
+)
+
+// ClientIdentity определяет IP-адрес, User-Agent и клиентский сертификат клиента
+// и сохраняет их в контексте запроса. Все обработчики должны получать эти значения
+// через ClientInfo и ClientCertificate, а не через c.ClientIP() и c.Request.TLS.
+func ClientIdentity(resolver *clientip.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(clientIPKey, resolver.Resolve(c.Request))
+		c.Set(userAgentKey, c.GetHeader("User-Agent"))
+		if identity := certs.PeerIdentity(c.Request.TLS); identity != nil {
+			c.Set(clientCertKey, identity)
+		}
+
+		c.Next()
+	}
+}
+
+// ClientInfo возвращает User-Agent и IP-адрес клиента, определенные ClientIdentity
+func ClientInfo(c *gin.Context) (userAgent, clientIP string) {
+	return c.GetString(userAgentKey), c.GetString(clientIPKey)
+}
+
+// ClientCertificate возвращает клиентский сертификат, проверенный при установке TLS соединения
+// по удостоверяющим центрам порта; nil, если сертификат не предъявлен
+func ClientCertificate(c *gin.Context) *certs.Identity {
+	identity, _ := c.Get(clientCertKey)
+	certificate, _ := identity.(*certs.Identity)
+	return certificate
+}
+
+// ClientBinding возвращает ключи, владение которыми клиент подтвердил в запросе: отпечаток
+// клиентского сертификата соединения и отпечаток ключа DPoP доказательства, проверенного DPoP.
+// Сервис сравнивает их с привязкой токенов.
+func ClientBinding(c *gin.Context) models.TokenBinding {
+	binding := models.TokenBinding{JWKThumbprint: c.GetString(dpopKey)}
+	if certificate := ClientCertificate(c); certificate != nil {
+		binding.CertThumbprint = certificate.Thumbprint
+	}
+	return binding
+}
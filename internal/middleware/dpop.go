@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"auth-service/internal/dpop"
+	"auth-service/internal/models"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dpopKey = "dpopKey"
+
+// DPoP проверяет DPoP доказательство (RFC 9449), если клиент передал его в заголовке DPoP,
+// и сохраняет отпечаток ключа в контексте запроса для ClientBinding. Если access токен
+// предъявлен в схеме DPoP, доказательство должно содержать его хеш. Адрес запроса для
+// сравнения с htu строится из внешнего адреса сервиса baseURL.
+func DPoP(verifier *dpop.Verifier, baseURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		proofs := c.Request.Header.Values(dpop.HeaderName)
+		if len(proofs) == 0 {
+			c.Next()
+			return
+		}
+
+		var accessToken string
+		if parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2); len(parts) == 2 && parts[0] == dpop.Scheme {
+			accessToken = parts[1]
+		}
+
+		jkt, err := "", fmt.Errorf("%w: передано несколько доказательств", dpop.ErrInvalidProof)
+		if len(proofs) == 1 {
+			jkt, err = verifier.Verify(c.Request.Context(), proofs[0], c.Request.Method, requestURI(c, baseURL), accessToken)
+		}
+		if errors.Is(err, dpop.ErrReplayCheckUnavailable) {
+			slog.ErrorContext(c.Request.Context(), "Ошибка проверки повторного использования DPoP доказательства", slog.Any("error", err))
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"status":        "error",
+				"error_code":    "DPOP_UNAVAILABLE",
+				"error_message": dpop.ErrReplayCheckUnavailable.Error(),
+			})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			// К защищенному ресурсу доступ не предоставляется, а запрос токенов некорректен
+			status := http.StatusBadRequest
+			if accessToken != "" {
+				status = http.StatusUnauthorized
+				c.Header("WWW-Authenticate", fmt.Sprintf(`DPoP error="invalid_dpop_proof", algs="%s"`, strings.Join(dpop.Algorithms, " ")))
+			}
+			c.JSON(status, gin.H{
+				"status":        "error",
+				"error_code":    "INVALID_DPOP_PROOF",
+				"error_message": err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(dpopKey, jkt)
+		c.Next()
+	}
+}
+
+// requestURI возвращает адрес запроса без параметров, с которым сравнивается htu доказательства
+func requestURI(c *gin.Context, baseURL string) string {
+	return strings.TrimSuffix(baseURL, "/") + c.Request.URL.EscapedPath()
+}
+
+// presentedBinding возвращает ключ, владение которым подтверждает access токен, предъявленный
+// в схеме scheme заголовка Authorization. В схеме DPoP запрос должен содержать доказательство;
+// в схеме Bearer доказательство не учитывается, поэтому токен, привязанный к ключу DPoP, отклоняется.
+func presentedBinding(c *gin.Context, scheme string) (models.TokenBinding, bool) {
+	binding := ClientBinding(c)
+	if scheme != dpop.Scheme {
+		binding.JWKThumbprint = ""
+		return binding, true
+	}
+	return binding, binding.JWKThumbprint != ""
+}
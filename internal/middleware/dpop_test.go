@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"auth-service/internal/certs"
+	"auth-service/internal/dpop"
+	"auth-service/internal/models"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testRefreshURI = "https://auth.example.com/api/v1/refresh"
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// failingReplayCache имитирует недоступное хранилище идентификаторов доказательств
+type failingReplayCache struct{}
+
+func (failingReplayCache) Use(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return false, errors.New("хранилище недоступно")
+}
+
+// proofSigner подписывает DPoP доказательства одним ключом
+type proofSigner struct {
+	private *ecdsa.PrivateKey
+	jwk     map[string]interface{}
+	issued  int
+}
+
+func newProofSigner(t *testing.T) *proofSigner {
+	t.Helper()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &proofSigner{
+		private: private,
+		jwk: map[string]interface{}{
+			"kty": "EC",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(private.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(private.Y.FillBytes(make([]byte, 32))),
+		},
+	}
+}
+
+// proof возвращает доказательство запроса method к uri; если accessToken не пуст, оно содержит его хеш
+func (s *proofSigner) proof(t *testing.T, method, uri, accessToken string) string {
+	t.Helper()
+	s.issued++
+	claims := jwt.MapClaims{
+		"htm": method,
+		"htu": uri,
+		"iat": time.Now().Unix(),
+		"jti": "proof-" + strconv.Itoa(s.issued),
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		claims["ath"] = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = s.jwk
+	proof, err := token.SignedString(s.private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof
+}
+
+// newDPoPRouter возвращает маршрутизатор, который отвечает отпечатком ключа из ClientBinding
+func newDPoPRouter(replay dpop.ReplayCache, baseURL string) *gin.Engine {
+	router := gin.New()
+	verifier := dpop.NewVerifier(time.Minute, replay, false)
+	router.POST("/api/v1/refresh", DPoP(verifier, baseURL), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"jkt": ClientBinding(c).JWKThumbprint})
+	})
+	return router
+}
+
+func TestDPoP(t *testing.T) {
+	signer := newProofSigner(t)
+
+	tests := []struct {
+		name          string
+		replay        dpop.ReplayCache
+		authorization string
+		proofs        func(t *testing.T) []string
+		status        int
+		errorCode     string
+		// bound в контексте запроса сохранен отпечаток ключа
+		bound bool
+	}{
+		{
+			name:   "без доказательства",
+			proofs: func(t *testing.T) []string { return nil },
+			status: http.StatusOK,
+		},
+		{
+			name: "корректное доказательство",
+			proofs: func(t *testing.T) []string {
+				return []string{signer.proof(t, http.MethodPost, testRefreshURI, "")}
+			},
+			status: http.StatusOK,
+			bound:  true,
+		},
+		{
+			name: "адрес из схемы соединения и заголовка Host",
+			proofs: func(t *testing.T) []string {
+				return []string{signer.proof(t, http.MethodPost, "http://auth.example.com/api/v1/refresh", "")}
+			},
+			status:    http.StatusBadRequest,
+			errorCode: "INVALID_DPOP_PROOF",
+		},
+		{
+			name: "доказательство другого адреса",
+			proofs: func(t *testing.T) []string {
+				return []string{signer.proof(t, http.MethodPost, "https://auth.example.com/api/v1/login", "")}
+			},
+			status:    http.StatusBadRequest,
+			errorCode: "INVALID_DPOP_PROOF",
+		},
+		{
+			name:          "доказательство без ath при токене в схеме DPoP",
+			authorization: "DPoP access-token",
+			proofs: func(t *testing.T) []string {
+				return []string{signer.proof(t, http.MethodPost, testRefreshURI, "")}
+			},
+			status:    http.StatusUnauthorized,
+			errorCode: "INVALID_DPOP_PROOF",
+		},
+		{
+			name:          "доказательство с ath при токене в схеме DPoP",
+			authorization: "DPoP access-token",
+			proofs: func(t *testing.T) []string {
+				return []string{signer.proof(t, http.MethodPost, testRefreshURI, "access-token")}
+			},
+			status: http.StatusOK,
+			bound:  true,
+		},
+		{
+			name: "несколько доказательств",
+			proofs: func(t *testing.T) []string {
+				return []string{
+					signer.proof(t, http.MethodPost, testRefreshURI, ""),
+					signer.proof(t, http.MethodPost, testRefreshURI, ""),
+				}
+			},
+			status:    http.StatusBadRequest,
+			errorCode: "INVALID_DPOP_PROOF",
+		},
+		{
+			name:   "хранилище идентификаторов недоступно",
+			replay: failingReplayCache{},
+			proofs: func(t *testing.T) []string {
+				return []string{signer.proof(t, http.MethodPost, testRefreshURI, "")}
+			},
+			status:    http.StatusServiceUnavailable,
+			errorCode: "DPOP_UNAVAILABLE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay := tt.replay
+			if replay == nil {
+				replay = dpop.NewMemoryReplayCache()
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "http://auth.example.com/api/v1/refresh?state=1", nil)
+			for _, proof := range tt.proofs(t) {
+				request.Header.Add(dpop.HeaderName, proof)
+			}
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			newDPoPRouter(replay, "https://auth.example.com/").ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.status {
+				t.Fatalf("статус %d, ожидался %d: %s", recorder.Code, tt.status, recorder.Body)
+			}
+			var body struct {
+				JKT       string `json:"jkt"`
+				ErrorCode string `json:"error_code"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.ErrorCode != tt.errorCode {
+				t.Fatalf("error_code %q, ожидался %q", body.ErrorCode, tt.errorCode)
+			}
+			if (body.JKT != "") != tt.bound {
+				t.Fatalf("отпечаток ключа в контексте: %q", body.JKT)
+			}
+			if challenge := recorder.Header().Get("WWW-Authenticate"); (challenge != "") != (tt.status == http.StatusUnauthorized) {
+				t.Fatalf("WWW-Authenticate: %q", challenge)
+			}
+		})
+	}
+}
+
+func TestPresentedBinding(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme string
+		cert   string
+		jkt    string
+		want   models.TokenBinding
+		ok     bool
+	}{
+		{name: "Bearer без ключей", scheme: "Bearer", ok: true},
+		{name: "Bearer с сертификатом", scheme: "Bearer", cert: "cert", want: models.TokenBinding{CertThumbprint: "cert"}, ok: true},
+		// Доказательство не учитывается, и токен, привязанный к ключу DPoP, будет отклонен сервисом
+		{name: "Bearer с DPoP доказательством", scheme: "Bearer", jkt: "jkt", ok: true},
+		{name: "DPoP с доказательством", scheme: dpop.Scheme, jkt: "jkt", want: models.TokenBinding{JWKThumbprint: "jkt"}, ok: true},
+		{name: "DPoP с доказательством и сертификатом", scheme: dpop.Scheme, cert: "cert", jkt: "jkt", want: models.TokenBinding{CertThumbprint: "cert", JWKThumbprint: "jkt"}, ok: true},
+		{name: "DPoP без доказательства", scheme: dpop.Scheme, cert: "cert", want: models.TokenBinding{CertThumbprint: "cert"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			if tt.cert != "" {
+				c.Set(clientCertKey, &certs.Identity{Thumbprint: tt.cert})
+			}
+			if tt.jkt != "" {
+				c.Set(dpopKey, tt.jkt)
+			}
+
+			binding, ok := presentedBinding(c, tt.scheme)
+			if ok != tt.ok || binding != tt.want {
+				t.Fatalf("presentedBinding = %+v, %v, ожидалось %+v, %v", binding, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
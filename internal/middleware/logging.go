@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"auth-service/internal/logging"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину идентификатора, переданного клиентом
+const maxRequestIDLength = 128
+
+// RequestID назначает запросу идентификатор и возвращает его в заголовке ответа.
+// Идентификатор из заголовка запроса используется, если он корректен, иначе генерируется новый.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+}
+
+// AccessLog записывает в лог результат обработки каждого запроса.
+// Тело запроса не записывается, а чувствительные параметры и заголовки скрываются.
+func AccessLog(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("query", logging.Query(c.Request.URL.RawQuery)),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.GetString("clientIP")),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+		if identity := ClientCertificate(c); identity != nil {
+			attrs = append(attrs, slog.String("client_cert", identity.Name()))
+		}
+		if logger.Enabled(c.Request.Context(), slog.LevelDebug) {
+			attrs = append(attrs, slog.Any("headers", logging.Header(c.Request.Header)))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		logger.LogAttrs(c.Request.Context(), level, "HTTP запрос", attrs...)
+	}
+}
+
+// Recovery перехватывает панику обработчика, записывает ее в лог и возвращает 500
+func Recovery(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		logger.ErrorContext(c.Request.Context(), "Паника при обработке запроса",
+			slog.Any("panic", recovered),
+			slog.String("path", c.Request.URL.Path),
+		)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"status":        "error",
+			"error_code":    "INTERNAL_ERROR",
+			"error_message": "внутренняя ошибка сервера",
+		})
+	})
+}
+
+// validRequestID проверяет идентификатор запроса, переданный клиентом
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
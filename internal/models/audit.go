@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Результаты действий в журнале аудита
+const (
+	// AuditOutcomeSuccess действие выполнено успешно
+	AuditOutcomeSuccess = "success"
+	// AuditOutcomeFailure действие отклонено или завершилось ошибкой
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEvent запись журнала аудита безопасности
+type AuditEvent struct {
+	ID         int64     `json:"id" db:"id"`
+	EventID    string    `json:"event_id" db:"event_id"`
+	EventType  string    `json:"event_type" db:"event_type"`
+	OccurredAt time.Time `json:"occurred_at" db:"occurred_at"`
+	ActorID    string    `json:"actor_id,omitempty" db:"actor_id"`
+	SubjectID  string    `json:"subject_id,omitempty" db:"subject_id"`
+	SessionID  *int      `json:"session_id,omitempty" db:"session_id"`
+	ClientIP   string    `json:"client_ip,omitempty" db:"client_ip"`
+	UserAgent  string    `json:"user_agent,omitempty" db:"user_agent"`
+	Outcome    string    `json:"outcome" db:"outcome"`
+	Reason     string    `json:"reason,omitempty" db:"reason"`
+}
+
+// AuditEventFilter фильтр поиска по журналу аудита
+type AuditEventFilter struct {
+	// UserID совпадает с инициатором или субъектом действия
+	UserID    string
+	EventType string
+	// From и To ограничивают время события: From <= occurred_at < To
+	From time.Time
+	To   time.Time
+	// Limit равный нулю снимает ограничение на количество записей
+	Limit  int
+	Offset int
+}
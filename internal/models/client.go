@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Области доступа сервисных клиентов
+const (
+	// ScopeAdmin разрешает клиенту обращаться к административному API со своим access токеном
+	ScopeAdmin = "admin"
+)
+
+// ClientScopes допустимые области доступа сервисных клиентов
+var ClientScopes = []string{ScopeAdmin}
+
+// Client сервисный клиент, получающий токены по client_credentials.
+// Токены клиента выпускаются на его ID так же, как токены пользователя.
+type Client struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	Name       string    `json:"name" db:"name"`
+	SecretHash string    `json:"-" db:"secret_hash"`
+	// Scopes области доступа клиента
+	Scopes    []string  `json:"scopes" db:"scopes"`
+	Disabled  bool      `json:"disabled" db:"disabled"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// HasScope сообщает, предоставлена ли клиенту область доступа scope
+func (c *Client) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CloudEventsSpecVersion версия спецификации CloudEvents
+const CloudEventsSpecVersion = "1.0"
+
+// Event событие предметной области в формате CloudEvents 1.0 (JSON)
+type Event struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Subject         string    `json:"subject,omitempty"`
+	Time            time.Time `json:"time"`
+	DataContentType string    `json:"datacontenttype,omitempty"`
+	// TraceParent и TraceState контекст трассировки операции, создавшей событие
+	// (расширение CloudEvents Distributed Tracing)
+	TraceParent string          `json:"traceparent,omitempty"`
+	TraceState  string          `json:"tracestate,omitempty"`
+	Data        json.RawMessage `json:"data,omitempty"`
+}
+
+// EventRecord событие в журнале событий, ожидающее публикации
+type EventRecord struct {
+	ID    int64
+	Event Event
+}
+
+// SessionEventData данные событий о действиях с сессиями
+type SessionEventData struct {
+	ActorID   string `json:"actor_id,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	SessionID int    `json:"session_id,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	OldIP     string `json:"old_ip,omitempty"`
+	NewIP     string `json:"new_ip,omitempty"`
+	Outcome   string `json:"outcome"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ConfigEventData данные события о перезагрузке конфигурации
+type ConfigEventData struct {
+	ActorID string         `json:"actor_id,omitempty"`
+	Outcome string         `json:"outcome"`
+	Reason  string         `json:"reason,omitempty"`
+	Changes []ConfigChange `json:"changes,omitempty"`
+}
+
+// ConfigChange изменение параметра конфигурации; значения секретов скрыты
+type ConfigChange struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+	// Restart новое значение вступит в силу только после перезапуска
+	Restart bool `json:"restart,omitempty"`
+}
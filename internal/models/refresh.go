@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RefreshTokenRotation замена refresh токена сессии
+type RefreshTokenRotation struct {
+	SessionID int
+	// OldRefreshToken хеш заменяемого refresh токена
+	OldRefreshToken string
+	// NewRefreshToken хеш нового refresh токена
+	NewRefreshToken   string
+	NewRefreshTokenID string
+	ExpiresAt         int64
+	// Successor выданная пара токенов, зашифрованная ключом из заменяемого токена
+	Successor []byte
+}
+
+// RefreshTokenHistory запись о замененном refresh токене
+type RefreshTokenHistory struct {
+	// RefreshToken хеш замененного refresh токена
+	RefreshToken string
+	SessionID    int
+	RotatedAt    time.Time
+	// Successor пара токенов, выданная взамен; nil, если после нее токены уже обновлялись
+	Successor []byte
+}
@@ -1,9 +1,19 @@
 package models
 
+// Типы access токенов (token_type)
+const (
+	// TokenTypeBearer токен предъявляется в заголовке Authorization: Bearer
+	TokenTypeBearer = "Bearer"
+	// TokenTypeDPoP токен привязан к ключу DPoP и предъявляется в заголовке Authorization: DPoP
+	TokenTypeDPoP = "DPoP"
+)
+
 // TokenPair содержит пару токенов доступа и обновления
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	// TokenType схема, в которой предъявляется access токен: Bearer или DPoP
+	TokenType string `json:"token_type,omitempty"`
 }
 
 // Response стандартный формат ответа API
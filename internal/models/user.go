@@ -1,6 +1,10 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // User представляет модель пользователя в системе
 type User struct {
@@ -17,4 +21,48 @@ type Session struct {
 	IsBlocked     bool      `json:"-" db:"is_blocked"`
 	ExpiresAt     int64     `json:"-" db:"expires_at"`
 	RefreshTokenID string    `json:"-" db:"refresh_token_id"`
-} 
\ No newline at end of file
+	CreatedAt     time.Time `json:"-" db:"created_at"`
+	// Binding ключ клиента, к которому привязаны токены сессии
+	Binding TokenBinding `json:"-"`
+	// Kind вид сессии: SessionKindUser или SessionKindClient
+	Kind string `json:"-" db:"kind"`
+} 
+
+const (
+	// SessionKindUser сессия, созданная входом пользователя
+	SessionKindUser = "user"
+	// SessionKindClient сессия сервисного клиента, подтвердившего свой секрет
+	SessionKindClient = "client"
+)
+
+// TokenBinding привязка токенов к ключу, которым владеет клиент (sender-constrained токены).
+// Пустое значение означает, что токены не привязаны.
+type TokenBinding struct {
+	// CertThumbprint SHA-256 отпечаток клиентского сертификата (x5t#S256, RFC 8705)
+	CertThumbprint string `json:"x5t#S256,omitempty"`
+	// JWKThumbprint SHA-256 отпечаток открытого ключа DPoP (jkt, RFC 9449)
+	JWKThumbprint string `json:"jkt,omitempty"`
+}
+
+// IsZero сообщает, что токены не привязаны к ключу клиента
+func (b TokenBinding) IsZero() bool {
+	return b == TokenBinding{}
+}
+
+// SessionFilter условия выборки сессий
+type SessionFilter struct {
+	// UserID равный uuid.Nil выбирает сессии всех пользователей
+	UserID uuid.UUID
+	// ActiveOnly выбирает только незаблокированные сессии с действующим refresh токеном
+	ActiveOnly bool
+	// Limit равный нулю снимает ограничение на количество записей
+	Limit  int
+	Offset int
+}
+
+// BlockedSession заблокированная сессия
+type BlockedSession struct {
+	SessionID int
+	UserID    uuid.UUID
+	BlockedAt time.Time
+}
\ No newline at end of file
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы доставки webhook сообщений
+const (
+	// WebhookStatusPending сообщение ожидает доставки или повторной попытки
+	WebhookStatusPending = "pending"
+	// WebhookStatusDelivered сообщение успешно доставлено
+	WebhookStatusDelivered = "delivered"
+	// WebhookStatusDead сообщение не удалось доставить за допустимое число попыток
+	WebhookStatusDead = "dead"
+)
+
+// Типы событий
+const (
+	// EventSessionCreated создана новая сессия (вход пользователя)
+	EventSessionCreated = "session.created"
+	// EventSessionRevoked сессии пользователя отозваны (выход)
+	EventSessionRevoked = "session.revoked"
+	// EventSessionRefreshed токены сессии обновлены
+	EventSessionRefreshed = "session.refreshed"
+	// EventSessionIPChanged токены обновлены с нового IP-адреса
+	EventSessionIPChanged = "session.ip_changed"
+	// EventRefreshReuseDetected повторно использован уже замененный refresh токен
+	EventRefreshReuseDetected = "refresh.reuse_detected"
+	// EventLoginFailed не удалось выполнить вход
+	EventLoginFailed = "login.failed"
+	// EventRefreshFailed не удалось обновить токены
+	EventRefreshFailed = "refresh.failed"
+	// EventLogoutFailed не удалось выполнить выход
+	EventLogoutFailed = "logout.failed"
+	// EventUserLocked все сессии пользователя заблокированы из-за подозрительной активности
+	EventUserLocked = "user.locked"
+	// EventClientCreated зарегистрирован сервисный клиент
+	EventClientCreated = "client.created"
+	// EventClientUpdated изменены параметры или секрет сервисного клиента
+	EventClientUpdated = "client.updated"
+	// EventConfigReloaded конфигурация перезагружена без перезапуска
+	EventConfigReloaded = "config.reloaded"
+
+	// EventAll подписка на все типы событий
+	EventAll = "*"
+)
+
+// EventTypes список всех известных типов событий
+var EventTypes = []string{
+	EventSessionCreated,
+	EventSessionRevoked,
+	EventSessionRefreshed,
+	EventSessionIPChanged,
+	EventRefreshReuseDetected,
+	EventLoginFailed,
+	EventRefreshFailed,
+	EventLogoutFailed,
+	EventUserLocked,
+	EventClientCreated,
+	EventClientUpdated,
+	EventConfigReloaded,
+}
+
+// WebhookSubscription подписка получателя webhook на типы событий
+type WebhookSubscription struct {
+	ID          int64     `json:"id" db:"id"`
+	URL         string    `json:"url" db:"url"`
+	Description string    `json:"description,omitempty" db:"description"`
+	EventTypes  []string  `json:"event_types" db:"event_types"`
+	Secrets     []string  `json:"-" db:"secrets"`
+	Enabled     bool      `json:"enabled" db:"enabled"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Matches сообщает, подписана ли подписка на событие указанного типа
+func (s *WebhookSubscription) Matches(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == EventAll || t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookMessage сообщение в очереди исходящих webhook (outbox)
+type WebhookMessage struct {
+	ID             int64           `json:"id" db:"id"`
+	SubscriptionID *int64          `json:"subscription_id,omitempty" db:"subscription_id"`
+	EventID        string          `json:"event_id,omitempty" db:"event_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError      string          `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// WebhookMessageFilter параметры выборки сообщений из outbox
+type WebhookMessageFilter struct {
+	SubscriptionID int64
+	Status         string
+	Limit          int
+	Offset         int
+}
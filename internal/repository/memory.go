@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryRepository реализация Repository, хранящая данные в памяти процесса.
+// Предназначена для тестов и запуска одного экземпляра сервиса при разработке:
+// данные теряются при остановке процесса. Безопасна для одновременного использования.
+type MemoryRepository struct {
+	mu sync.Mutex
+
+	sessions      map[int]*models.Session
+	sessionTokens map[string]int
+	tokenHistory  map[string]*models.RefreshTokenHistory
+	blockedAt     map[int]time.Time
+	lastSessionID int
+
+	eventLog    []*memoryEventRecord
+	auditEvents []*models.AuditEvent
+	auditIDs    map[string]bool
+
+	outbox        []*memoryWebhookMessage
+	subscriptions map[int64]*models.WebhookSubscription
+	lastOutboxID  int64
+	lastSubID     int64
+
+	clients map[uuid.UUID]*models.Client
+}
+
+// MemoryRepository должен оставаться взаимозаменяемым с PostgresRepository
+var _ Repository = (*MemoryRepository)(nil)
+
+// NewMemoryRepository создает новый экземпляр MemoryRepository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		sessions:      make(map[int]*models.Session),
+		sessionTokens: make(map[string]int),
+		tokenHistory:  make(map[string]*models.RefreshTokenHistory),
+		blockedAt:     make(map[int]time.Time),
+		auditIDs:      make(map[string]bool),
+		subscriptions: make(map[int64]*models.WebhookSubscription),
+		clients:       make(map[uuid.UUID]*models.Client),
+	}
+}
+
+// CreateSession создает новую сессию пользователя
+func (r *MemoryRepository) CreateSession(ctx context.Context, userID uuid.UUID, refreshToken, refreshTokenID, userAgent, clientIP, kind string, binding models.TokenBinding, expiresAt int64, events ...models.Event) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sessionTokens[refreshToken]; ok {
+		return 0, fmt.Errorf("не удалось создать сессию: refresh токен уже используется")
+	}
+
+	r.lastSessionID++
+	session := &models.Session{
+		ID:             r.lastSessionID,
+		UserID:         userID,
+		RefreshToken:   refreshToken,
+		RefreshTokenID: refreshTokenID,
+		UserAgent:      userAgent,
+		ClientIP:       clientIP,
+		ExpiresAt:      expiresAt,
+		CreatedAt:      time.Now(),
+		Binding:        binding,
+		Kind:           kind,
+	}
+	r.sessions[session.ID] = session
+	r.sessionTokens[refreshToken] = session.ID
+	r.insertEvents(session.ID, events)
+
+	return session.ID, nil
+}
+
+// GetSessionByRefreshToken возвращает сессию по хешу refresh токена
+func (r *MemoryRepository) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, ok := r.sessionTokens[refreshTokenHash]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	session := r.sessions[id]
+	if session.IsBlocked {
+		return nil, ErrSessionRevoked
+	}
+	if session.ExpiresAt <= time.Now().Unix() {
+		return nil, ErrSessionExpired
+	}
+
+	copied := *session
+	return &copied, nil
+}
+
+// GetSession возвращает сессию по ID независимо от ее состояния
+func (r *MemoryRepository) GetSession(ctx context.Context, sessionID int) (*models.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	copied := *session
+	return &copied, nil
+}
+
+// RotateRefreshToken заменяет refresh токен сессии, если он не был заменен конкурирующим запросом
+func (r *MemoryRepository) RotateRefreshToken(ctx context.Context, rotation models.RefreshTokenRotation, events ...models.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[rotation.SessionID]
+	switch {
+	case !ok:
+		return ErrSessionNotFound
+	case session.IsBlocked:
+		return ErrSessionRevoked
+	case session.RefreshToken != rotation.OldRefreshToken:
+		return ErrRefreshTokenRotated
+	case session.ExpiresAt <= time.Now().Unix():
+		return ErrSessionExpired
+	}
+	if _, ok := r.sessionTokens[rotation.NewRefreshToken]; ok {
+		return fmt.Errorf("не удалось обновить сессию: refresh токен уже используется")
+	}
+
+	// Пары, выданные при предыдущих заменах, больше не возвращаются повторно
+	for _, history := range r.tokenHistory {
+		if history.SessionID == session.ID {
+			history.Successor = nil
+		}
+	}
+	r.tokenHistory[rotation.OldRefreshToken] = &models.RefreshTokenHistory{
+		RefreshToken: rotation.OldRefreshToken,
+		SessionID:    session.ID,
+		RotatedAt:    time.Now(),
+		Successor:    cloneBytes(rotation.Successor),
+	}
+
+	delete(r.sessionTokens, session.RefreshToken)
+	session.RefreshToken = rotation.NewRefreshToken
+	session.RefreshTokenID = rotation.NewRefreshTokenID
+	session.ExpiresAt = rotation.ExpiresAt
+	r.sessionTokens[session.RefreshToken] = session.ID
+	r.insertEvents(session.ID, events)
+
+	return nil
+}
+
+// GetRefreshTokenHistory возвращает запись о замененном refresh токене по его хешу
+func (r *MemoryRepository) GetRefreshTokenHistory(ctx context.Context, refreshTokenHash string) (*models.RefreshTokenHistory, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history, ok := r.tokenHistory[refreshTokenHash]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	copied := *history
+	copied.Successor = cloneBytes(history.Successor)
+	return &copied, nil
+}
+
+// BlockSession блокирует сессию
+func (r *MemoryRepository) BlockSession(ctx context.Context, sessionID int, events ...models.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session, ok := r.sessions[sessionID]; ok {
+		session.IsBlocked = true
+		r.blockedAt[sessionID] = time.Now()
+	}
+	r.insertEvents(sessionID, events)
+
+	return nil
+}
+
+// BlockAllUserSessions блокирует все сессии пользователя
+func (r *MemoryRepository) BlockAllUserSessions(ctx context.Context, userID uuid.UUID, events ...models.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, session := range r.sessions {
+		if session.UserID == userID {
+			session.IsBlocked = true
+			r.blockedAt[session.ID] = time.Now()
+		}
+	}
+	r.insertEvents(0, events)
+
+	return nil
+}
+
+// BlockSessionsCreatedBefore блокирует все незаблокированные сессии, созданные раньше before
+func (r *MemoryRepository) BlockSessionsCreatedBefore(ctx context.Context, before time.Time, events ...models.Event) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blocked := 0
+	for _, session := range r.sessions {
+		if !session.IsBlocked && session.CreatedAt.Before(before) {
+			session.IsBlocked = true
+			r.blockedAt[session.ID] = time.Now()
+			blocked++
+		}
+	}
+	r.insertEvents(0, events)
+
+	return blocked, nil
+}
+
+// ListSessions возвращает сессии по фильтру, начиная с самых новых
+func (r *MemoryRepository) ListSessions(ctx context.Context, filter models.SessionFilter) ([]*models.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().Unix()
+	var matched []*models.Session
+	for _, session := range r.sessions {
+		if filter.UserID != uuid.Nil && session.UserID != filter.UserID {
+			continue
+		}
+		if filter.ActiveOnly && (session.IsBlocked || session.ExpiresAt <= now) {
+			continue
+		}
+		copied := *session
+		matched = append(matched, &copied)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID > matched[j].ID })
+
+	return page(matched, filter.Limit, filter.Offset), nil
+}
+
+// ListBlockedSessions возвращает сессии, заблокированные не раньше since
+func (r *MemoryRepository) ListBlockedSessions(ctx context.Context, since time.Time) ([]models.BlockedSession, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var blocked []models.BlockedSession
+	for id, blockedAt := range r.blockedAt {
+		if !blockedAt.Before(since) {
+			blocked = append(blocked, models.BlockedSession{
+				SessionID: id,
+				UserID:    r.sessions[id].UserID,
+				BlockedAt: blockedAt,
+			})
+		}
+	}
+	sort.Slice(blocked, func(i, j int) bool { return blocked[i].SessionID < blocked[j].SessionID })
+
+	return blocked, nil
+}
+
+// DeleteStaleSessions удаляет истекшие и заблокированные раньше before сессии вместе с историей их токенов
+func (r *MemoryRepository) DeleteStaleSessions(ctx context.Context, before time.Time, limit int) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var stale []int
+	for id, session := range r.sessions {
+		blockedAt, blocked := r.blockedAt[id]
+		if session.ExpiresAt < before.Unix() || (blocked && blockedAt.Before(before)) {
+			stale = append(stale, id)
+		}
+	}
+	sort.Ints(stale)
+	if len(stale) > limit {
+		stale = stale[:limit]
+	}
+
+	deleted := make(map[int]bool, len(stale))
+	for _, id := range stale {
+		delete(r.sessionTokens, r.sessions[id].RefreshToken)
+		delete(r.sessions, id)
+		delete(r.blockedAt, id)
+		deleted[id] = true
+	}
+	for hash, history := range r.tokenHistory {
+		if deleted[history.SessionID] {
+			delete(r.tokenHistory, hash)
+		}
+	}
+
+	return len(stale), nil
+}
+
+// CountActiveSessions возвращает количество незаблокированных сессий с действующим refresh токеном
+func (r *MemoryRepository) CountActiveSessions(ctx context.Context) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().Unix()
+	count := 0
+	for _, session := range r.sessions {
+		if !session.IsBlocked && session.ExpiresAt > now {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Ping всегда успешен: хранилище находится в памяти процесса
+func (r *MemoryRepository) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// CheckSchema всегда успешен: хранилищу в памяти не нужна схема
+func (r *MemoryRepository) CheckSchema(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Close ничего не делает: данные остаются доступными до завершения процесса
+func (r *MemoryRepository) Close() error {
+	return nil
+}
+
+// cloneBytes копирует срез, сохраняя различие между nil и пустым срезом
+func cloneBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateClient создает сервисного клиента
+func (r *MemoryRepository) CreateClient(ctx context.Context, client *models.Client) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clients[client.ID]; ok {
+		return fmt.Errorf("не удалось создать клиента: клиент %s уже существует", client.ID)
+	}
+
+	client.CreatedAt = time.Now()
+	r.clients[client.ID] = copyClient(client)
+
+	return nil
+}
+
+// GetClient возвращает сервисного клиента по ID
+func (r *MemoryRepository) GetClient(ctx context.Context, id uuid.UUID) (*models.Client, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return nil, ErrClientNotFound
+	}
+
+	return copyClient(client), nil
+}
+
+// ListClients возвращает всех сервисных клиентов в порядке создания
+func (r *MemoryRepository) ListClients(ctx context.Context) ([]*models.Client, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var clients []*models.Client
+	for _, client := range r.clients {
+		clients = append(clients, copyClient(client))
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if !clients[i].CreatedAt.Equal(clients[j].CreatedAt) {
+			return clients[i].CreatedAt.Before(clients[j].CreatedAt)
+		}
+		return clients[i].ID.String() < clients[j].ID.String()
+	})
+
+	return clients, nil
+}
+
+// UpdateClient сохраняет название, секрет, области доступа и состояние клиента
+func (r *MemoryRepository) UpdateClient(ctx context.Context, client *models.Client) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.clients[client.ID]
+	if !ok {
+		return ErrClientNotFound
+	}
+
+	client.CreatedAt = stored.CreatedAt
+	r.clients[client.ID] = copyClient(client)
+
+	return nil
+}
+
+// copyClient копирует клиента вместе со списком областей доступа
+func copyClient(client *models.Client) *models.Client {
+	copied := *client
+	copied.Scopes = append([]string{}, client.Scopes...)
+	return &copied
+}
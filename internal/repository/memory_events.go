@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"time"
+)
+
+// memoryEventRecord событие в журнале событий MemoryRepository
+type memoryEventRecord struct {
+	id          int64
+	event       models.Event
+	lockedUntil time.Time
+	published   bool
+}
+
+// insertEvents записывает события в журнал событий и журнал аудита.
+// Вызывается под r.mu.
+func (r *MemoryRepository) insertEvents(sessionID int, events []models.Event) {
+	for _, event := range events {
+		r.eventLog = append(r.eventLog, &memoryEventRecord{
+			id:    int64(len(r.eventLog) + 1),
+			event: event,
+		})
+
+		// Повторная запись события с тем же ID игнорируется
+		if r.auditIDs[event.ID] {
+			continue
+		}
+		audit := newAuditEvent(event, sessionID)
+		audit.ID = int64(len(r.auditEvents) + 1)
+		r.auditEvents = append(r.auditEvents, audit)
+		r.auditIDs[event.ID] = true
+	}
+}
+
+// AppendEvents записывает в журнал события, не связанные с изменением сессии
+func (r *MemoryRepository) AppendEvents(ctx context.Context, events ...models.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.insertEvents(0, events)
+	return nil
+}
+
+// ClaimEvents захватывает неопубликованные события
+func (r *MemoryRepository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]*models.EventRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var records []*models.EventRecord
+	for _, record := range r.eventLog {
+		if len(records) >= limit {
+			break
+		}
+		if record.published || record.lockedUntil.After(now) {
+			continue
+		}
+		record.lockedUntil = now.Add(lease)
+		records = append(records, &models.EventRecord{ID: record.id, Event: record.event})
+	}
+
+	return records, nil
+}
+
+// MarkEventsPublished отмечает события как опубликованные
+func (r *MemoryRepository) MarkEventsPublished(ctx context.Context, ids ...int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		if id >= 1 && id <= int64(len(r.eventLog)) {
+			record := r.eventLog[id-1]
+			record.published = true
+			record.lockedUntil = time.Time{}
+		}
+	}
+
+	return nil
+}
+
+// ListAuditEvents возвращает записи аудита по фильтру, начиная с самых новых
+func (r *MemoryRepository) ListAuditEvents(ctx context.Context, filter models.AuditEventFilter) ([]*models.AuditEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*models.AuditEvent
+	for i := len(r.auditEvents) - 1; i >= 0; i-- {
+		if matchesAuditFilter(r.auditEvents[i], filter) {
+			matched = append(matched, r.auditEvents[i])
+		}
+	}
+
+	return copyAuditEvents(page(matched, filter.Limit, filter.Offset)), nil
+}
+
+// ExportAuditEvents передает в fn записи аудита по фильтру в порядке их добавления
+func (r *MemoryRepository) ExportAuditEvents(ctx context.Context, filter models.AuditEventFilter, fn func(event *models.AuditEvent) error) error {
+	r.mu.Lock()
+	var matched []*models.AuditEvent
+	for _, event := range r.auditEvents {
+		if matchesAuditFilter(event, filter) {
+			matched = append(matched, event)
+		}
+	}
+	events := copyAuditEvents(page(matched, filter.Limit, filter.Offset))
+	r.mu.Unlock()
+
+	// fn вызывается без блокировки, чтобы медленный получатель не останавливал запись событий
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// matchesAuditFilter проверяет, удовлетворяет ли запись аудита фильтру
+func matchesAuditFilter(event *models.AuditEvent, filter models.AuditEventFilter) bool {
+	if filter.UserID != "" && event.ActorID != filter.UserID && event.SubjectID != filter.UserID {
+		return false
+	}
+	if filter.EventType != "" && event.EventType != filter.EventType {
+		return false
+	}
+	if !filter.From.IsZero() && event.OccurredAt.Before(filter.From) {
+		return false
+	}
+	if !filter.To.IsZero() && !event.OccurredAt.Before(filter.To) {
+		return false
+	}
+	return true
+}
+
+// copyAuditEvents копирует записи аудита, чтобы вызывающий код не изменял хранилище
+func copyAuditEvents(events []*models.AuditEvent) []*models.AuditEvent {
+	var copied []*models.AuditEvent
+	for _, event := range events {
+		c := *event
+		if event.SessionID != nil {
+			sessionID := *event.SessionID
+			c.SessionID = &sessionID
+		}
+		copied = append(copied, &c)
+	}
+	return copied
+}
+
+// page возвращает часть среза по limit и offset; limit 0 означает все элементы
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
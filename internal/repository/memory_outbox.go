@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// memoryWebhookMessage сообщение в outbox MemoryRepository
+type memoryWebhookMessage struct {
+	message     models.WebhookMessage
+	lockedUntil time.Time
+}
+
+// EnqueueWebhookEvents ставит события в очередь отдельно для каждой включенной подписки на их тип
+func (r *MemoryRepository) EnqueueWebhookEvents(ctx context.Context, events ...models.Event) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	payloads := make([][]byte, len(events))
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации события: %w", err)
+		}
+		payloads[i] = payload
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i, event := range events {
+		for _, subscription := range r.sortedSubscriptions() {
+			if !subscription.Enabled || !subscription.Matches(event.Type) {
+				continue
+			}
+			subscriptionID := subscription.ID
+			r.lastOutboxID++
+			r.outbox = append(r.outbox, &memoryWebhookMessage{message: models.WebhookMessage{
+				ID:             r.lastOutboxID,
+				SubscriptionID: &subscriptionID,
+				EventID:        event.ID,
+				EventType:      event.Type,
+				Payload:        payloads[i],
+				Status:         models.WebhookStatusPending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+			}})
+		}
+	}
+
+	return nil
+}
+
+// ClaimWebhookMessages захватывает готовые к отправке сообщения
+func (r *MemoryRepository) ClaimWebhookMessages(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var ready []*memoryWebhookMessage
+	for _, stored := range r.outbox {
+		if stored.message.Status == models.WebhookStatusPending &&
+			!stored.message.NextAttemptAt.After(now) &&
+			!stored.lockedUntil.After(now) {
+			ready = append(ready, stored)
+		}
+	}
+	sort.SliceStable(ready, func(i, j int) bool {
+		return ready[i].message.NextAttemptAt.Before(ready[j].message.NextAttemptAt)
+	})
+
+	var messages []*models.WebhookMessage
+	for _, stored := range page(ready, limit, 0) {
+		stored.message.Attempts++
+		stored.lockedUntil = now.Add(lease)
+		messages = append(messages, copyWebhookMessage(&stored.message))
+	}
+
+	return messages, nil
+}
+
+// MarkWebhookDelivered отмечает сообщение как доставленное
+func (r *MemoryRepository) MarkWebhookDelivered(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored := r.findWebhookMessage(id); stored != nil {
+		deliveredAt := time.Now()
+		stored.message.Status = models.WebhookStatusDelivered
+		stored.message.DeliveredAt = &deliveredAt
+		stored.message.LastError = ""
+		stored.lockedUntil = time.Time{}
+	}
+
+	return nil
+}
+
+// MarkWebhookFailed сохраняет ошибку доставки и планирует повторную попытку
+func (r *MemoryRepository) MarkWebhookFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored := r.findWebhookMessage(id); stored != nil {
+		stored.message.Status = models.WebhookStatusPending
+		if dead {
+			stored.message.Status = models.WebhookStatusDead
+		}
+		stored.message.LastError = lastError
+		stored.message.NextAttemptAt = nextAttemptAt
+		stored.lockedUntil = time.Time{}
+	}
+
+	return nil
+}
+
+// RetryWebhookMessage возвращает сообщение в очередь для немедленной отправки
+func (r *MemoryRepository) RetryWebhookMessage(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.findWebhookMessage(id)
+	if stored == nil || stored.message.Status == models.WebhookStatusDelivered {
+		return ErrWebhookMessageNotFound
+	}
+	stored.message.Status = models.WebhookStatusPending
+	stored.message.Attempts = 0
+	stored.message.NextAttemptAt = time.Now()
+	stored.lockedUntil = time.Time{}
+
+	return nil
+}
+
+// GetWebhookMessage возвращает сообщение по ID
+func (r *MemoryRepository) GetWebhookMessage(ctx context.Context, id int64) (*models.WebhookMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.findWebhookMessage(id)
+	if stored == nil {
+		return nil, ErrWebhookMessageNotFound
+	}
+
+	return copyWebhookMessage(&stored.message), nil
+}
+
+// ListWebhookMessages возвращает сообщения, удовлетворяющие фильтру, начиная с самых новых
+func (r *MemoryRepository) ListWebhookMessages(ctx context.Context, filter models.WebhookMessageFilter) ([]*models.WebhookMessage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*models.WebhookMessage
+	for i := len(r.outbox) - 1; i >= 0; i-- {
+		message := &r.outbox[i].message
+		if filter.Status != "" && message.Status != filter.Status {
+			continue
+		}
+		if filter.SubscriptionID != 0 && (message.SubscriptionID == nil || *message.SubscriptionID != filter.SubscriptionID) {
+			continue
+		}
+		matched = append(matched, message)
+	}
+
+	var messages []*models.WebhookMessage
+	for _, message := range page(matched, filter.Limit, filter.Offset) {
+		messages = append(messages, copyWebhookMessage(message))
+	}
+	return messages, nil
+}
+
+// CountWebhookBacklog возвращает количество сообщений, ожидающих отправки дольше olderThan
+func (r *MemoryRepository) CountWebhookBacklog(ctx context.Context, olderThan time.Duration) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	threshold := time.Now().Add(-olderThan)
+	count := 0
+	for _, stored := range r.outbox {
+		if stored.message.Status == models.WebhookStatusPending && !stored.message.NextAttemptAt.After(threshold) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// findWebhookMessage ищет сообщение по ID. Вызывается под r.mu.
+func (r *MemoryRepository) findWebhookMessage(id int64) *memoryWebhookMessage {
+	for _, stored := range r.outbox {
+		if stored.message.ID == id {
+			return stored
+		}
+	}
+	return nil
+}
+
+// copyWebhookMessage копирует сообщение, чтобы вызывающий код не изменял хранилище
+func copyWebhookMessage(message *models.WebhookMessage) *models.WebhookMessage {
+	copied := *message
+	copied.Payload = cloneBytes(message.Payload)
+	if message.SubscriptionID != nil {
+		subscriptionID := *message.SubscriptionID
+		copied.SubscriptionID = &subscriptionID
+	}
+	if message.DeliveredAt != nil {
+		deliveredAt := *message.DeliveredAt
+		copied.DeliveredAt = &deliveredAt
+	}
+	return &copied
+}
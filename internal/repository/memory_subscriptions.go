@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"sort"
+	"time"
+)
+
+// CreateWebhookSubscription создает подписку на webhook
+func (r *MemoryRepository) CreateWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastSubID++
+	now := time.Now()
+	subscription.ID = r.lastSubID
+	subscription.CreatedAt = now
+	subscription.UpdatedAt = now
+	r.subscriptions[subscription.ID] = copyWebhookSubscription(subscription)
+
+	return nil
+}
+
+// GetWebhookSubscription возвращает подписку по ID
+func (r *MemoryRepository) GetWebhookSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription, ok := r.subscriptions[id]
+	if !ok {
+		return nil, ErrWebhookSubscriptionNotFound
+	}
+
+	return copyWebhookSubscription(subscription), nil
+}
+
+// ListWebhookSubscriptions возвращает все подписки
+func (r *MemoryRepository) ListWebhookSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var subscriptions []*models.WebhookSubscription
+	for _, subscription := range r.sortedSubscriptions() {
+		subscriptions = append(subscriptions, copyWebhookSubscription(subscription))
+	}
+
+	return subscriptions, nil
+}
+
+// UpdateWebhookSubscription сохраняет изменения подписки
+func (r *MemoryRepository) UpdateWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.subscriptions[subscription.ID]
+	if !ok {
+		return ErrWebhookSubscriptionNotFound
+	}
+
+	subscription.CreatedAt = stored.CreatedAt
+	subscription.UpdatedAt = time.Now()
+	r.subscriptions[subscription.ID] = copyWebhookSubscription(subscription)
+
+	return nil
+}
+
+// DeleteWebhookSubscription удаляет подписку вместе с ее сообщениями в outbox
+func (r *MemoryRepository) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.subscriptions[id]; !ok {
+		return ErrWebhookSubscriptionNotFound
+	}
+	delete(r.subscriptions, id)
+
+	outbox := r.outbox[:0]
+	for _, stored := range r.outbox {
+		if stored.message.SubscriptionID == nil || *stored.message.SubscriptionID != id {
+			outbox = append(outbox, stored)
+		}
+	}
+	r.outbox = outbox
+
+	return nil
+}
+
+// sortedSubscriptions возвращает подписки в порядке ID. Вызывается под r.mu.
+func (r *MemoryRepository) sortedSubscriptions() []*models.WebhookSubscription {
+	subscriptions := make([]*models.WebhookSubscription, 0, len(r.subscriptions))
+	for _, subscription := range r.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].ID < subscriptions[j].ID
+	})
+	return subscriptions
+}
+
+// copyWebhookSubscription копирует подписку, чтобы вызывающий код не изменял хранилище
+func copyWebhookSubscription(subscription *models.WebhookSubscription) *models.WebhookSubscription {
+	copied := *subscription
+	copied.EventTypes = append([]string{}, subscription.EventTypes...)
+	copied.Secrets = append([]string{}, subscription.Secrets...)
+	return &copied
+}
@@ -0,0 +1,13 @@
+package repository_test
+
+import (
+	"auth-service/internal/repository"
+	"auth-service/internal/repository/repotest"
+	"testing"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repository {
+		return repository.NewMemoryRepository()
+	})
+}
@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationsFS миграции схемы, встроенные в исполняемый файл.
+// Миграции каждой СУБД лежат в своем каталоге и называются
+// <версия>_<название>.up.sql и <версия>_<название>.down.sql.
+// Версии миграций разных СУБД совпадают: одна версия описывает одно изменение схемы.
+//
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationsFS embed.FS
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration версия схемы базы данных
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus состояние миграции в базе данных
+type MigrationStatus struct {
+	Migration
+	// AppliedAt время применения; nil, если миграция не применена
+	AppliedAt *time.Time
+}
+
+// Migrator интерфейс хранилища с версионируемой схемой
+type Migrator interface {
+	// Migrate применяет все еще не примененные миграции
+	Migrate(ctx context.Context) error
+
+	// MigrateTo приводит схему к версии version; версия 0 откатывает все миграции
+	MigrateTo(ctx context.Context, version int) error
+
+	// MigrationStatus возвращает состояние всех встроенных миграций
+	MigrationStatus(ctx context.Context) ([]MigrationStatus, error)
+}
+
+// migrationDialect особенности СУБД, используемые при применении миграций
+type migrationDialect struct {
+	// dir каталог миграций СУБД в migrationsFS
+	dir string
+	// createTable создает таблицу schema_migrations, если ее нет
+	createTable string
+	// tableExists проверяет, создана ли таблица schema_migrations
+	tableExists string
+	// lock не дает одновременно запущенным экземплярам применять миграции параллельно.
+	// Блокировка берется на соединении conn, возвращаемая функция снимает ее.
+	lock func(ctx context.Context, conn *sql.Conn) (func(), error)
+}
+
+// loadMigrations читает встроенные миграции СУБД, упорядоченные по версии
+func loadMigrations(dialect migrationDialect) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationsFS, dialect.dir)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения миграций: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("некорректное имя файла миграции: %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := migrationsFS.ReadFile(dialect.dir + "/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения миграции %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("версия миграции %d используется дважды", version)
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("у миграции %d нет файла up или down", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// migrate применяет все еще не примененные миграции
+func migrate(ctx context.Context, db *sql.DB, dialect migrationDialect) error {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		return nil
+	}
+	return migrateTo(ctx, db, dialect, migrations[len(migrations)-1].Version)
+}
+
+// migrateTo приводит схему к версии version: применяет миграции до нее включительно
+// и откатывает примененные миграции с большей версией. Версия 0 откатывает все миграции.
+// Каждая миграция выполняется в отдельной транзакции.
+func migrateTo(ctx context.Context, db *sql.DB, dialect migrationDialect, version int) error {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return err
+	}
+	if version != 0 && !hasMigration(migrations, version) {
+		return fmt.Errorf("неизвестная версия схемы: %d", version)
+	}
+
+	return withMigrationLock(ctx, db, dialect, func(conn *sql.Conn) error {
+		applied, err := appliedMigrations(ctx, conn, dialect)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if migration.Version > version || applied[migration.Version] != nil {
+				continue
+			}
+			slog.InfoContext(ctx, "Применение миграции", slog.Int("version", migration.Version), slog.String("name", migration.Name))
+			err := runMigration(ctx, conn, migration.Up,
+				`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("ошибка применения миграции %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+
+		for i := len(migrations) - 1; i >= 0; i-- {
+			migration := migrations[i]
+			if migration.Version <= version || applied[migration.Version] == nil {
+				continue
+			}
+			slog.InfoContext(ctx, "Откат миграции", slog.Int("version", migration.Version), slog.String("name", migration.Name))
+			err := runMigration(ctx, conn, migration.Down,
+				`DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("ошибка отката миграции %d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// migrationStatus возвращает состояние всех встроенных миграций
+func migrationStatus(ctx context.Context, db *sql.DB, dialect migrationDialect) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := appliedMigrations(ctx, conn, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		statuses = append(statuses, MigrationStatus{
+			Migration: migration,
+			AppliedAt: applied[migration.Version],
+		})
+	}
+
+	return statuses, nil
+}
+
+// checkSchema проверяет, что применены все миграции, известные этой версии сервиса.
+// Миграции более новых версий сервиса не считаются ошибкой, чтобы во время
+// обновления предыдущие экземпляры оставались готовыми.
+func checkSchema(ctx context.Context, db *sql.DB, dialect migrationDialect) error {
+	statuses, err := migrationStatus(ctx, db, dialect)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки схемы: %w", err)
+	}
+
+	var pending []int
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Version)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("не применены миграции схемы: %v", pending)
+	}
+
+	return nil
+}
+
+// withMigrationLock выполняет fn на отдельном соединении под блокировкой миграций
+func withMigrationLock(ctx context.Context, db *sql.DB, dialect migrationDialect, fn func(conn *sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := dialect.lock(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("не удалось получить блокировку миграций: %w", err)
+	}
+	defer unlock()
+
+	if _, err := conn.ExecContext(ctx, dialect.createTable); err != nil {
+		return fmt.Errorf("не удалось создать таблицу schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// appliedMigrations возвращает время применения миграций по их версиям
+func appliedMigrations(ctx context.Context, conn *sql.Conn, dialect migrationDialect) (map[int]*time.Time, error) {
+	applied := make(map[int]*time.Time)
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, dialect.tableExists).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("ошибка чтения версии схемы: %w", err)
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения версии схемы: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения версии схемы: %w", err)
+		}
+		applied[version] = &appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// runMigration выполняет скрипт миграции и изменение schema_migrations в одной транзакции
+func runMigration(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// hasMigration проверяет, есть ли миграция с версией version
+func hasMigration(migrations []Migration, version int) bool {
+	for _, migration := range migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+-- Исходная схема; выражения IF NOT EXISTS позволяют применить миграцию
+-- к базе, созданной до появления миграций
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id SERIAL PRIMARY KEY,
+	user_id UUID NOT NULL,
+	refresh_token TEXT NOT NULL,
+	refresh_token_id TEXT NOT NULL,
+	user_agent TEXT NOT NULL,
+	client_ip TEXT NOT NULL,
+	is_blocked BOOLEAN NOT NULL DEFAULT FALSE,
+	expires_at BIGINT NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS webhook_outbox (
+	id BIGSERIAL PRIMARY KEY,
+	event_type TEXT NOT NULL,
+	payload JSONB NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	locked_until TIMESTAMP WITH TIME ZONE,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_outbox_pending_idx
+	ON webhook_outbox (next_attempt_at)
+	WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+	id BIGSERIAL PRIMARY KEY,
+	url TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	event_types TEXT[] NOT NULL,
+	secrets TEXT[] NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE webhook_outbox
+	ADD COLUMN IF NOT EXISTS subscription_id BIGINT REFERENCES webhook_subscriptions (id) ON DELETE CASCADE;
+
+ALTER TABLE webhook_outbox
+	ADD COLUMN IF NOT EXISTS event_id TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS event_log (
+	id BIGSERIAL PRIMARY KEY,
+	event_id TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	payload JSONB NOT NULL,
+	locked_until TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS event_log_unpublished_idx
+	ON event_log (id)
+	WHERE published_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS audit_events (
+	id BIGSERIAL PRIMARY KEY,
+	event_id TEXT NOT NULL UNIQUE,
+	event_type TEXT NOT NULL,
+	occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	actor_id TEXT NOT NULL DEFAULT '',
+	subject_id TEXT NOT NULL DEFAULT '',
+	session_id INTEGER,
+	client_ip TEXT NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	outcome TEXT NOT NULL,
+	reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_occurred_at_idx ON audit_events (occurred_at);
+CREATE INDEX IF NOT EXISTS audit_events_subject_idx ON audit_events (subject_id, occurred_at);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_id, occurred_at);
+
+-- Журнал аудита только дополняется: изменение и удаление записей запрещены
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_events допускает только добавление записей';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER audit_events_append_only
+	BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_events
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,3 @@
+-- Очистка сессий ищет истекшие и давно заблокированные сессии
+CREATE INDEX IF NOT EXISTS sessions_expires_at_idx ON sessions (expires_at);
+CREATE INDEX IF NOT EXISTS sessions_blocked_updated_at_idx ON sessions (updated_at) WHERE is_blocked;
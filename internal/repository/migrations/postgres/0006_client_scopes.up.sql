@@ -0,0 +1,2 @@
+-- Области доступа сервисных клиентов, например admin для административного API
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL DEFAULT '{}';
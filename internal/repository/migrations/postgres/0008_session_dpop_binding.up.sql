@@ -0,0 +1,2 @@
+-- Отпечаток открытого ключа DPoP, к которому привязаны токены сессии (RFC 9449)
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS jwk_thumbprint TEXT NOT NULL DEFAULT '';
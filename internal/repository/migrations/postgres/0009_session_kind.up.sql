@@ -0,0 +1,2 @@
+-- Вид сессии: вход пользователя или сервисный клиент, подтвердивший секрет
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'user';
@@ -0,0 +1,92 @@
+-- Исходная схема SQLite. Время хранится текстом в UTC (TIMESTAMP),
+-- списки в подписках на webhook — JSON-массивами
+
+CREATE TABLE sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id TEXT NOT NULL,
+	refresh_token TEXT NOT NULL,
+	refresh_token_id TEXT NOT NULL,
+	user_agent TEXT NOT NULL,
+	client_ip TEXT NOT NULL,
+	is_blocked BOOLEAN NOT NULL DEFAULT FALSE,
+	expires_at INTEGER NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhook_subscriptions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	event_types TEXT NOT NULL,
+	secrets TEXT NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhook_outbox (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	subscription_id INTEGER REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+	event_id TEXT NOT NULL DEFAULT '',
+	event_type TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP NOT NULL,
+	locked_until TIMESTAMP,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	delivered_at TIMESTAMP
+);
+
+CREATE INDEX webhook_outbox_pending_idx
+	ON webhook_outbox (next_attempt_at)
+	WHERE status = 'pending';
+
+CREATE INDEX webhook_outbox_subscription_idx ON webhook_outbox (subscription_id);
+
+CREATE TABLE event_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id TEXT NOT NULL,
+	event_type TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	locked_until TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	published_at TIMESTAMP
+);
+
+CREATE INDEX event_log_unpublished_idx
+	ON event_log (id)
+	WHERE published_at IS NULL;
+
+CREATE TABLE audit_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	event_id TEXT NOT NULL UNIQUE,
+	event_type TEXT NOT NULL,
+	occurred_at TIMESTAMP NOT NULL,
+	actor_id TEXT NOT NULL DEFAULT '',
+	subject_id TEXT NOT NULL DEFAULT '',
+	session_id INTEGER,
+	client_ip TEXT NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	outcome TEXT NOT NULL,
+	reason TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX audit_events_occurred_at_idx ON audit_events (occurred_at);
+CREATE INDEX audit_events_subject_idx ON audit_events (subject_id, occurred_at);
+CREATE INDEX audit_events_actor_idx ON audit_events (actor_id, occurred_at);
+
+-- Журнал аудита только дополняется: изменение и удаление записей запрещены
+CREATE TRIGGER audit_events_no_update
+	BEFORE UPDATE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit_events допускает только добавление записей');
+END;
+
+CREATE TRIGGER audit_events_no_delete
+	BEFORE DELETE ON audit_events
+BEGIN
+	SELECT RAISE(ABORT, 'audit_events допускает только добавление записей');
+END;
@@ -0,0 +1,3 @@
+-- Сессия ищется по хешу refresh токена при каждом обновлении, а блокируются все сессии пользователя
+CREATE UNIQUE INDEX IF NOT EXISTS sessions_refresh_token_idx ON sessions (refresh_token);
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id);
@@ -0,0 +1,10 @@
+-- Замененные refresh токены: повторное предъявление такого токена означает его утечку,
+-- кроме повторов в течение короткого окна после замены
+CREATE TABLE refresh_token_history (
+	refresh_token TEXT PRIMARY KEY,
+	session_id INTEGER NOT NULL REFERENCES sessions (id) ON DELETE CASCADE,
+	rotated_at TIMESTAMP NOT NULL,
+	successor BLOB
+);
+
+CREATE INDEX refresh_token_history_session_idx ON refresh_token_history (session_id);
@@ -0,0 +1,9 @@
+-- Сервисные клиенты, получающие токены по client_credentials.
+-- Хранится только хеш секрета: сам секрет показывается один раз при создании клиента.
+CREATE TABLE clients (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	secret_hash TEXT NOT NULL,
+	disabled BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL
+);
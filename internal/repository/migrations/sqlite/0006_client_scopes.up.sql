@@ -0,0 +1,2 @@
+-- Области доступа сервисных клиентов (JSON-массив), например admin для административного API
+ALTER TABLE clients ADD COLUMN scopes TEXT NOT NULL DEFAULT '[]';
@@ -0,0 +1,2 @@
+-- Отпечаток клиентского сертификата, к которому привязаны токены сессии (RFC 8705)
+ALTER TABLE sessions ADD COLUMN cert_thumbprint TEXT NOT NULL DEFAULT '';
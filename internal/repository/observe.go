@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"auth-service/internal/metrics"
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("auth-service/internal/repository")
+
+// observeOperation начинает span операции repository с СУБД system.
+// Возвращаемая функция завершает span и учитывает время выполнения операции в метриках.
+func observeOperation(ctx context.Context, system attribute.KeyValue, repository, operation string) (context.Context, func()) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, repository+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(system, semconv.DBOperation(operation)),
+	)
+	return ctx, func() {
+		metrics.ObserveRepository(operation, start)
+		span.End()
+	}
+}
+
+// withQueryTimeout ограничивает время операции timeout; 0 отключает ограничение.
+// Возвращаемая функция отменяет ctx и вызывает end.
+func withQueryTimeout(ctx context.Context, timeout time.Duration, end func()) (context.Context, func()) {
+	if timeout <= 0 {
+		return ctx, end
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		end()
+	}
+}
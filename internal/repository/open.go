@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"auth-service/internal/config"
+	"fmt"
+)
+
+// Open создает хранилище, выбранное в cfg.Driver: postgres, sqlite или memory
+func Open(cfg config.DatabaseConfig) (Repository, error) {
+	switch cfg.Driver {
+	case "", "postgres":
+		repo, err := NewPostgresRepository(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	case "sqlite":
+		repo, err := NewSQLiteRepository(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return repo, nil
+	case "memory":
+		return NewMemoryRepository(), nil
+	default:
+		return nil, fmt.Errorf("неизвестное хранилище: %s", cfg.Driver)
+	}
+}
@@ -1,23 +1,44 @@
 package repository
 
 import (
+	"auth-service/internal/config"
+	"auth-service/internal/metrics"
 	"auth-service/internal/models"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// Ошибки поиска сессии по refresh токену
+var (
+	// ErrSessionNotFound сессия с таким refresh токеном не найдена
+	ErrSessionNotFound = errors.New("сессия не найдена")
+	// ErrSessionExpired срок действия refresh токена истек
+	ErrSessionExpired = errors.New("сессия истекла")
+	// ErrSessionRevoked сессия заблокирована
+	ErrSessionRevoked = errors.New("сессия заблокирована")
+	// ErrRefreshTokenRotated refresh токен уже заменен другим запросом
+	ErrRefreshTokenRotated = errors.New("refresh токен уже заменен")
 )
 
 // PostgresRepository реализация Repository с использованием PostgreSQL
 type PostgresRepository struct {
 	db *sql.DB
+	// connString строка подключения для соединений LISTEN
+	connString string
+	// queryTimeout ограничивает время одной операции; 0 отключает ограничение
+	queryTimeout time.Duration
 }
 
 // NewPostgresRepository создает новый экземпляр PostgresRepository
-func NewPostgresRepository(connStr string) (*PostgresRepository, error) {
-	db, err := sql.Open("postgres", connStr)
+func NewPostgresRepository(cfg config.DatabaseConfig) (*PostgresRepository, error) {
+	db, err := sql.Open("postgres", cfg.GetConnectionString())
 	if err != nil {
 		return nil, fmt.Errorf("не удалось подключиться к базе данных: %w", err)
 	}
@@ -27,45 +48,27 @@ func NewPostgresRepository(connStr string) (*PostgresRepository, error) {
 		return nil, fmt.Errorf("не удалось проверить соединение с базой данных: %w", err)
 	}
 
-	// Создаем необходимые таблицы, если они не существуют
-	if err = createTables(db); err != nil {
-		return nil, fmt.Errorf("не удалось создать таблицы: %w", err)
-	}
-
-	return &PostgresRepository{db: db}, nil
-}
-
-// createTables создает необходимые таблицы в базе данных
-func createTables(db *sql.DB) error {
-	query := `
-	CREATE TABLE IF NOT EXISTS sessions (
-		id SERIAL PRIMARY KEY,
-		user_id UUID NOT NULL,
-		refresh_token TEXT NOT NULL,
-		refresh_token_id TEXT NOT NULL,
-		user_agent TEXT NOT NULL,
-		client_ip TEXT NOT NULL,
-		is_blocked BOOLEAN NOT NULL DEFAULT FALSE,
-		expires_at BIGINT NOT NULL,
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	_, err := db.Exec(query)
-	return err
+	return &PostgresRepository{db: db, connString: cfg.GetConnectionString(), queryTimeout: cfg.QueryTimeout}, nil
 }
 
 // CreateSession создает новую сессию пользователя
-func (r *PostgresRepository) CreateSession(userID uuid.UUID, refreshToken, refreshTokenID, userAgent, clientIP string, expiresAt int64) (int, error) {
+func (r *PostgresRepository) CreateSession(ctx context.Context, userID uuid.UUID, refreshToken, refreshTokenID, userAgent, clientIP, kind string, binding models.TokenBinding, expiresAt int64, events ...models.Event) (int, error) {
+	ctx, end := r.startOperation(ctx, "CreateSession")
+	defer end()
+
 	var sessionID int
 	query := `
-	INSERT INTO sessions (user_id, refresh_token, refresh_token_id, user_agent, client_ip, expires_at)
-	VALUES ($1, $2, $3, $4, $5, $6)
+	INSERT INTO sessions (user_id, refresh_token, refresh_token_id, user_agent, client_ip, expires_at, cert_thumbprint, jwk_thumbprint, kind)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	RETURNING id
 	`
 
-	err := r.db.QueryRow(query, userID, refreshToken, refreshTokenID, userAgent, clientIP, expiresAt).Scan(&sessionID)
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, query, userID, refreshToken, refreshTokenID, userAgent, clientIP, expiresAt, binding.CertThumbprint, binding.JWKThumbprint, kind).Scan(&sessionID); err != nil {
+			return err
+		}
+		return insertEvents(ctx, tx, sessionID, events)
+	})
 	if err != nil {
 		return 0, fmt.Errorf("не удалось создать сессию: %w", err)
 	}
@@ -74,15 +77,18 @@ func (r *PostgresRepository) CreateSession(userID uuid.UUID, refreshToken, refre
 }
 
 // GetSessionByRefreshToken возвращает сессию по хешу refresh токена
-func (r *PostgresRepository) GetSessionByRefreshToken(refreshTokenHash string) (*models.Session, error) {
+func (r *PostgresRepository) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.Session, error) {
+	ctx, end := r.startOperation(ctx, "GetSessionByRefreshToken")
+	defer end()
+
 	query := `
-	SELECT id, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at, refresh_token_id
+	SELECT id, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at, refresh_token_id, created_at, cert_thumbprint, jwk_thumbprint, kind
 	FROM sessions
-	WHERE refresh_token = $1 AND is_blocked = FALSE AND expires_at > $2
+	WHERE refresh_token = $1
 	`
 
-	row := r.db.QueryRow(query, refreshTokenHash, time.Now().Unix())
-	
+	row := r.db.QueryRowContext(ctx, query, refreshTokenHash)
+
 	session := &models.Session{}
 	err := row.Scan(
 		&session.ID,
@@ -93,43 +99,184 @@ func (r *PostgresRepository) GetSessionByRefreshToken(refreshTokenHash string) (
 		&session.IsBlocked,
 		&session.ExpiresAt,
 		&session.RefreshTokenID,
+		&session.CreatedAt,
+		&session.Binding.CertThumbprint,
+		&session.Binding.JWKThumbprint,
+		&session.Kind,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("сессия не найдена или истекла")
+			return nil, ErrSessionNotFound
 		}
 		return nil, fmt.Errorf("ошибка получения сессии: %w", err)
 	}
 
+	if session.IsBlocked {
+		return nil, ErrSessionRevoked
+	}
+	if session.ExpiresAt <= time.Now().Unix() {
+		return nil, ErrSessionExpired
+	}
+
 	return session, nil
 }
 
-// UpdateSession обновляет refresh токен в сессии
-func (r *PostgresRepository) UpdateSession(sessionID int, refreshToken, refreshTokenID string, expiresAt int64) error {
+// GetSession возвращает сессию по ID независимо от ее состояния
+func (r *PostgresRepository) GetSession(ctx context.Context, sessionID int) (*models.Session, error) {
+	ctx, end := r.startOperation(ctx, "GetSession")
+	defer end()
+
+	query := `
+	SELECT id, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at, refresh_token_id, created_at, cert_thumbprint, jwk_thumbprint, kind
+	FROM sessions
+	WHERE id = $1
+	`
+
+	session := &models.Session{}
+	err := r.db.QueryRowContext(ctx, query, sessionID).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.RefreshToken,
+		&session.UserAgent,
+		&session.ClientIP,
+		&session.IsBlocked,
+		&session.ExpiresAt,
+		&session.RefreshTokenID,
+		&session.CreatedAt,
+		&session.Binding.CertThumbprint,
+		&session.Binding.JWKThumbprint,
+		&session.Kind,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения сессии: %w", err)
+	}
+
+	return session, nil
+}
+
+// RotateRefreshToken заменяет refresh токен сессии, если он не был заменен конкурирующим запросом
+func (r *PostgresRepository) RotateRefreshToken(ctx context.Context, rotation models.RefreshTokenRotation, events ...models.Event) error {
+	ctx, end := r.startOperation(ctx, "RotateRefreshToken")
+	defer end()
+
+	// Условие на текущий токен делает замену атомарной: из конкурирующих запросов
+	// с одним токеном строку изменит только первый
 	query := `
 	UPDATE sessions
 	SET refresh_token = $1, refresh_token_id = $2, expires_at = $3, updated_at = CURRENT_TIMESTAMP
-	WHERE id = $4
+	WHERE id = $4 AND refresh_token = $5 AND is_blocked = FALSE AND expires_at > $6
 	`
 
-	_, err := r.db.Exec(query, refreshToken, refreshTokenID, expiresAt, sessionID)
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query,
+			rotation.NewRefreshToken,
+			rotation.NewRefreshTokenID,
+			rotation.ExpiresAt,
+			rotation.SessionID,
+			rotation.OldRefreshToken,
+			time.Now().Unix(),
+		)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return rotationConflict(ctx, tx, rotation)
+		}
+
+		// Пары, выданные при предыдущих заменах, больше не возвращаются повторно
+		if _, err := tx.ExecContext(ctx, `UPDATE refresh_token_history SET successor = NULL WHERE session_id = $1 AND successor IS NOT NULL`, rotation.SessionID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO refresh_token_history (refresh_token, session_id, successor) VALUES ($1, $2, $3)`, rotation.OldRefreshToken, rotation.SessionID, rotation.Successor); err != nil {
+			return err
+		}
+
+		return insertEvents(ctx, tx, rotation.SessionID, events)
+	})
 	if err != nil {
+		if errors.Is(err, ErrRefreshTokenRotated) || errors.Is(err, ErrSessionRevoked) || errors.Is(err, ErrSessionExpired) || errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
 		return fmt.Errorf("не удалось обновить сессию: %w", err)
 	}
 
 	return nil
 }
 
+// rotationConflict определяет, почему сессия не была изменена при замене refresh токена
+func rotationConflict(ctx context.Context, tx *sql.Tx, rotation models.RefreshTokenRotation) error {
+	var refreshToken string
+	var isBlocked bool
+	var expiresAt int64
+	err := tx.QueryRowContext(ctx, `SELECT refresh_token, is_blocked, expires_at FROM sessions WHERE id = $1`, rotation.SessionID).
+		Scan(&refreshToken, &isBlocked, &expiresAt)
+	switch {
+	case err == sql.ErrNoRows:
+		return ErrSessionNotFound
+	case err != nil:
+		return err
+	case isBlocked:
+		return ErrSessionRevoked
+	case refreshToken != rotation.OldRefreshToken:
+		return ErrRefreshTokenRotated
+	default:
+		return ErrSessionExpired
+	}
+}
+
+// GetRefreshTokenHistory возвращает запись о замененном refresh токене по его хешу
+func (r *PostgresRepository) GetRefreshTokenHistory(ctx context.Context, refreshTokenHash string) (*models.RefreshTokenHistory, error) {
+	ctx, end := r.startOperation(ctx, "GetRefreshTokenHistory")
+	defer end()
+
+	query := `
+	SELECT refresh_token, session_id, rotated_at, successor
+	FROM refresh_token_history
+	WHERE refresh_token = $1
+	`
+
+	history := &models.RefreshTokenHistory{}
+	err := r.db.QueryRowContext(ctx, query, refreshTokenHash).Scan(
+		&history.RefreshToken,
+		&history.SessionID,
+		&history.RotatedAt,
+		&history.Successor,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения истории refresh токена: %w", err)
+	}
+
+	return history, nil
+}
+
 // BlockSession блокирует сессию
-func (r *PostgresRepository) BlockSession(sessionID int) error {
+func (r *PostgresRepository) BlockSession(ctx context.Context, sessionID int, events ...models.Event) error {
+	ctx, end := r.startOperation(ctx, "BlockSession")
+	defer end()
+
 	query := `
 	UPDATE sessions
 	SET is_blocked = TRUE, updated_at = CURRENT_TIMESTAMP
 	WHERE id = $1
 	`
 
-	_, err := r.db.Exec(query, sessionID)
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, sessionID); err != nil {
+			return err
+		}
+		return insertEvents(ctx, tx, sessionID, events)
+	})
 	if err != nil {
 		return fmt.Errorf("не удалось заблокировать сессию: %w", err)
 	}
@@ -138,14 +285,22 @@ func (r *PostgresRepository) BlockSession(sessionID int) error {
 }
 
 // BlockAllUserSessions блокирует все сессии пользователя
-func (r *PostgresRepository) BlockAllUserSessions(userID uuid.UUID) error {
+func (r *PostgresRepository) BlockAllUserSessions(ctx context.Context, userID uuid.UUID, events ...models.Event) error {
+	ctx, end := r.startOperation(ctx, "BlockAllUserSessions")
+	defer end()
+
 	query := `
 	UPDATE sessions
 	SET is_blocked = TRUE, updated_at = CURRENT_TIMESTAMP
 	WHERE user_id = $1
 	`
 
-	_, err := r.db.Exec(query, userID)
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return err
+		}
+		return insertEvents(ctx, tx, 0, events)
+	})
 	if err != nil {
 		return fmt.Errorf("не удалось заблокировать все сессии пользователя: %w", err)
 	}
@@ -153,6 +308,63 @@ func (r *PostgresRepository) BlockAllUserSessions(userID uuid.UUID) error {
 	return nil
 }
 
+// startOperation начинает операцию репозитория и ограничивает ее время queryTimeout.
+// Операция также прерывается при отмене ctx, например при разрыве соединения клиентом.
+// Возвращаемая функция должна быть вызвана по завершении операции.
+func (r *PostgresRepository) startOperation(ctx context.Context, operation string) (context.Context, func()) {
+	ctx, end := r.observeOperation(ctx, operation)
+	return withQueryTimeout(ctx, r.queryTimeout, end)
+}
+
+// observeOperation начинает span операции без ограничения ее времени
+func (r *PostgresRepository) observeOperation(ctx context.Context, operation string) (context.Context, func()) {
+	return observeOperation(ctx, semconv.DBSystemPostgreSQL, "PostgresRepository", operation)
+}
+
+// withTx выполняет fn в транзакции и фиксирует ее, если fn не вернула ошибку
+func (r *PostgresRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountActiveSessions возвращает количество незаблокированных сессий с действующим refresh токеном
+func (r *PostgresRepository) CountActiveSessions(ctx context.Context) (int, error) {
+	ctx, end := r.startOperation(ctx, "CountActiveSessions")
+	defer end()
+
+	var count int
+	query := `SELECT COUNT(*) FROM sessions WHERE is_blocked = FALSE AND expires_at > $1`
+	if err := r.db.QueryRowContext(ctx, query, time.Now().Unix()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка подсчета активных сессий: %w", err)
+	}
+
+	return count, nil
+}
+
+// Ping проверяет соединение с базой данных
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	ctx, end := r.startOperation(ctx, "Ping")
+	defer end()
+
+	return r.db.PingContext(ctx)
+}
+
+// RegisterMetrics подключает метрики пула соединений и активных сессий
+func (r *PostgresRepository) RegisterMetrics() error {
+	return metrics.RegisterDB(r.db, func() (int, error) {
+		return r.CountActiveSessions(context.Background())
+	})
+}
+
 // Close закрывает соединение с базой данных
 func (r *PostgresRepository) Close() error {
 	return r.db.Close()
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+const auditEventColumns = `id, event_id, event_type, occurred_at, actor_id, subject_id, session_id, client_ip, user_agent, outcome, reason`
+
+// newAuditEvent формирует запись аудита из события
+func newAuditEvent(event models.Event, sessionID int) *models.AuditEvent {
+	var data models.SessionEventData
+	// Данные событий другого формата не мешают записи аудита
+	_ = json.Unmarshal(event.Data, &data)
+
+	audit := &models.AuditEvent{
+		EventID:    event.ID,
+		EventType:  event.Type,
+		OccurredAt: event.Time,
+		ActorID:    data.ActorID,
+		SubjectID:  event.Subject,
+		ClientIP:   data.ClientIP,
+		UserAgent:  data.UserAgent,
+		Outcome:    data.Outcome,
+		Reason:     data.Reason,
+	}
+	if audit.ClientIP == "" {
+		audit.ClientIP = data.NewIP
+	}
+	if audit.Outcome == "" {
+		audit.Outcome = models.AuditOutcomeSuccess
+	}
+	if sessionID == 0 {
+		sessionID = data.SessionID
+	}
+	if sessionID != 0 {
+		audit.SessionID = &sessionID
+	}
+
+	return audit
+}
+
+// insertAuditEvent добавляет запись в журнал аудита в рамках транзакции.
+// Повторная запись события с тем же ID игнорируется.
+func insertAuditEvent(ctx context.Context, tx *sql.Tx, audit *models.AuditEvent) error {
+	query := `
+	INSERT INTO audit_events (event_id, event_type, occurred_at, actor_id, subject_id, session_id, client_ip, user_agent, outcome, reason)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	ON CONFLICT (event_id) DO NOTHING
+	`
+
+	var sessionID sql.NullInt64
+	if audit.SessionID != nil {
+		sessionID = sql.NullInt64{Int64: int64(*audit.SessionID), Valid: true}
+	}
+
+	_, err := tx.ExecContext(ctx, query,
+		audit.EventID,
+		audit.EventType,
+		audit.OccurredAt,
+		audit.ActorID,
+		audit.SubjectID,
+		sessionID,
+		audit.ClientIP,
+		audit.UserAgent,
+		audit.Outcome,
+		audit.Reason,
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось записать событие в журнал аудита: %w", err)
+	}
+
+	return nil
+}
+
+// ListAuditEvents возвращает записи аудита по фильтру, начиная с самых новых
+func (r *PostgresRepository) ListAuditEvents(ctx context.Context, filter models.AuditEventFilter) ([]*models.AuditEvent, error) {
+	ctx, end := r.startOperation(ctx, "ListAuditEvents")
+	defer end()
+
+	rows, err := r.queryAuditEvents(ctx, filter, "DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.AuditEvent
+	err = scanAuditEvents(rows, func(event *models.AuditEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ExportAuditEvents передает в fn записи аудита по фильтру в порядке их добавления
+func (r *PostgresRepository) ExportAuditEvents(ctx context.Context, filter models.AuditEventFilter, fn func(event *models.AuditEvent) error) error {
+	// Выгрузка может длиться дольше queryTimeout, поэтому ее ограничивает только ctx запроса
+	ctx, end := r.observeOperation(ctx, "ExportAuditEvents")
+	defer end()
+
+	rows, err := r.queryAuditEvents(ctx, filter, "ASC")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return scanAuditEvents(rows, fn)
+}
+
+// queryAuditEvents выполняет поиск по журналу аудита с указанным порядком сортировки по ID
+func (r *PostgresRepository) queryAuditEvents(ctx context.Context, filter models.AuditEventFilter, order string) (*sql.Rows, error) {
+	query := `
+	SELECT ` + auditEventColumns + `
+	FROM audit_events
+	WHERE ($1 = '' OR actor_id = $1 OR subject_id = $1)
+		AND ($2 = '' OR event_type = $2)
+		AND ($3::timestamptz IS NULL OR occurred_at >= $3)
+		AND ($4::timestamptz IS NULL OR occurred_at < $4)
+	ORDER BY id ` + order + `
+	LIMIT NULLIF($5, 0) OFFSET $6
+	`
+
+	var from, to sql.NullTime
+	if !filter.From.IsZero() {
+		from = sql.NullTime{Time: filter.From, Valid: true}
+	}
+	if !filter.To.IsZero() {
+		to = sql.NullTime{Time: filter.To, Valid: true}
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, filter.UserID, filter.EventType, from, to, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска по журналу аудита: %w", err)
+	}
+
+	return rows, nil
+}
+
+// scanAuditEvents читает записи аудита из результата запроса и передает их в fn
+func scanAuditEvents(rows *sql.Rows, fn func(event *models.AuditEvent) error) error {
+	for rows.Next() {
+		event := &models.AuditEvent{}
+		var sessionID sql.NullInt64
+		err := rows.Scan(
+			&event.ID,
+			&event.EventID,
+			&event.EventType,
+			&event.OccurredAt,
+			&event.ActorID,
+			&event.SubjectID,
+			&sessionID,
+			&event.ClientIP,
+			&event.UserAgent,
+			&event.Outcome,
+			&event.Reason,
+		)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения записи аудита: %w", err)
+		}
+		if sessionID.Valid {
+			id := int(sessionID.Int64)
+			event.SessionID = &id
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения журнала аудита: %w", err)
+	}
+
+	return nil
+}
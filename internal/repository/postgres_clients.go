@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrClientNotFound возвращается, если сервисный клиент не найден
+var ErrClientNotFound = errors.New("клиент не найден")
+
+const clientColumns = `id, name, secret_hash, scopes, disabled, created_at`
+
+// CreateClient создает сервисного клиента
+func (r *PostgresRepository) CreateClient(ctx context.Context, client *models.Client) error {
+	ctx, end := r.startOperation(ctx, "CreateClient")
+	defer end()
+
+	query := `
+	INSERT INTO clients (id, name, secret_hash, scopes, disabled)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		client.ID,
+		client.Name,
+		client.SecretHash,
+		pq.Array(nonNilStrings(client.Scopes)),
+		client.Disabled,
+	).Scan(&client.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("не удалось создать клиента: %w", err)
+	}
+
+	return nil
+}
+
+// GetClient возвращает сервисного клиента по ID
+func (r *PostgresRepository) GetClient(ctx context.Context, id uuid.UUID) (*models.Client, error) {
+	ctx, end := r.startOperation(ctx, "GetClient")
+	defer end()
+
+	query := `SELECT ` + clientColumns + ` FROM clients WHERE id = $1`
+
+	client, err := scanClient(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения клиента: %w", err)
+	}
+
+	return client, nil
+}
+
+// ListClients возвращает всех сервисных клиентов в порядке создания
+func (r *PostgresRepository) ListClients(ctx context.Context) ([]*models.Client, error) {
+	ctx, end := r.startOperation(ctx, "ListClients")
+	defer end()
+
+	query := `SELECT ` + clientColumns + ` FROM clients ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения клиентов: %w", err)
+	}
+	defer rows.Close()
+
+	return scanClients(rows)
+}
+
+// UpdateClient сохраняет название, секрет, области доступа и состояние клиента
+func (r *PostgresRepository) UpdateClient(ctx context.Context, client *models.Client) error {
+	ctx, end := r.startOperation(ctx, "UpdateClient")
+	defer end()
+
+	query := `
+	UPDATE clients
+	SET name = $1, secret_hash = $2, scopes = $3, disabled = $4
+	WHERE id = $5
+	RETURNING created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		client.Name,
+		client.SecretHash,
+		pq.Array(nonNilStrings(client.Scopes)),
+		client.Disabled,
+		client.ID,
+	).Scan(&client.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrClientNotFound
+		}
+		return fmt.Errorf("не удалось обновить клиента: %w", err)
+	}
+
+	return nil
+}
+
+// scanClient читает сервисного клиента из строки результата
+func scanClient(row rowScanner) (*models.Client, error) {
+	client := &models.Client{}
+	err := row.Scan(
+		&client.ID,
+		&client.Name,
+		&client.SecretHash,
+		pq.Array(&client.Scopes),
+		&client.Disabled,
+		&client.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// scanClients читает сервисных клиентов из результата запроса
+func scanClients(rows *sql.Rows) ([]*models.Client, error) {
+	var clients []*models.Client
+	for rows.Next() {
+		client, err := scanClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения клиента: %w", err)
+		}
+		clients = append(clients, client)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения клиентов: %w", err)
+	}
+
+	return clients, nil
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// listenerPingInterval интервал проверки соединения LISTEN при отсутствии уведомлений
+const listenerPingInterval = 90 * time.Second
+
+// PostgresRepository доставляет уведомления между экземплярами сервиса
+var _ Broadcaster = (*PostgresRepository)(nil)
+
+// insertEvents записывает события в журнал событий и журнал аудита в рамках транзакции.
+// sessionID — сессия, к которой относятся события, или 0, если она берется из данных события.
+func insertEvents(ctx context.Context, tx *sql.Tx, sessionID int, events []models.Event) error {
+	query := `
+	INSERT INTO event_log (event_id, event_type, payload)
+	VALUES ($1, $2, $3)
+	`
+
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации события: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, event.ID, event.Type, payload); err != nil {
+			return fmt.Errorf("не удалось записать событие в журнал: %w", err)
+		}
+		if err := insertAuditEvent(ctx, tx, newAuditEvent(event, sessionID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AppendEvents записывает в журнал события, не связанные с изменением сессии
+func (r *PostgresRepository) AppendEvents(ctx context.Context, events ...models.Event) error {
+	ctx, end := r.startOperation(ctx, "AppendEvents")
+	defer end()
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		return insertEvents(ctx, tx, 0, events)
+	})
+}
+
+// ClaimEvents захватывает неопубликованные события
+func (r *PostgresRepository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]*models.EventRecord, error) {
+	ctx, end := r.startOperation(ctx, "ClaimEvents")
+	defer end()
+
+	query := `
+	UPDATE event_log
+	SET locked_until = CURRENT_TIMESTAMP + $2 * INTERVAL '1 millisecond'
+	WHERE id IN (
+		SELECT id FROM event_log
+		WHERE published_at IS NULL
+			AND (locked_until IS NULL OR locked_until <= CURRENT_TIMESTAMP)
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, payload
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("не удалось захватить события: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*models.EventRecord
+	for rows.Next() {
+		record := &models.EventRecord{}
+		var payload []byte
+		if err := rows.Scan(&record.ID, &payload); err != nil {
+			return nil, fmt.Errorf("ошибка чтения события: %w", err)
+		}
+		if err := json.Unmarshal(payload, &record.Event); err != nil {
+			return nil, fmt.Errorf("ошибка разбора события %d: %w", record.ID, err)
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения событий: %w", err)
+	}
+
+	// RETURNING не гарантирует порядок строк
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	return records, nil
+}
+
+// MarkEventsPublished отмечает события как опубликованные
+func (r *PostgresRepository) MarkEventsPublished(ctx context.Context, ids ...int64) error {
+	ctx, end := r.startOperation(ctx, "MarkEventsPublished")
+	defer end()
+
+	query := `
+	UPDATE event_log
+	SET published_at = CURRENT_TIMESTAMP, locked_until = NULL
+	WHERE id = ANY($1)
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+		return fmt.Errorf("не удалось отметить публикацию событий: %w", err)
+	}
+
+	return nil
+}
+
+// Notify отправляет уведомление в канал Postgres LISTEN/NOTIFY
+func (r *PostgresRepository) Notify(ctx context.Context, channel, payload string) error {
+	ctx, end := r.startOperation(ctx, "Notify")
+	defer end()
+
+	if _, err := r.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, payload); err != nil {
+		return fmt.Errorf("не удалось отправить уведомление в канал %s: %w", channel, err)
+	}
+	return nil
+}
+
+// Subscribe получает уведомления канала Postgres LISTEN/NOTIFY до отмены ctx.
+// Соединение LISTEN устанавливается отдельно от пула и восстанавливается после разрыва.
+func (r *PostgresRepository) Subscribe(ctx context.Context, channel string, handle func(payload string), resync func()) error {
+	listener := pq.NewListener(r.connString, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+			slog.Warn("Потеряно соединение LISTEN", slog.String("channel", channel), slog.Any("error", err))
+		case pq.ListenerEventReconnected:
+			slog.Info("Соединение LISTEN восстановлено", slog.String("channel", channel))
+		}
+	})
+	// Listen ожидает подключения к базе данных, поэтому прерывается закрытием listener
+	stop := context.AfterFunc(ctx, func() { _ = listener.Close() })
+	defer func() {
+		if stop() {
+			_ = listener.Close()
+		}
+	}()
+
+	if err := listener.Listen(channel); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("не удалось подписаться на канал %s: %w", channel, err)
+	}
+	resync()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification, ok := <-listener.Notify:
+			if !ok {
+				return nil
+			}
+			// nil приходит после переподключения: уведомления за время разрыва потеряны
+			if notification == nil {
+				resync()
+				continue
+			}
+			handle(notification.Extra)
+		case <-time.After(listenerPingInterval):
+			// Проверка обнаруживает разрыв соединения, о котором не сообщила сеть
+			go func() { _ = listener.Ping() }()
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// migrationLockID ключ advisory-блокировки, под которой выполняются миграции,
+// чтобы одновременно запущенные экземпляры не применяли их параллельно
+const migrationLockID int64 = 7_240_513_266_170_101
+
+// postgresMigrations миграции схемы PostgreSQL
+var postgresMigrations = migrationDialect{
+	dir: "migrations/postgres",
+	createTable: `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)
+	`,
+	tableExists: `SELECT to_regclass('schema_migrations') IS NOT NULL`,
+	lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+		// Блокировка уровня сессии принадлежит соединению, поэтому все запросы выполняются через conn
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+			return nil, err
+		}
+		return func() {
+			_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+		}, nil
+	},
+}
+
+// PostgresRepository поддерживает версионируемую схему
+var _ Migrator = (*PostgresRepository)(nil)
+
+// Migrate применяет все еще не примененные миграции
+func (r *PostgresRepository) Migrate(ctx context.Context) error {
+	return migrate(ctx, r.db, postgresMigrations)
+}
+
+// MigrateTo приводит схему к версии version: применяет миграции до нее включительно
+// и откатывает примененные миграции с большей версией. Версия 0 откатывает все миграции.
+func (r *PostgresRepository) MigrateTo(ctx context.Context, version int) error {
+	return migrateTo(ctx, r.db, postgresMigrations, version)
+}
+
+// MigrationStatus возвращает состояние всех встроенных миграций
+func (r *PostgresRepository) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	return migrationStatus(ctx, r.db, postgresMigrations)
+}
+
+// CheckSchema проверяет, что применены все миграции, известные этой версии сервиса
+func (r *PostgresRepository) CheckSchema(ctx context.Context) error {
+	ctx, end := r.startOperation(ctx, "CheckSchema")
+	defer end()
+
+	return checkSchema(ctx, r.db, postgresMigrations)
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWebhookMessageNotFound возвращается, если сообщение webhook не найдено
+var ErrWebhookMessageNotFound = errors.New("сообщение webhook не найдено")
+
+const webhookMessageColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at`
+
+// EnqueueWebhookEvents сохраняет события в outbox:
+// для каждой включенной подписки на тип события создается отдельное сообщение
+func (r *PostgresRepository) EnqueueWebhookEvents(ctx context.Context, events ...models.Event) error {
+	ctx, end := r.startOperation(ctx, "EnqueueWebhookEvents")
+	defer end()
+
+	query := `
+	INSERT INTO webhook_outbox (subscription_id, event_id, event_type, payload)
+	SELECT id, $1, $2, $3
+	FROM webhook_subscriptions
+	WHERE enabled AND ($2 = ANY(event_types) OR '*' = ANY(event_types))
+	`
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		for _, event := range events {
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("ошибка сериализации события: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx, query, event.ID, event.Type, payload); err != nil {
+				return fmt.Errorf("не удалось сохранить событие webhook: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ClaimWebhookMessages захватывает готовые к отправке сообщения
+func (r *PostgresRepository) ClaimWebhookMessages(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookMessage, error) {
+	ctx, end := r.startOperation(ctx, "ClaimWebhookMessages")
+	defer end()
+
+	query := `
+	UPDATE webhook_outbox
+	SET attempts = attempts + 1, locked_until = CURRENT_TIMESTAMP + $2 * INTERVAL '1 millisecond'
+	WHERE id IN (
+		SELECT id FROM webhook_outbox
+		WHERE status = 'pending'
+			AND next_attempt_at <= CURRENT_TIMESTAMP
+			AND (locked_until IS NULL OR locked_until <= CURRENT_TIMESTAMP)
+		ORDER BY next_attempt_at, id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + webhookMessageColumns
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("не удалось захватить сообщения webhook: %w", err)
+	}
+	defer rows.Close()
+
+	return scanWebhookMessages(rows)
+}
+
+// MarkWebhookDelivered отмечает сообщение как доставленное
+func (r *PostgresRepository) MarkWebhookDelivered(ctx context.Context, id int64) error {
+	ctx, end := r.startOperation(ctx, "MarkWebhookDelivered")
+	defer end()
+
+	query := `
+	UPDATE webhook_outbox
+	SET status = 'delivered', delivered_at = CURRENT_TIMESTAMP, locked_until = NULL, last_error = ''
+	WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("не удалось отметить доставку webhook: %w", err)
+	}
+
+	return nil
+}
+
+// MarkWebhookFailed сохраняет ошибку доставки и планирует повторную попытку
+func (r *PostgresRepository) MarkWebhookFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error {
+	ctx, end := r.startOperation(ctx, "MarkWebhookFailed")
+	defer end()
+
+	status := models.WebhookStatusPending
+	if dead {
+		status = models.WebhookStatusDead
+	}
+
+	query := `
+	UPDATE webhook_outbox
+	SET status = $1, last_error = $2, next_attempt_at = $3, locked_until = NULL
+	WHERE id = $4
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, status, lastError, nextAttemptAt, id); err != nil {
+		return fmt.Errorf("не удалось сохранить ошибку доставки webhook: %w", err)
+	}
+
+	return nil
+}
+
+// RetryWebhookMessage возвращает сообщение в очередь для немедленной отправки
+func (r *PostgresRepository) RetryWebhookMessage(ctx context.Context, id int64) error {
+	ctx, end := r.startOperation(ctx, "RetryWebhookMessage")
+	defer end()
+
+	query := `
+	UPDATE webhook_outbox
+	SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, locked_until = NULL
+	WHERE id = $1 AND status <> 'delivered'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("не удалось повторно поставить webhook в очередь: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось повторно поставить webhook в очередь: %w", err)
+	}
+	if affected == 0 {
+		return ErrWebhookMessageNotFound
+	}
+
+	return nil
+}
+
+// GetWebhookMessage возвращает сообщение по ID
+func (r *PostgresRepository) GetWebhookMessage(ctx context.Context, id int64) (*models.WebhookMessage, error) {
+	ctx, end := r.startOperation(ctx, "GetWebhookMessage")
+	defer end()
+
+	query := `SELECT ` + webhookMessageColumns + ` FROM webhook_outbox WHERE id = $1`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сообщения webhook: %w", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanWebhookMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, ErrWebhookMessageNotFound
+	}
+
+	return messages[0], nil
+}
+
+// ListWebhookMessages возвращает сообщения, удовлетворяющие фильтру
+func (r *PostgresRepository) ListWebhookMessages(ctx context.Context, filter models.WebhookMessageFilter) ([]*models.WebhookMessage, error) {
+	ctx, end := r.startOperation(ctx, "ListWebhookMessages")
+	defer end()
+
+	query := `
+	SELECT ` + webhookMessageColumns + `
+	FROM webhook_outbox
+	WHERE ($1 = '' OR status = $1) AND ($2 = 0 OR subscription_id = $2)
+	ORDER BY id DESC
+	LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.Status, filter.SubscriptionID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сообщений webhook: %w", err)
+	}
+	defer rows.Close()
+
+	return scanWebhookMessages(rows)
+}
+
+// CountWebhookBacklog возвращает количество сообщений, которые должны были быть отправлены
+// раньше, чем olderThan назад, но все еще ожидают доставки
+func (r *PostgresRepository) CountWebhookBacklog(ctx context.Context, olderThan time.Duration) (int, error) {
+	ctx, end := r.startOperation(ctx, "CountWebhookBacklog")
+	defer end()
+
+	query := `
+	SELECT COUNT(*) FROM webhook_outbox
+	WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP - $1 * INTERVAL '1 millisecond'
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, olderThan.Milliseconds()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка подсчета очереди webhook: %w", err)
+	}
+
+	return count, nil
+}
+
+// scanWebhookMessages читает сообщения webhook из результата запроса
+func scanWebhookMessages(rows *sql.Rows) ([]*models.WebhookMessage, error) {
+	var messages []*models.WebhookMessage
+	for rows.Next() {
+		message := &models.WebhookMessage{}
+		var subscriptionID sql.NullInt64
+		var deliveredAt sql.NullTime
+		err := rows.Scan(
+			&message.ID,
+			&subscriptionID,
+			&message.EventID,
+			&message.EventType,
+			&message.Payload,
+			&message.Status,
+			&message.Attempts,
+			&message.NextAttemptAt,
+			&message.LastError,
+			&message.CreatedAt,
+			&deliveredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения сообщения webhook: %w", err)
+		}
+		if subscriptionID.Valid {
+			message.SubscriptionID = &subscriptionID.Int64
+		}
+		if deliveredAt.Valid {
+			message.DeliveredAt = &deliveredAt.Time
+		}
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения сообщений webhook: %w", err)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostgresRepository позволяет выполнять фоновые задачи на одном экземпляре сервиса
+var _ Locker = (*PostgresRepository)(nil)
+
+// BlockSessionsCreatedBefore блокирует все незаблокированные сессии, созданные раньше before
+func (r *PostgresRepository) BlockSessionsCreatedBefore(ctx context.Context, before time.Time, events ...models.Event) (int, error) {
+	ctx, end := r.startOperation(ctx, "BlockSessionsCreatedBefore")
+	defer end()
+
+	query := `
+	UPDATE sessions
+	SET is_blocked = TRUE, updated_at = CURRENT_TIMESTAMP
+	WHERE NOT is_blocked AND created_at < $1
+	`
+
+	var blocked int64
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, before)
+		if err != nil {
+			return err
+		}
+		if blocked, err = result.RowsAffected(); err != nil {
+			return err
+		}
+		return insertEvents(ctx, tx, 0, events)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("не удалось заблокировать сессии: %w", err)
+	}
+
+	return int(blocked), nil
+}
+
+// ListSessions возвращает сессии по фильтру, начиная с самых новых
+func (r *PostgresRepository) ListSessions(ctx context.Context, filter models.SessionFilter) ([]*models.Session, error) {
+	ctx, end := r.startOperation(ctx, "ListSessions")
+	defer end()
+
+	query := `
+	SELECT id, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at, refresh_token_id, created_at, cert_thumbprint, jwk_thumbprint, kind
+	FROM sessions
+	WHERE ($1::uuid IS NULL OR user_id = $1)
+		AND (NOT $2 OR (NOT is_blocked AND expires_at > $3))
+	ORDER BY id DESC
+	LIMIT NULLIF($4, 0) OFFSET $5
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, sessionUserFilter(filter.UserID), filter.ActiveOnly, time.Now().Unix(), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сессий: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
+
+// ListBlockedSessions возвращает сессии, заблокированные не раньше since
+func (r *PostgresRepository) ListBlockedSessions(ctx context.Context, since time.Time) ([]models.BlockedSession, error) {
+	ctx, end := r.startOperation(ctx, "ListBlockedSessions")
+	defer end()
+
+	query := `SELECT id, user_id, updated_at FROM sessions WHERE is_blocked AND updated_at >= $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения заблокированных сессий: %w", err)
+	}
+	defer rows.Close()
+
+	return scanBlockedSessions(rows)
+}
+
+// DeleteStaleSessions удаляет истекшие и заблокированные раньше before сессии.
+// История refresh токенов удаляется каскадно.
+func (r *PostgresRepository) DeleteStaleSessions(ctx context.Context, before time.Time, limit int) (int, error) {
+	ctx, end := r.startOperation(ctx, "DeleteStaleSessions")
+	defer end()
+
+	query := `
+	DELETE FROM sessions
+	WHERE id IN (
+		SELECT id FROM sessions
+		WHERE expires_at < $1 OR (is_blocked AND updated_at < $2)
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, before.Unix(), before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось удалить устаревшие сессии: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("не удалось удалить устаревшие сессии: %w", err)
+	}
+
+	return int(deleted), nil
+}
+
+// TryLock захватывает advisory-блокировку name, не дожидаясь ее освобождения.
+// Блокировка уровня сессии принадлежит соединению, поэтому оно удерживается до вызова unlock.
+func (r *PostgresRepository) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	ctx, end := r.startOperation(ctx, "TryLock")
+	defer end()
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("не удалось захватить блокировку %s: %w", name, err)
+	}
+
+	lockID := advisoryLockID(name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, lockID).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("не удалось захватить блокировку %s: %w", name, err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+		conn.Close()
+	}, true, nil
+}
+
+// sessionUserFilter параметр запроса для фильтра сессий по пользователю; NULL снимает фильтр
+func sessionUserFilter(userID uuid.UUID) sql.NullString {
+	if userID == uuid.Nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: userID.String(), Valid: true}
+}
+
+// advisoryLockID ключ advisory-блокировки для имени name
+func advisoryLockID(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("auth-service:" + name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// ErrWebhookSubscriptionNotFound возвращается, если подписка на webhook не найдена
+var ErrWebhookSubscriptionNotFound = errors.New("подписка на webhook не найдена")
+
+const webhookSubscriptionColumns = `id, url, description, event_types, secrets, enabled, created_at, updated_at`
+
+// CreateWebhookSubscription создает подписку на webhook
+func (r *PostgresRepository) CreateWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	ctx, end := r.startOperation(ctx, "CreateWebhookSubscription")
+	defer end()
+
+	query := `
+	INSERT INTO webhook_subscriptions (url, description, event_types, secrets, enabled)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		subscription.URL,
+		subscription.Description,
+		pq.Array(nonNilStrings(subscription.EventTypes)),
+		pq.Array(nonNilStrings(subscription.Secrets)),
+		subscription.Enabled,
+	).Scan(&subscription.ID, &subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("не удалось создать подписку на webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetWebhookSubscription возвращает подписку по ID
+func (r *PostgresRepository) GetWebhookSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	ctx, end := r.startOperation(ctx, "GetWebhookSubscription")
+	defer end()
+
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	subscription, err := scanWebhookSubscription(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookSubscriptionNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения подписки на webhook: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// ListWebhookSubscriptions возвращает все подписки
+func (r *PostgresRepository) ListWebhookSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	ctx, end := r.startOperation(ctx, "ListWebhookSubscriptions")
+	defer end()
+
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения подписок на webhook: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.WebhookSubscription
+	for rows.Next() {
+		subscription, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения подписки на webhook: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения подписок на webhook: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// UpdateWebhookSubscription сохраняет изменения подписки
+func (r *PostgresRepository) UpdateWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	ctx, end := r.startOperation(ctx, "UpdateWebhookSubscription")
+	defer end()
+
+	query := `
+	UPDATE webhook_subscriptions
+	SET url = $1, description = $2, event_types = $3, secrets = $4, enabled = $5, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $6
+	RETURNING updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		subscription.URL,
+		subscription.Description,
+		pq.Array(nonNilStrings(subscription.EventTypes)),
+		pq.Array(nonNilStrings(subscription.Secrets)),
+		subscription.Enabled,
+		subscription.ID,
+	).Scan(&subscription.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebhookSubscriptionNotFound
+		}
+		return fmt.Errorf("не удалось обновить подписку на webhook: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteWebhookSubscription удаляет подписку вместе с ее сообщениями в outbox
+func (r *PostgresRepository) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	ctx, end := r.startOperation(ctx, "DeleteWebhookSubscription")
+	defer end()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("не удалось удалить подписку на webhook: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось удалить подписку на webhook: %w", err)
+	}
+	if affected == 0 {
+		return ErrWebhookSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// rowScanner общий интерфейс *sql.Row и *sql.Rows для чтения строки
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanWebhookSubscription читает подписку из строки результата
+func scanWebhookSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+	subscription := &models.WebhookSubscription{}
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.URL,
+		&subscription.Description,
+		pq.Array(&subscription.EventTypes),
+		pq.Array(&subscription.Secrets),
+		&subscription.Enabled,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// nonNilStrings заменяет nil на пустой срез, так как pq.Array передает nil как NULL
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
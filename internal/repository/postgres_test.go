@@ -0,0 +1,39 @@
+package repository_test
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/repository"
+	"auth-service/internal/repository/repotest"
+	"context"
+	"os"
+	"testing"
+)
+
+// postgresDSNEnv переменная окружения со строкой подключения к тестовой базе PostgreSQL.
+// Все данные базы удаляются перед каждой проверкой.
+const postgresDSNEnv = "TEST_POSTGRES_DSN"
+
+func TestPostgresRepository(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("не задана переменная окружения %s", postgresDSNEnv)
+	}
+
+	repotest.Run(t, func(t *testing.T) repository.Repository {
+		repo, err := repository.NewPostgresRepository(config.DatabaseConfig{DSN: dsn})
+		if err != nil {
+			t.Fatalf("NewPostgresRepository: %v", err)
+		}
+		// Откат всех миграций очищает базу после предыдущей проверки
+		ctx := context.Background()
+		if err := repo.MigrateTo(ctx, 0); err != nil {
+			_ = repo.Close()
+			t.Fatalf("MigrateTo(0): %v", err)
+		}
+		if err := repo.Migrate(ctx); err != nil {
+			_ = repo.Close()
+			t.Fatalf("Migrate: %v", err)
+		}
+		return repo
+	})
+}
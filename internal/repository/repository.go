@@ -2,27 +2,188 @@ package repository
 
 import (
 	"auth-service/internal/models"
+	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 // Repository интерфейс для работы с данными
 type Repository interface {
-	// CreateSession создает новую сессию для пользователя
-	CreateSession(userID uuid.UUID, refreshToken, refreshTokenID, userAgent, clientIP string, expiresAt int64) (int, error)
+	// CreateSession создает новую сессию для пользователя, токены которой привязаны к ключу клиента binding.
+	// Переданные события записываются в журнал событий в той же транзакции.
+	CreateSession(ctx context.Context, userID uuid.UUID, refreshToken, refreshTokenID, userAgent, clientIP, kind string, binding models.TokenBinding, expiresAt int64, events ...models.Event) (int, error)
 
-	// GetSessionByRefreshToken получает сессию по refresh токену
-	GetSessionByRefreshToken(refreshTokenHash string) (*models.Session, error)
+	// GetSessionByRefreshToken получает сессию по refresh токену.
+	// Возвращает ErrSessionNotFound, ErrSessionExpired или ErrSessionRevoked, если сессия недействительна.
+	GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.Session, error)
 
-	// UpdateSession обновляет сессию
-	UpdateSession(sessionID int, refreshToken, refreshTokenID string, expiresAt int64) error
+	// GetSession получает сессию по ID независимо от ее состояния.
+	// Возвращает ErrSessionNotFound, если сессии нет.
+	GetSession(ctx context.Context, sessionID int) (*models.Session, error)
 
-	// BlockSession блокирует сессию
-	BlockSession(sessionID int) error
+	// RotateRefreshToken атомарно заменяет refresh токен сессии, если ее текущий токен
+	// равен rotation.OldRefreshToken, и сохраняет старый токен в истории.
+	// Возвращает ErrRefreshTokenRotated, если токен уже заменен конкурирующим запросом,
+	// ErrSessionRevoked или ErrSessionExpired, если сессия стала недействительной.
+	// Переданные события записываются в журнал событий в той же транзакции.
+	RotateRefreshToken(ctx context.Context, rotation models.RefreshTokenRotation, events ...models.Event) error
 
-	// BlockAllUserSessions блокирует все сессии пользователя
-	BlockAllUserSessions(userID uuid.UUID) error
+	// GetRefreshTokenHistory получает запись о замененном refresh токене.
+	// Возвращает ErrSessionNotFound, если токен не заменялся.
+	GetRefreshTokenHistory(ctx context.Context, refreshTokenHash string) (*models.RefreshTokenHistory, error)
+
+	// BlockSession блокирует сессию.
+	// Переданные события записываются в журнал событий в той же транзакции.
+	BlockSession(ctx context.Context, sessionID int, events ...models.Event) error
+
+	// BlockAllUserSessions блокирует все сессии пользователя.
+	// Переданные события записываются в журнал событий в той же транзакции.
+	BlockAllUserSessions(ctx context.Context, userID uuid.UUID, events ...models.Event) error
+
+	// BlockSessionsCreatedBefore блокирует все незаблокированные сессии, созданные раньше before,
+	// и возвращает их количество.
+	// Переданные события записываются в журнал событий в той же транзакции.
+	BlockSessionsCreatedBefore(ctx context.Context, before time.Time, events ...models.Event) (int, error)
+
+	// ListSessions возвращает сессии по фильтру, начиная с самых новых
+	ListSessions(ctx context.Context, filter models.SessionFilter) ([]*models.Session, error)
+
+	// ListBlockedSessions возвращает сессии, заблокированные не раньше since
+	ListBlockedSessions(ctx context.Context, since time.Time) ([]models.BlockedSession, error)
+
+	// DeleteStaleSessions удаляет не более limit сессий, срок действия которых истек раньше before
+	// или которые заблокированы раньше before, вместе с историей их refresh токенов.
+	// Возвращает количество удаленных сессий.
+	DeleteStaleSessions(ctx context.Context, before time.Time, limit int) (int, error)
+
+	// CountActiveSessions возвращает количество незаблокированных сессий с действующим refresh токеном
+	CountActiveSessions(ctx context.Context) (int, error)
+
+	// Ping проверяет соединение с хранилищем
+	Ping(ctx context.Context) error
+
+	// CheckSchema проверяет, что схема хранилища создана и соответствует версии сервиса
+	CheckSchema(ctx context.Context) error
+
+	EventLog
+	AuditLog
+	WebhookOutbox
+	WebhookSubscriptions
+	Clients
 
 	// Close закрывает соединение с базой данных
 	Close() error
 }
+
+// Locker хранилище, позволяющее выполнять фоновую задачу только на одном экземпляре сервиса
+type Locker interface {
+	// TryLock захватывает блокировку name, если ее не удерживает другой экземпляр.
+	// Возвращает false, если блокировка занята; иначе unlock освобождает ее.
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// Broadcaster хранилище, доставляющее уведомления между экземплярами сервиса
+type Broadcaster interface {
+	// Notify отправляет уведомление payload всем подписчикам канала channel
+	Notify(ctx context.Context, channel, payload string) error
+
+	// Subscribe передает в handle уведомления канала channel до отмены ctx.
+	// Уведомления, отправленные при разрыве соединения, теряются, поэтому resync вызывается
+	// после подключения и каждого переподключения.
+	Subscribe(ctx context.Context, channel string, handle func(payload string), resync func()) error
+}
+
+// RevocationChecker хранилище, учитывающее отзыв выданных access токенов при блокировке сессий
+type RevocationChecker interface {
+	// IsAccessTokenRevoked сообщает, отозван ли access токен tokenID сессии sessionID,
+	// выпущенный пользователю userID в момент issuedAt
+	IsAccessTokenRevoked(ctx context.Context, userID uuid.UUID, sessionID int, tokenID string, issuedAt time.Time) (bool, error)
+}
+
+// EventLog интерфейс журнала событий, из которого события публикуются в sink-и
+type EventLog interface {
+	// AppendEvents записывает в журнал события, не связанные с изменением сессии.
+	// Как и события сессий, они попадают в журнал аудита.
+	AppendEvents(ctx context.Context, events ...models.Event) error
+
+	// ClaimEvents захватывает неопубликованные события на время lease в порядке их записи
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]*models.EventRecord, error)
+
+	// MarkEventsPublished отмечает события как опубликованные
+	MarkEventsPublished(ctx context.Context, ids ...int64) error
+}
+
+// AuditLog интерфейс журнала аудита безопасности.
+// Записи добавляются вместе с событиями и не изменяются.
+type AuditLog interface {
+	// ListAuditEvents возвращает записи аудита по фильтру, начиная с самых новых
+	ListAuditEvents(ctx context.Context, filter models.AuditEventFilter) ([]*models.AuditEvent, error)
+
+	// ExportAuditEvents передает в fn записи аудита по фильтру в порядке их добавления
+	ExportAuditEvents(ctx context.Context, filter models.AuditEventFilter, fn func(event *models.AuditEvent) error) error
+}
+
+// WebhookOutbox интерфейс очереди исходящих webhook
+type WebhookOutbox interface {
+	// EnqueueWebhookEvents ставит события в очередь отдельно
+	// для каждой включенной подписки на их тип
+	EnqueueWebhookEvents(ctx context.Context, events ...models.Event) error
+
+	// ClaimWebhookMessages захватывает готовые к отправке сообщения на время lease
+	// и увеличивает счетчик попыток. Захваченные сообщения не выдаются другим обработчикам.
+	ClaimWebhookMessages(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookMessage, error)
+
+	// MarkWebhookDelivered отмечает сообщение как доставленное
+	MarkWebhookDelivered(ctx context.Context, id int64) error
+
+	// MarkWebhookFailed сохраняет ошибку доставки и планирует повторную попытку,
+	// либо переводит сообщение в статус dead, если dead = true
+	MarkWebhookFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error
+
+	// RetryWebhookMessage возвращает сообщение в очередь для немедленной отправки
+	RetryWebhookMessage(ctx context.Context, id int64) error
+
+	// GetWebhookMessage возвращает сообщение по ID
+	GetWebhookMessage(ctx context.Context, id int64) (*models.WebhookMessage, error)
+
+	// ListWebhookMessages возвращает сообщения, удовлетворяющие фильтру
+	ListWebhookMessages(ctx context.Context, filter models.WebhookMessageFilter) ([]*models.WebhookMessage, error)
+
+	// CountWebhookBacklog возвращает количество сообщений, ожидающих отправки дольше olderThan
+	CountWebhookBacklog(ctx context.Context, olderThan time.Duration) (int, error)
+}
+
+// WebhookSubscriptions интерфейс реестра подписок на webhook
+type WebhookSubscriptions interface {
+	// CreateWebhookSubscription создает подписку и заполняет ее ID и время создания
+	CreateWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+
+	// GetWebhookSubscription возвращает подписку по ID
+	GetWebhookSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error)
+
+	// ListWebhookSubscriptions возвращает все подписки
+	ListWebhookSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error)
+
+	// UpdateWebhookSubscription сохраняет изменения подписки
+	UpdateWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+
+	// DeleteWebhookSubscription удаляет подписку вместе с ее сообщениями в outbox
+	DeleteWebhookSubscription(ctx context.Context, id int64) error
+}
+
+// Clients интерфейс реестра сервисных клиентов
+type Clients interface {
+	// CreateClient создает клиента и заполняет время его создания
+	CreateClient(ctx context.Context, client *models.Client) error
+
+	// GetClient возвращает клиента по ID
+	GetClient(ctx context.Context, id uuid.UUID) (*models.Client, error)
+
+	// ListClients возвращает всех клиентов
+	ListClients(ctx context.Context) ([]*models.Client, error)
+
+	// UpdateClient сохраняет изменения клиента.
+	// Возвращает ErrClientNotFound, если клиента нет.
+	UpdateClient(ctx context.Context, client *models.Client) error
+}
@@ -0,0 +1,807 @@
+// Package repotest содержит проверки поведения, общего для всех реализаций repository.Repository.
+//
+// Реализация подключает проверки из своего теста:
+//
+//	func TestMemoryRepository(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) repository.Repository {
+//			return repository.NewMemoryRepository()
+//		})
+//	}
+package repotest
+
+import (
+	"auth-service/internal/events"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Factory создает пустое хранилище для одной проверки
+type Factory func(t *testing.T) repository.Repository
+
+// Run выполняет все проверки для реализации, создаваемой newRepository
+func Run(t *testing.T, newRepository Factory) {
+	t.Run("Sessions", func(t *testing.T) { RunSessions(t, newRepository) })
+	t.Run("Rotation", func(t *testing.T) { RunRotation(t, newRepository) })
+	t.Run("Events", func(t *testing.T) { RunEvents(t, newRepository) })
+	t.Run("Webhooks", func(t *testing.T) { RunWebhooks(t, newRepository) })
+	t.Run("Clients", func(t *testing.T) { RunClients(t, newRepository) })
+}
+
+// RunSessions проверяет создание, поиск, истечение, блокировку и удаление сессий
+func RunSessions(t *testing.T, newRepository Factory) {
+	t.Run("LookupByHash", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+		userID := uuid.New()
+
+		id := createSession(t, repo, userID, "hash-1", time.Hour)
+		session, err := repo.GetSessionByRefreshToken(ctx, "hash-1")
+		if err != nil {
+			t.Fatalf("GetSessionByRefreshToken: %v", err)
+		}
+		if session.ID != id || session.UserID != userID || session.RefreshToken != "hash-1" ||
+			session.UserAgent != "agent" || session.ClientIP != "192.0.2.1" || session.RefreshTokenID != "id-hash-1" {
+			t.Fatalf("GetSessionByRefreshToken вернул %+v", session)
+		}
+
+		if _, err := repo.GetSessionByRefreshToken(ctx, "unknown"); !errors.Is(err, repository.ErrSessionNotFound) {
+			t.Fatalf("поиск по неизвестному хешу: ожидалась ErrSessionNotFound, получено %v", err)
+		}
+		if _, err := repo.GetSession(ctx, id+1000); !errors.Is(err, repository.ErrSessionNotFound) {
+			t.Fatalf("GetSession неизвестной сессии: ожидалась ErrSessionNotFound, получено %v", err)
+		}
+	})
+
+	t.Run("Binding", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		binding := models.TokenBinding{CertThumbprint: "x5t", JWKThumbprint: "jkt"}
+		id, err := repo.CreateSession(ctx, uuid.New(), "bound", "id-bound", "agent", "192.0.2.1", models.SessionKindClient, binding, expiresIn(time.Hour))
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+
+		session, err := repo.GetSessionByRefreshToken(ctx, "bound")
+		if err != nil {
+			t.Fatalf("GetSessionByRefreshToken: %v", err)
+		}
+		if session.Binding != binding || session.Kind != models.SessionKindClient {
+			t.Fatalf("GetSessionByRefreshToken вернул привязку %+v и вид %q", session.Binding, session.Kind)
+		}
+		if session, err = repo.GetSession(ctx, id); err != nil || session.Binding != binding || session.Kind != models.SessionKindClient {
+			t.Fatalf("GetSession вернул %+v, %v", session, err)
+		}
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		createSession(t, repo, uuid.New(), "expired", -time.Minute)
+		createSession(t, repo, uuid.New(), "active", time.Hour)
+
+		if _, err := repo.GetSessionByRefreshToken(ctx, "expired"); !errors.Is(err, repository.ErrSessionExpired) {
+			t.Fatalf("истекшая сессия: ожидалась ErrSessionExpired, получено %v", err)
+		}
+		expectActiveSessions(t, repo, 1)
+	})
+
+	t.Run("BlockSession", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		id := createSession(t, repo, uuid.New(), "blocked", time.Hour)
+		if err := repo.BlockSession(ctx, id); err != nil {
+			t.Fatalf("BlockSession: %v", err)
+		}
+
+		if _, err := repo.GetSessionByRefreshToken(ctx, "blocked"); !errors.Is(err, repository.ErrSessionRevoked) {
+			t.Fatalf("заблокированная сессия: ожидалась ErrSessionRevoked, получено %v", err)
+		}
+		session, err := repo.GetSession(ctx, id)
+		if err != nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		if !session.IsBlocked {
+			t.Fatal("GetSession: сессия не отмечена как заблокированная")
+		}
+		expectActiveSessions(t, repo, 0)
+	})
+
+	t.Run("BlockAllUserSessions", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+		userID := uuid.New()
+
+		createSession(t, repo, userID, "user-1", time.Hour)
+		createSession(t, repo, userID, "user-2", time.Hour)
+		createSession(t, repo, uuid.New(), "other", time.Hour)
+
+		if err := repo.BlockAllUserSessions(ctx, userID); err != nil {
+			t.Fatalf("BlockAllUserSessions: %v", err)
+		}
+
+		for _, hash := range []string{"user-1", "user-2"} {
+			if _, err := repo.GetSessionByRefreshToken(ctx, hash); !errors.Is(err, repository.ErrSessionRevoked) {
+				t.Fatalf("сессия %s: ожидалась ErrSessionRevoked, получено %v", hash, err)
+			}
+		}
+		if _, err := repo.GetSessionByRefreshToken(ctx, "other"); err != nil {
+			t.Fatalf("сессия другого пользователя заблокирована: %v", err)
+		}
+		expectActiveSessions(t, repo, 1)
+	})
+
+	t.Run("ListSessions", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+		userID := uuid.New()
+
+		expired := createSession(t, repo, userID, "expired", -time.Hour)
+		blocked := createSession(t, repo, userID, "blocked", time.Hour)
+		active := createSession(t, repo, userID, "active", time.Hour)
+		createSession(t, repo, uuid.New(), "other", time.Hour)
+		if err := repo.BlockSession(ctx, blocked); err != nil {
+			t.Fatalf("BlockSession: %v", err)
+		}
+
+		sessions, err := repo.ListSessions(ctx, models.SessionFilter{UserID: userID})
+		if err != nil {
+			t.Fatalf("ListSessions: %v", err)
+		}
+		if len(sessions) != 3 || sessions[0].ID != active || sessions[1].ID != blocked || sessions[2].ID != expired {
+			t.Fatalf("ListSessions вернул %d сессий, ожидались %d, %d и %d от новых к старым", len(sessions), active, blocked, expired)
+		}
+		if !sessions[1].IsBlocked || sessions[0].CreatedAt.IsZero() || sessions[0].UserAgent != "agent" {
+			t.Fatalf("ListSessions вернул %+v", sessions[:2])
+		}
+
+		sessions, err = repo.ListSessions(ctx, models.SessionFilter{UserID: userID, ActiveOnly: true})
+		if err != nil {
+			t.Fatalf("ListSessions: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].ID != active {
+			t.Fatalf("ListSessions с ActiveOnly вернул %+v", sessions)
+		}
+
+		sessions, err = repo.ListSessions(ctx, models.SessionFilter{Limit: 2, Offset: 1})
+		if err != nil {
+			t.Fatalf("ListSessions: %v", err)
+		}
+		if len(sessions) != 2 || sessions[0].ID != active || sessions[1].ID != blocked {
+			t.Fatalf("ListSessions всех пользователей с Limit и Offset вернул %d сессий", len(sessions))
+		}
+	})
+
+	t.Run("BlockSessionsCreatedBefore", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		old := createSession(t, repo, uuid.New(), "old", time.Hour)
+		time.Sleep(10 * time.Millisecond)
+		before := time.Now()
+		time.Sleep(10 * time.Millisecond)
+		createSession(t, repo, uuid.New(), "new", time.Hour)
+
+		blocked, err := repo.BlockSessionsCreatedBefore(ctx, before, newEvent(t, models.EventSessionRevoked, uuid.New()))
+		if err != nil {
+			t.Fatalf("BlockSessionsCreatedBefore: %v", err)
+		}
+		if blocked != 1 {
+			t.Fatalf("BlockSessionsCreatedBefore заблокировал %d сессий, ожидалась 1", blocked)
+		}
+		if _, err := repo.GetSessionByRefreshToken(ctx, "old"); !errors.Is(err, repository.ErrSessionRevoked) {
+			t.Fatalf("сессия %d: ожидалась ErrSessionRevoked, получено %v", old, err)
+		}
+		if _, err := repo.GetSessionByRefreshToken(ctx, "new"); err != nil {
+			t.Fatalf("сессия, созданная позже before, заблокирована: %v", err)
+		}
+
+		// Уже заблокированные сессии не учитываются повторно
+		if blocked, err = repo.BlockSessionsCreatedBefore(ctx, before); err != nil || blocked != 0 {
+			t.Fatalf("повторный BlockSessionsCreatedBefore: %d, %v", blocked, err)
+		}
+	})
+
+	t.Run("ListBlockedSessions", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+		userID := uuid.New()
+
+		first := createSession(t, repo, userID, "user-1", time.Hour)
+		second := createSession(t, repo, userID, "user-2", time.Hour)
+		createSession(t, repo, uuid.New(), "other", time.Hour)
+
+		since := time.Now().Add(-time.Minute)
+		if err := repo.BlockAllUserSessions(ctx, userID); err != nil {
+			t.Fatalf("BlockAllUserSessions: %v", err)
+		}
+
+		blocked, err := repo.ListBlockedSessions(ctx, since)
+		if err != nil {
+			t.Fatalf("ListBlockedSessions: %v", err)
+		}
+		if len(blocked) != 2 || blocked[0].SessionID != first || blocked[1].SessionID != second {
+			t.Fatalf("ListBlockedSessions вернул %+v, ожидались сессии %d и %d", blocked, first, second)
+		}
+		for _, session := range blocked {
+			if session.UserID != userID || session.BlockedAt.Before(since) {
+				t.Fatalf("ListBlockedSessions вернул %+v", session)
+			}
+		}
+
+		blocked, err = repo.ListBlockedSessions(ctx, time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("ListBlockedSessions: %v", err)
+		}
+		if len(blocked) != 0 {
+			t.Fatalf("ListBlockedSessions вернул сессии, заблокированные раньше since: %+v", blocked)
+		}
+	})
+
+	t.Run("DeleteStaleSessions", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		createSession(t, repo, uuid.New(), "expired", -time.Hour)
+		blocked := createSession(t, repo, uuid.New(), "blocked", time.Hour)
+		createSession(t, repo, uuid.New(), "active", time.Hour)
+
+		if err := repo.RotateRefreshToken(ctx, rotation(blocked, "blocked", "blocked-2", nil)); err != nil {
+			t.Fatalf("RotateRefreshToken: %v", err)
+		}
+		if err := repo.BlockSession(ctx, blocked); err != nil {
+			t.Fatalf("BlockSession: %v", err)
+		}
+
+		// Сессии, ставшие недействительными позже before, сохраняются
+		deleted, err := repo.DeleteStaleSessions(ctx, time.Now().Add(-2*time.Hour), 10)
+		if err != nil {
+			t.Fatalf("DeleteStaleSessions: %v", err)
+		}
+		if deleted != 0 {
+			t.Fatalf("DeleteStaleSessions удалил %d сессий до окончания времени хранения", deleted)
+		}
+
+		before := time.Now().Add(time.Second)
+		deleted, err = repo.DeleteStaleSessions(ctx, before, 1)
+		if err != nil {
+			t.Fatalf("DeleteStaleSessions: %v", err)
+		}
+		if deleted != 1 {
+			t.Fatalf("DeleteStaleSessions с limit = 1 удалил %d сессий", deleted)
+		}
+		deleted, err = repo.DeleteStaleSessions(ctx, before, 10)
+		if err != nil {
+			t.Fatalf("DeleteStaleSessions: %v", err)
+		}
+		if deleted != 1 {
+			t.Fatalf("DeleteStaleSessions удалил %d сессий, ожидалась 1", deleted)
+		}
+
+		if _, err := repo.GetSession(ctx, blocked); !errors.Is(err, repository.ErrSessionNotFound) {
+			t.Fatalf("удаленная сессия: ожидалась ErrSessionNotFound, получено %v", err)
+		}
+		if _, err := repo.GetRefreshTokenHistory(ctx, "blocked"); !errors.Is(err, repository.ErrSessionNotFound) {
+			t.Fatalf("история удаленной сессии: ожидалась ErrSessionNotFound, получено %v", err)
+		}
+		if _, err := repo.GetSessionByRefreshToken(ctx, "active"); err != nil {
+			t.Fatalf("действующая сессия удалена: %v", err)
+		}
+	})
+}
+
+// RunRotation проверяет замену refresh токенов
+func RunRotation(t *testing.T, newRepository Factory) {
+	t.Run("Rotate", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		id := createSession(t, repo, uuid.New(), "old", time.Hour)
+		if err := repo.RotateRefreshToken(ctx, rotation(id, "old", "new", []byte("successor"))); err != nil {
+			t.Fatalf("RotateRefreshToken: %v", err)
+		}
+
+		if _, err := repo.GetSessionByRefreshToken(ctx, "old"); !errors.Is(err, repository.ErrSessionNotFound) {
+			t.Fatalf("замененный токен: ожидалась ErrSessionNotFound, получено %v", err)
+		}
+		session, err := repo.GetSessionByRefreshToken(ctx, "new")
+		if err != nil {
+			t.Fatalf("поиск по новому токену: %v", err)
+		}
+		if session.ID != id || session.RefreshTokenID != "id-new" {
+			t.Fatalf("поиск по новому токену вернул %+v", session)
+		}
+
+		history, err := repo.GetRefreshTokenHistory(ctx, "old")
+		if err != nil {
+			t.Fatalf("GetRefreshTokenHistory: %v", err)
+		}
+		if history.SessionID != id || string(history.Successor) != "successor" || history.RotatedAt.IsZero() {
+			t.Fatalf("GetRefreshTokenHistory вернул %+v", history)
+		}
+		if _, err := repo.GetRefreshTokenHistory(ctx, "new"); !errors.Is(err, repository.ErrSessionNotFound) {
+			t.Fatalf("история незамененного токена: ожидалась ErrSessionNotFound, получено %v", err)
+		}
+
+		// Следующая замена делает недоступной пару, выданную при предыдущей
+		if err := repo.RotateRefreshToken(ctx, rotation(id, "new", "newer", []byte("next"))); err != nil {
+			t.Fatalf("повторная RotateRefreshToken: %v", err)
+		}
+		history, err = repo.GetRefreshTokenHistory(ctx, "old")
+		if err != nil {
+			t.Fatalf("GetRefreshTokenHistory: %v", err)
+		}
+		if history.Successor != nil {
+			t.Fatal("после следующей замены пара предыдущей замены не удалена")
+		}
+	})
+
+	t.Run("StaleToken", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		id := createSession(t, repo, uuid.New(), "current", time.Hour)
+		err := repo.RotateRefreshToken(ctx, rotation(id, "stale", "new", nil))
+		if !errors.Is(err, repository.ErrRefreshTokenRotated) {
+			t.Fatalf("замена устаревшего токена: ожидалась ErrRefreshTokenRotated, получено %v", err)
+		}
+		if _, err := repo.GetSessionByRefreshToken(ctx, "current"); err != nil {
+			t.Fatalf("текущий токен перестал действовать: %v", err)
+		}
+	})
+
+	t.Run("InvalidSession", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		blocked := createSession(t, repo, uuid.New(), "blocked", time.Hour)
+		if err := repo.BlockSession(ctx, blocked); err != nil {
+			t.Fatalf("BlockSession: %v", err)
+		}
+		if err := repo.RotateRefreshToken(ctx, rotation(blocked, "blocked", "new-1", nil)); !errors.Is(err, repository.ErrSessionRevoked) {
+			t.Fatalf("замена в заблокированной сессии: ожидалась ErrSessionRevoked, получено %v", err)
+		}
+
+		expired := createSession(t, repo, uuid.New(), "expired", -time.Minute)
+		if err := repo.RotateRefreshToken(ctx, rotation(expired, "expired", "new-2", nil)); !errors.Is(err, repository.ErrSessionExpired) {
+			t.Fatalf("замена в истекшей сессии: ожидалась ErrSessionExpired, получено %v", err)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		id := createSession(t, repo, uuid.New(), "shared", time.Hour)
+
+		const attempts = 16
+		errs := make([]error, attempts)
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = repo.RotateRefreshToken(ctx, rotation(id, "shared", fmt.Sprintf("next-%d", i), nil))
+			}(i)
+		}
+		wg.Wait()
+
+		winner := -1
+		for i, err := range errs {
+			switch {
+			case err == nil && winner == -1:
+				winner = i
+			case err == nil:
+				t.Fatalf("токен заменен дважды: попытки %d и %d", winner, i)
+			case !errors.Is(err, repository.ErrRefreshTokenRotated):
+				t.Fatalf("попытка %d: ожидалась ErrRefreshTokenRotated, получено %v", i, err)
+			}
+		}
+		if winner == -1 {
+			t.Fatal("ни одна из конкурирующих замен не выполнена")
+		}
+
+		session, err := repo.GetSession(ctx, id)
+		if err != nil {
+			t.Fatalf("GetSession: %v", err)
+		}
+		if session.RefreshToken != fmt.Sprintf("next-%d", winner) {
+			t.Fatalf("в сессии сохранен токен %s, а заменила его попытка %d", session.RefreshToken, winner)
+		}
+	})
+}
+
+// RunEvents проверяет журнал событий и журнал аудита
+func RunEvents(t *testing.T, newRepository Factory) {
+	t.Run("ClaimAndPublish", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+		userID := uuid.New()
+
+		created := newEvent(t, models.EventSessionCreated, userID)
+		if _, err := repo.CreateSession(ctx, userID, "hash", "id", "agent", "192.0.2.1", models.SessionKindUser, models.TokenBinding{}, expiresIn(time.Hour), created); err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		failed := newEvent(t, models.EventLoginFailed, userID)
+		if err := repo.AppendEvents(ctx, failed); err != nil {
+			t.Fatalf("AppendEvents: %v", err)
+		}
+
+		records, err := repo.ClaimEvents(ctx, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimEvents: %v", err)
+		}
+		if len(records) != 2 || records[0].Event.ID != created.ID || records[1].Event.ID != failed.ID {
+			t.Fatalf("ClaimEvents вернул %d событий, ожидались %s и %s в порядке записи", len(records), created.ID, failed.ID)
+		}
+
+		// Захваченные события не выдаются повторно до истечения lease
+		again, err := repo.ClaimEvents(ctx, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimEvents: %v", err)
+		}
+		if len(again) != 0 {
+			t.Fatalf("захваченные события выданы повторно: %d", len(again))
+		}
+
+		if err := repo.MarkEventsPublished(ctx, records[0].ID, records[1].ID); err != nil {
+			t.Fatalf("MarkEventsPublished: %v", err)
+		}
+		published, err := repo.ClaimEvents(ctx, 10, 0)
+		if err != nil {
+			t.Fatalf("ClaimEvents: %v", err)
+		}
+		if len(published) != 0 {
+			t.Fatalf("опубликованные события выданы повторно: %d", len(published))
+		}
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+		userID := uuid.New()
+
+		created := newEvent(t, models.EventSessionCreated, userID)
+		sessionID, err := repo.CreateSession(ctx, userID, "hash", "id", "agent", "192.0.2.1", models.SessionKindUser, models.TokenBinding{}, expiresIn(time.Hour), created)
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		other := newEvent(t, models.EventLoginFailed, uuid.New())
+		if err := repo.AppendEvents(ctx, other); err != nil {
+			t.Fatalf("AppendEvents: %v", err)
+		}
+		// Повторная запись того же события не дублирует запись аудита
+		if err := repo.AppendEvents(ctx, other); err != nil {
+			t.Fatalf("AppendEvents: %v", err)
+		}
+
+		all, err := repo.ListAuditEvents(ctx, models.AuditEventFilter{})
+		if err != nil {
+			t.Fatalf("ListAuditEvents: %v", err)
+		}
+		if len(all) != 2 || all[0].EventID != other.ID || all[1].EventID != created.ID {
+			t.Fatalf("ListAuditEvents вернул %d записей, ожидались %s и %s, начиная с новой", len(all), other.ID, created.ID)
+		}
+
+		byUser, err := repo.ListAuditEvents(ctx, models.AuditEventFilter{UserID: userID.String()})
+		if err != nil {
+			t.Fatalf("ListAuditEvents: %v", err)
+		}
+		if len(byUser) != 1 || byUser[0].EventID != created.ID {
+			t.Fatalf("фильтр по пользователю вернул %d записей", len(byUser))
+		}
+		audit := byUser[0]
+		if audit.SessionID == nil || *audit.SessionID != sessionID || audit.Outcome != models.AuditOutcomeSuccess || audit.ClientIP != "192.0.2.1" {
+			t.Fatalf("запись аудита заполнена неверно: %+v", audit)
+		}
+
+		byType, err := repo.ListAuditEvents(ctx, models.AuditEventFilter{EventType: models.EventLoginFailed})
+		if err != nil {
+			t.Fatalf("ListAuditEvents: %v", err)
+		}
+		if len(byType) != 1 || byType[0].EventID != other.ID {
+			t.Fatalf("фильтр по типу события вернул %d записей", len(byType))
+		}
+
+		paged, err := repo.ListAuditEvents(ctx, models.AuditEventFilter{Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("ListAuditEvents: %v", err)
+		}
+		if len(paged) != 1 || paged[0].EventID != created.ID {
+			t.Fatalf("постраничная выборка вернула %d записей", len(paged))
+		}
+
+		var exported []string
+		err = repo.ExportAuditEvents(ctx, models.AuditEventFilter{}, func(event *models.AuditEvent) error {
+			exported = append(exported, event.EventID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ExportAuditEvents: %v", err)
+		}
+		if len(exported) != 2 || exported[0] != created.ID || exported[1] != other.ID {
+			t.Fatalf("ExportAuditEvents вернул %v, ожидался порядок добавления", exported)
+		}
+	})
+}
+
+// RunWebhooks проверяет подписки и очередь исходящих webhook
+func RunWebhooks(t *testing.T, newRepository Factory) {
+	t.Run("Subscriptions", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		subscription := &models.WebhookSubscription{
+			URL:        "https://example.com/hook",
+			EventTypes: []string{models.EventSessionCreated},
+			Secrets:    []string{"secret"},
+			Enabled:    true,
+		}
+		if err := repo.CreateWebhookSubscription(ctx, subscription); err != nil {
+			t.Fatalf("CreateWebhookSubscription: %v", err)
+		}
+		if subscription.ID == 0 || subscription.CreatedAt.IsZero() {
+			t.Fatalf("CreateWebhookSubscription не заполнил ID и время создания: %+v", subscription)
+		}
+
+		subscription.Enabled = false
+		if err := repo.UpdateWebhookSubscription(ctx, subscription); err != nil {
+			t.Fatalf("UpdateWebhookSubscription: %v", err)
+		}
+		stored, err := repo.GetWebhookSubscription(ctx, subscription.ID)
+		if err != nil {
+			t.Fatalf("GetWebhookSubscription: %v", err)
+		}
+		if stored.Enabled || stored.URL != subscription.URL || len(stored.Secrets) != 1 {
+			t.Fatalf("GetWebhookSubscription вернул %+v", stored)
+		}
+
+		list, err := repo.ListWebhookSubscriptions(ctx)
+		if err != nil {
+			t.Fatalf("ListWebhookSubscriptions: %v", err)
+		}
+		if len(list) != 1 {
+			t.Fatalf("ListWebhookSubscriptions вернул %d подписок", len(list))
+		}
+
+		if err := repo.DeleteWebhookSubscription(ctx, subscription.ID); err != nil {
+			t.Fatalf("DeleteWebhookSubscription: %v", err)
+		}
+		if _, err := repo.GetWebhookSubscription(ctx, subscription.ID); !errors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+			t.Fatalf("удаленная подписка: ожидалась ErrWebhookSubscriptionNotFound, получено %v", err)
+		}
+		if err := repo.DeleteWebhookSubscription(ctx, subscription.ID); !errors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+			t.Fatalf("повторное удаление: ожидалась ErrWebhookSubscriptionNotFound, получено %v", err)
+		}
+	})
+
+	t.Run("Outbox", func(t *testing.T) {
+		repo := open(t, newRepository)
+		ctx := context.Background()
+
+		matching := &models.WebhookSubscription{URL: "https://example.com/a", EventTypes: []string{models.EventAll}, Secrets: []string{"a"}, Enabled: true}
+		disabled := &models.WebhookSubscription{URL: "https://example.com/b", EventTypes: []string{models.EventAll}, Secrets: []string{"b"}, Enabled: false}
+		other := &models.WebhookSubscription{URL: "https://example.com/c", EventTypes: []string{models.EventUserLocked}, Secrets: []string{"c"}, Enabled: true}
+		for _, subscription := range []*models.WebhookSubscription{matching, disabled, other} {
+			if err := repo.CreateWebhookSubscription(ctx, subscription); err != nil {
+				t.Fatalf("CreateWebhookSubscription: %v", err)
+			}
+		}
+
+		event := newEvent(t, models.EventSessionCreated, uuid.New())
+		if err := repo.EnqueueWebhookEvents(ctx, event); err != nil {
+			t.Fatalf("EnqueueWebhookEvents: %v", err)
+		}
+
+		messages, err := repo.ClaimWebhookMessages(ctx, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimWebhookMessages: %v", err)
+		}
+		if len(messages) != 1 {
+			t.Fatalf("ClaimWebhookMessages вернул %d сообщений, ожидалось одно для включенной подписки", len(messages))
+		}
+		message := messages[0]
+		if message.SubscriptionID == nil || *message.SubscriptionID != matching.ID || message.EventID != event.ID || message.Attempts != 1 {
+			t.Fatalf("ClaimWebhookMessages вернул %+v", message)
+		}
+
+		again, err := repo.ClaimWebhookMessages(ctx, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimWebhookMessages: %v", err)
+		}
+		if len(again) != 0 {
+			t.Fatalf("захваченное сообщение выдано повторно")
+		}
+
+		if err := repo.MarkWebhookFailed(ctx, message.ID, "timeout", time.Now().Add(-time.Hour), false); err != nil {
+			t.Fatalf("MarkWebhookFailed: %v", err)
+		}
+		backlog, err := repo.CountWebhookBacklog(ctx, time.Minute)
+		if err != nil {
+			t.Fatalf("CountWebhookBacklog: %v", err)
+		}
+		if backlog != 1 {
+			t.Fatalf("CountWebhookBacklog вернул %d, ожидалось 1", backlog)
+		}
+
+		if err := repo.MarkWebhookFailed(ctx, message.ID, "gone", time.Now(), true); err != nil {
+			t.Fatalf("MarkWebhookFailed: %v", err)
+		}
+		dead, err := repo.ListWebhookMessages(ctx, models.WebhookMessageFilter{Status: models.WebhookStatusDead, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListWebhookMessages: %v", err)
+		}
+		if len(dead) != 1 || dead[0].LastError != "gone" {
+			t.Fatalf("ListWebhookMessages вернул %d сообщений в статусе dead", len(dead))
+		}
+
+		if err := repo.RetryWebhookMessage(ctx, message.ID); err != nil {
+			t.Fatalf("RetryWebhookMessage: %v", err)
+		}
+		retried, err := repo.ClaimWebhookMessages(ctx, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("ClaimWebhookMessages: %v", err)
+		}
+		if len(retried) != 1 || retried[0].Attempts != 1 {
+			t.Fatalf("сообщение не возвращено в очередь со сброшенным счетчиком попыток")
+		}
+
+		if err := repo.MarkWebhookDelivered(ctx, message.ID); err != nil {
+			t.Fatalf("MarkWebhookDelivered: %v", err)
+		}
+		delivered, err := repo.GetWebhookMessage(ctx, message.ID)
+		if err != nil {
+			t.Fatalf("GetWebhookMessage: %v", err)
+		}
+		if delivered.Status != models.WebhookStatusDelivered || delivered.DeliveredAt == nil {
+			t.Fatalf("GetWebhookMessage вернул %+v", delivered)
+		}
+		if err := repo.RetryWebhookMessage(ctx, message.ID); !errors.Is(err, repository.ErrWebhookMessageNotFound) {
+			t.Fatalf("повтор доставленного сообщения: ожидалась ErrWebhookMessageNotFound, получено %v", err)
+		}
+
+		// Удаление подписки удаляет ее сообщения
+		if err := repo.DeleteWebhookSubscription(ctx, matching.ID); err != nil {
+			t.Fatalf("DeleteWebhookSubscription: %v", err)
+		}
+		if _, err := repo.GetWebhookMessage(ctx, message.ID); !errors.Is(err, repository.ErrWebhookMessageNotFound) {
+			t.Fatalf("сообщение удаленной подписки: ожидалась ErrWebhookMessageNotFound, получено %v", err)
+		}
+	})
+}
+
+// RunClients проверяет реестр сервисных клиентов
+func RunClients(t *testing.T, newRepository Factory) {
+	repo := open(t, newRepository)
+	ctx := context.Background()
+
+	if _, err := repo.GetClient(ctx, uuid.New()); !errors.Is(err, repository.ErrClientNotFound) {
+		t.Fatalf("GetClient неизвестного клиента: ожидалась ErrClientNotFound, получено %v", err)
+	}
+
+	first := &models.Client{ID: uuid.New(), Name: "billing", SecretHash: "hash-1", Scopes: []string{models.ScopeAdmin}}
+	second := &models.Client{ID: uuid.New(), Name: "reports", SecretHash: "hash-2", Disabled: true}
+	for _, client := range []*models.Client{first, second} {
+		if err := repo.CreateClient(ctx, client); err != nil {
+			t.Fatalf("CreateClient: %v", err)
+		}
+		if client.CreatedAt.IsZero() {
+			t.Fatalf("CreateClient не заполнил время создания: %+v", client)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := repo.CreateClient(ctx, &models.Client{ID: first.ID, Name: "duplicate", SecretHash: "hash"}); err == nil {
+		t.Fatal("CreateClient с существующим ID: ожидалась ошибка")
+	}
+
+	stored, err := repo.GetClient(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("GetClient: %v", err)
+	}
+	if stored.Name != "reports" || stored.SecretHash != "hash-2" || !stored.Disabled || len(stored.Scopes) != 0 {
+		t.Fatalf("GetClient вернул %+v", stored)
+	}
+
+	clients, err := repo.ListClients(ctx)
+	if err != nil {
+		t.Fatalf("ListClients: %v", err)
+	}
+	if len(clients) != 2 || clients[0].ID != first.ID || clients[1].ID != second.ID {
+		t.Fatalf("ListClients вернул %+v", clients)
+	}
+	if !clients[0].HasScope(models.ScopeAdmin) {
+		t.Fatalf("ListClients не вернул области доступа клиента: %+v", clients[0])
+	}
+
+	updated := &models.Client{ID: first.ID, Name: "billing-v2", SecretHash: "hash-3", Disabled: true}
+	if err := repo.UpdateClient(ctx, updated); err != nil {
+		t.Fatalf("UpdateClient: %v", err)
+	}
+	if !updated.CreatedAt.Equal(clients[0].CreatedAt) {
+		t.Fatalf("UpdateClient изменил время создания: %v, ожидалось %v", updated.CreatedAt, clients[0].CreatedAt)
+	}
+	stored, err = repo.GetClient(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("GetClient: %v", err)
+	}
+	if stored.Name != "billing-v2" || stored.SecretHash != "hash-3" || !stored.Disabled || stored.HasScope(models.ScopeAdmin) {
+		t.Fatalf("GetClient после UpdateClient вернул %+v", stored)
+	}
+
+	if err := repo.UpdateClient(ctx, &models.Client{ID: uuid.New(), Name: "unknown"}); !errors.Is(err, repository.ErrClientNotFound) {
+		t.Fatalf("UpdateClient неизвестного клиента: ожидалась ErrClientNotFound, получено %v", err)
+	}
+}
+
+// open создает хранилище и закрывает его по завершении проверки
+func open(t *testing.T, newRepository Factory) repository.Repository {
+	t.Helper()
+	repo := newRepository(t)
+	t.Cleanup(func() { _ = repo.Close() })
+	return repo
+}
+
+// createSession создает сессию с refresh токеном hash, истекающую через ttl
+func createSession(t *testing.T, repo repository.Repository, userID uuid.UUID, hash string, ttl time.Duration) int {
+	t.Helper()
+	id, err := repo.CreateSession(context.Background(), userID, hash, "id-"+hash, "agent", "192.0.2.1", models.SessionKindUser, models.TokenBinding{}, expiresIn(ttl))
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	return id
+}
+
+// rotation описывает замену токена oldHash на newHash
+func rotation(sessionID int, oldHash, newHash string, successor []byte) models.RefreshTokenRotation {
+	return models.RefreshTokenRotation{
+		SessionID:         sessionID,
+		OldRefreshToken:   oldHash,
+		NewRefreshToken:   newHash,
+		NewRefreshTokenID: "id-" + newHash,
+		ExpiresAt:         expiresIn(time.Hour),
+		Successor:         successor,
+	}
+}
+
+// expectActiveSessions проверяет количество активных сессий
+func expectActiveSessions(t *testing.T, repo repository.Repository, want int) {
+	t.Helper()
+	count, err := repo.CountActiveSessions(context.Background())
+	if err != nil {
+		t.Fatalf("CountActiveSessions: %v", err)
+	}
+	if count != want {
+		t.Fatalf("CountActiveSessions вернул %d, ожидалось %d", count, want)
+	}
+}
+
+// newEvent создает событие о действии пользователя
+func newEvent(t *testing.T, eventType string, userID uuid.UUID) models.Event {
+	t.Helper()
+	event, err := events.New(context.Background(), "repotest", eventType, userID.String(), models.SessionEventData{
+		ActorID:   userID.String(),
+		UserID:    userID.String(),
+		UserAgent: "agent",
+		ClientIP:  "192.0.2.1",
+		Outcome:   models.AuditOutcomeSuccess,
+	})
+	if err != nil {
+		t.Fatalf("events.New: %v", err)
+	}
+	return event
+}
+
+// expiresIn возвращает время истечения refresh токена через ttl
+func expiresIn(ttl time.Duration) int64 {
+	return time.Now().Add(ttl).Unix()
+}
@@ -0,0 +1,395 @@
+package repository
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/metrics"
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/google/uuid"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+// SQLiteRepository реализация Repository с использованием встроенной базы SQLite.
+// Предназначена для небольших установок, где отдельный сервер PostgreSQL не нужен.
+type SQLiteRepository struct {
+	db *sql.DB
+	// queryTimeout ограничивает время одной операции; 0 отключает ограничение
+	queryTimeout time.Duration
+}
+
+// SQLiteRepository должен оставаться взаимозаменяемым с PostgresRepository
+var _ Repository = (*SQLiteRepository)(nil)
+
+// NewSQLiteRepository создает новый экземпляр SQLiteRepository.
+// cfg.DSN — путь к файлу базы данных или URI вида file:auth.db?mode=rwc.
+func NewSQLiteRepository(cfg config.DatabaseConfig) (*SQLiteRepository, error) {
+	if cfg.DSN == "" {
+		return nil, errors.New("не задана строка подключения к SQLite (DB_DSN)")
+	}
+
+	dsn, err := sqliteDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть базу данных SQLite: %w", err)
+	}
+	// Каждое соединение с базой в памяти видит свою отдельную базу
+	if isSQLiteMemory(cfg.DSN) {
+		db.SetMaxOpenConns(1)
+	}
+
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("не удалось открыть базу данных SQLite: %w", err)
+	}
+
+	return &SQLiteRepository{db: db, queryTimeout: cfg.QueryTimeout}, nil
+}
+
+// sqliteDSN дополняет строку подключения параметрами, которые нужны репозиторию,
+// если они не заданы явно: внешние ключи для каскадного удаления, WAL для одновременного
+// чтения и записи и BEGIN IMMEDIATE, чтобы транзакции ждали блокировку записи,
+// а не завершались ошибкой при конфликте
+func sqliteDSN(dsn string) (string, error) {
+	path, rawQuery, _ := strings.Cut(dsn, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", fmt.Errorf("некорректная строка подключения к SQLite: %w", err)
+	}
+
+	pragmas := make(map[string]bool)
+	for _, pragma := range query["_pragma"] {
+		name := strings.FieldsFunc(pragma, func(r rune) bool { return r == '(' || r == '=' })
+		if len(name) > 0 {
+			pragmas[strings.ToLower(strings.TrimSpace(name[0]))] = true
+		}
+	}
+
+	defaults := [][2]string{{"foreign_keys", "foreign_keys(1)"}, {"busy_timeout", "busy_timeout(5000)"}}
+	if !isSQLiteMemory(dsn) {
+		defaults = append(defaults, [2]string{"journal_mode", "journal_mode(WAL)"})
+	}
+	for _, pragma := range defaults {
+		if !pragmas[pragma[0]] {
+			query.Add("_pragma", pragma[1])
+		}
+	}
+	if query.Get("_txlock") == "" {
+		query.Set("_txlock", "immediate")
+	}
+
+	return path + "?" + query.Encode(), nil
+}
+
+// isSQLiteMemory проверяет, указывает ли строка подключения на базу в памяти
+func isSQLiteMemory(dsn string) bool {
+	return strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
+
+// sqliteNow возвращает текущее время для записи в SQLite.
+// Время хранится текстом в UTC, поэтому сравнение строк соответствует порядку времени.
+func sqliteNow() time.Time {
+	return time.Now().UTC()
+}
+
+// CreateSession создает новую сессию пользователя
+func (r *SQLiteRepository) CreateSession(ctx context.Context, userID uuid.UUID, refreshToken, refreshTokenID, userAgent, clientIP, kind string, binding models.TokenBinding, expiresAt int64, events ...models.Event) (int, error) {
+	ctx, end := r.startOperation(ctx, "CreateSession")
+	defer end()
+
+	var sessionID int
+	query := `
+	INSERT INTO sessions (user_id, refresh_token, refresh_token_id, user_agent, client_ip, expires_at, created_at, updated_at, cert_thumbprint, jwk_thumbprint, kind)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $8, $9, $10)
+	RETURNING id
+	`
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, query, userID, refreshToken, refreshTokenID, userAgent, clientIP, expiresAt, sqliteNow(), binding.CertThumbprint, binding.JWKThumbprint, kind).Scan(&sessionID); err != nil {
+			return err
+		}
+		return insertSQLiteEvents(ctx, tx, sessionID, events)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("не удалось создать сессию: %w", err)
+	}
+
+	return sessionID, nil
+}
+
+// GetSessionByRefreshToken возвращает сессию по хешу refresh токена
+func (r *SQLiteRepository) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*models.Session, error) {
+	ctx, end := r.startOperation(ctx, "GetSessionByRefreshToken")
+	defer end()
+
+	query := `
+	SELECT id, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at, refresh_token_id, created_at, cert_thumbprint, jwk_thumbprint, kind
+	FROM sessions
+	WHERE refresh_token = $1
+	`
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, refreshTokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения сессии: %w", err)
+	}
+
+	if session.IsBlocked {
+		return nil, ErrSessionRevoked
+	}
+	if session.ExpiresAt <= time.Now().Unix() {
+		return nil, ErrSessionExpired
+	}
+
+	return session, nil
+}
+
+// GetSession возвращает сессию по ID независимо от ее состояния
+func (r *SQLiteRepository) GetSession(ctx context.Context, sessionID int) (*models.Session, error) {
+	ctx, end := r.startOperation(ctx, "GetSession")
+	defer end()
+
+	query := `
+	SELECT id, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at, refresh_token_id, created_at, cert_thumbprint, jwk_thumbprint, kind
+	FROM sessions
+	WHERE id = $1
+	`
+
+	session, err := scanSession(r.db.QueryRowContext(ctx, query, sessionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения сессии: %w", err)
+	}
+
+	return session, nil
+}
+
+// RotateRefreshToken заменяет refresh токен сессии, если он не был заменен конкурирующим запросом
+func (r *SQLiteRepository) RotateRefreshToken(ctx context.Context, rotation models.RefreshTokenRotation, events ...models.Event) error {
+	ctx, end := r.startOperation(ctx, "RotateRefreshToken")
+	defer end()
+
+	// Транзакция начинается с блокировки записи, а условие на текущий токен
+	// не дает заменить уже замененный токен
+	query := `
+	UPDATE sessions
+	SET refresh_token = $1, refresh_token_id = $2, expires_at = $3, updated_at = $4
+	WHERE id = $5 AND refresh_token = $6 AND is_blocked = FALSE AND expires_at > $7
+	`
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		now := sqliteNow()
+		result, err := tx.ExecContext(ctx, query,
+			rotation.NewRefreshToken,
+			rotation.NewRefreshTokenID,
+			rotation.ExpiresAt,
+			now,
+			rotation.SessionID,
+			rotation.OldRefreshToken,
+			now.Unix(),
+		)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return rotationConflict(ctx, tx, rotation)
+		}
+
+		// Пары, выданные при предыдущих заменах, больше не возвращаются повторно
+		if _, err := tx.ExecContext(ctx, `UPDATE refresh_token_history SET successor = NULL WHERE session_id = $1 AND successor IS NOT NULL`, rotation.SessionID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO refresh_token_history (refresh_token, session_id, rotated_at, successor) VALUES ($1, $2, $3, $4)`, rotation.OldRefreshToken, rotation.SessionID, now, rotation.Successor); err != nil {
+			return err
+		}
+
+		return insertSQLiteEvents(ctx, tx, rotation.SessionID, events)
+	})
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenRotated) || errors.Is(err, ErrSessionRevoked) || errors.Is(err, ErrSessionExpired) || errors.Is(err, ErrSessionNotFound) {
+			return err
+		}
+		return fmt.Errorf("не удалось обновить сессию: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshTokenHistory возвращает запись о замененном refresh токене по его хешу
+func (r *SQLiteRepository) GetRefreshTokenHistory(ctx context.Context, refreshTokenHash string) (*models.RefreshTokenHistory, error) {
+	ctx, end := r.startOperation(ctx, "GetRefreshTokenHistory")
+	defer end()
+
+	query := `
+	SELECT refresh_token, session_id, rotated_at, successor
+	FROM refresh_token_history
+	WHERE refresh_token = $1
+	`
+
+	history := &models.RefreshTokenHistory{}
+	err := r.db.QueryRowContext(ctx, query, refreshTokenHash).Scan(
+		&history.RefreshToken,
+		&history.SessionID,
+		&history.RotatedAt,
+		&history.Successor,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения истории refresh токена: %w", err)
+	}
+
+	return history, nil
+}
+
+// BlockSession блокирует сессию
+func (r *SQLiteRepository) BlockSession(ctx context.Context, sessionID int, events ...models.Event) error {
+	ctx, end := r.startOperation(ctx, "BlockSession")
+	defer end()
+
+	query := `
+	UPDATE sessions
+	SET is_blocked = TRUE, updated_at = $1
+	WHERE id = $2
+	`
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, sqliteNow(), sessionID); err != nil {
+			return err
+		}
+		return insertSQLiteEvents(ctx, tx, sessionID, events)
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось заблокировать сессию: %w", err)
+	}
+
+	return nil
+}
+
+// BlockAllUserSessions блокирует все сессии пользователя
+func (r *SQLiteRepository) BlockAllUserSessions(ctx context.Context, userID uuid.UUID, events ...models.Event) error {
+	ctx, end := r.startOperation(ctx, "BlockAllUserSessions")
+	defer end()
+
+	query := `
+	UPDATE sessions
+	SET is_blocked = TRUE, updated_at = $1
+	WHERE user_id = $2
+	`
+
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, query, sqliteNow(), userID); err != nil {
+			return err
+		}
+		return insertSQLiteEvents(ctx, tx, 0, events)
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось заблокировать все сессии пользователя: %w", err)
+	}
+
+	return nil
+}
+
+// CountActiveSessions возвращает количество незаблокированных сессий с действующим refresh токеном
+func (r *SQLiteRepository) CountActiveSessions(ctx context.Context) (int, error) {
+	ctx, end := r.startOperation(ctx, "CountActiveSessions")
+	defer end()
+
+	var count int
+	query := `SELECT COUNT(*) FROM sessions WHERE is_blocked = FALSE AND expires_at > $1`
+	if err := r.db.QueryRowContext(ctx, query, time.Now().Unix()).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка подсчета активных сессий: %w", err)
+	}
+
+	return count, nil
+}
+
+// Ping проверяет доступность базы данных
+func (r *SQLiteRepository) Ping(ctx context.Context) error {
+	ctx, end := r.startOperation(ctx, "Ping")
+	defer end()
+
+	return r.db.PingContext(ctx)
+}
+
+// RegisterMetrics подключает метрики пула соединений и активных сессий
+func (r *SQLiteRepository) RegisterMetrics() error {
+	return metrics.RegisterDB(r.db, func() (int, error) {
+		return r.CountActiveSessions(context.Background())
+	})
+}
+
+// Close закрывает базу данных
+func (r *SQLiteRepository) Close() error {
+	return r.db.Close()
+}
+
+// startOperation начинает операцию репозитория и ограничивает ее время queryTimeout.
+// Возвращаемая функция должна быть вызвана по завершении операции.
+func (r *SQLiteRepository) startOperation(ctx context.Context, operation string) (context.Context, func()) {
+	ctx, end := r.observeOperation(ctx, operation)
+	return withQueryTimeout(ctx, r.queryTimeout, end)
+}
+
+// observeOperation начинает span операции без ограничения ее времени
+func (r *SQLiteRepository) observeOperation(ctx context.Context, operation string) (context.Context, func()) {
+	return observeOperation(ctx, semconv.DBSystemSqlite, "SQLiteRepository", operation)
+}
+
+// withTx выполняет fn в транзакции и фиксирует ее, если fn не вернула ошибку
+func (r *SQLiteRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// scanSession читает сессию из строки результата
+func scanSession(row rowScanner) (*models.Session, error) {
+	session := &models.Session{}
+	err := row.Scan(
+		&session.ID,
+		&session.UserID,
+		&session.RefreshToken,
+		&session.UserAgent,
+		&session.ClientIP,
+		&session.IsBlocked,
+		&session.ExpiresAt,
+		&session.RefreshTokenID,
+		&session.CreatedAt,
+		&session.Binding.CertThumbprint,
+		&session.Binding.JWKThumbprint,
+		&session.Kind,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
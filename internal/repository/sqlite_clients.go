@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// CreateClient создает сервисного клиента
+func (r *SQLiteRepository) CreateClient(ctx context.Context, client *models.Client) error {
+	ctx, end := r.startOperation(ctx, "CreateClient")
+	defer end()
+
+	scopes, err := json.Marshal(nonNilStrings(client.Scopes))
+	if err != nil {
+		return fmt.Errorf("не удалось создать клиента: %w", err)
+	}
+
+	query := `
+	INSERT INTO clients (id, name, secret_hash, scopes, disabled, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING created_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query, client.ID, client.Name, client.SecretHash, string(scopes), client.Disabled, sqliteNow()).Scan(&client.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("не удалось создать клиента: %w", err)
+	}
+
+	return nil
+}
+
+// GetClient возвращает сервисного клиента по ID
+func (r *SQLiteRepository) GetClient(ctx context.Context, id uuid.UUID) (*models.Client, error) {
+	ctx, end := r.startOperation(ctx, "GetClient")
+	defer end()
+
+	query := `SELECT ` + clientColumns + ` FROM clients WHERE id = $1`
+
+	client, err := scanSQLiteClient(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrClientNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения клиента: %w", err)
+	}
+
+	return client, nil
+}
+
+// ListClients возвращает всех сервисных клиентов в порядке создания
+func (r *SQLiteRepository) ListClients(ctx context.Context) ([]*models.Client, error) {
+	ctx, end := r.startOperation(ctx, "ListClients")
+	defer end()
+
+	query := `SELECT ` + clientColumns + ` FROM clients ORDER BY created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения клиентов: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []*models.Client
+	for rows.Next() {
+		client, err := scanSQLiteClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения клиента: %w", err)
+		}
+		clients = append(clients, client)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения клиентов: %w", err)
+	}
+
+	return clients, nil
+}
+
+// UpdateClient сохраняет название, секрет, области доступа и состояние клиента
+func (r *SQLiteRepository) UpdateClient(ctx context.Context, client *models.Client) error {
+	ctx, end := r.startOperation(ctx, "UpdateClient")
+	defer end()
+
+	scopes, err := json.Marshal(nonNilStrings(client.Scopes))
+	if err != nil {
+		return fmt.Errorf("не удалось обновить клиента: %w", err)
+	}
+
+	query := `
+	UPDATE clients
+	SET name = $1, secret_hash = $2, scopes = $3, disabled = $4
+	WHERE id = $5
+	RETURNING created_at
+	`
+
+	err = r.db.QueryRowContext(ctx, query, client.Name, client.SecretHash, string(scopes), client.Disabled, client.ID).Scan(&client.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrClientNotFound
+		}
+		return fmt.Errorf("не удалось обновить клиента: %w", err)
+	}
+
+	return nil
+}
+
+// scanSQLiteClient читает сервисного клиента из строки результата
+func scanSQLiteClient(row rowScanner) (*models.Client, error) {
+	client := &models.Client{}
+	var scopes string
+	err := row.Scan(
+		&client.ID,
+		&client.Name,
+		&client.SecretHash,
+		&scopes,
+		&client.Disabled,
+		&client.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(scopes), &client.Scopes); err != nil {
+		return nil, fmt.Errorf("ошибка разбора областей доступа клиента %s: %w", client.ID, err)
+	}
+	return client, nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// insertSQLiteEvents записывает события в журнал событий и журнал аудита в рамках транзакции.
+// sessionID — сессия, к которой относятся события, или 0, если она берется из данных события.
+func insertSQLiteEvents(ctx context.Context, tx *sql.Tx, sessionID int, events []models.Event) error {
+	query := `
+	INSERT INTO event_log (event_id, event_type, payload, created_at)
+	VALUES ($1, $2, $3, $4)
+	`
+
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("ошибка сериализации события: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, event.ID, event.Type, payload, sqliteNow()); err != nil {
+			return fmt.Errorf("не удалось записать событие в журнал: %w", err)
+		}
+		audit := newAuditEvent(event, sessionID)
+		audit.OccurredAt = audit.OccurredAt.UTC()
+		if err := insertAuditEvent(ctx, tx, audit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AppendEvents записывает в журнал события, не связанные с изменением сессии
+func (r *SQLiteRepository) AppendEvents(ctx context.Context, events ...models.Event) error {
+	ctx, end := r.startOperation(ctx, "AppendEvents")
+	defer end()
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		return insertSQLiteEvents(ctx, tx, 0, events)
+	})
+}
+
+// ClaimEvents захватывает неопубликованные события
+func (r *SQLiteRepository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]*models.EventRecord, error) {
+	ctx, end := r.startOperation(ctx, "ClaimEvents")
+	defer end()
+
+	// Запрос выполняется под блокировкой записи базы, поэтому события
+	// не могут быть захвачены двумя обработчиками одновременно
+	query := `
+	UPDATE event_log
+	SET locked_until = $3
+	WHERE id IN (
+		SELECT id FROM event_log
+		WHERE published_at IS NULL
+			AND (locked_until IS NULL OR locked_until <= $2)
+		ORDER BY id
+		LIMIT $1
+	)
+	RETURNING id, payload
+	`
+
+	now := sqliteNow()
+	rows, err := r.db.QueryContext(ctx, query, limit, now, now.Add(lease))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось захватить события: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*models.EventRecord
+	for rows.Next() {
+		record := &models.EventRecord{}
+		var payload []byte
+		if err := rows.Scan(&record.ID, &payload); err != nil {
+			return nil, fmt.Errorf("ошибка чтения события: %w", err)
+		}
+		if err := json.Unmarshal(payload, &record.Event); err != nil {
+			return nil, fmt.Errorf("ошибка разбора события %d: %w", record.ID, err)
+		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения событий: %w", err)
+	}
+
+	// RETURNING не гарантирует порядок строк
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	return records, nil
+}
+
+// MarkEventsPublished отмечает события как опубликованные
+func (r *SQLiteRepository) MarkEventsPublished(ctx context.Context, ids ...int64) error {
+	ctx, end := r.startOperation(ctx, "MarkEventsPublished")
+	defer end()
+
+	query := `
+	UPDATE event_log
+	SET published_at = $1, locked_until = NULL
+	WHERE id IN (SELECT value FROM json_each($2))
+	`
+
+	encoded, err := json.Marshal(ids)
+	if err != nil {
+		return fmt.Errorf("не удалось отметить публикацию событий: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, query, sqliteNow(), string(encoded)); err != nil {
+		return fmt.Errorf("не удалось отметить публикацию событий: %w", err)
+	}
+
+	return nil
+}
+
+// ListAuditEvents возвращает записи аудита по фильтру, начиная с самых новых
+func (r *SQLiteRepository) ListAuditEvents(ctx context.Context, filter models.AuditEventFilter) ([]*models.AuditEvent, error) {
+	ctx, end := r.startOperation(ctx, "ListAuditEvents")
+	defer end()
+
+	rows, err := r.queryAuditEvents(ctx, filter, "DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.AuditEvent
+	err = scanAuditEvents(rows, func(event *models.AuditEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ExportAuditEvents передает в fn записи аудита по фильтру в порядке их добавления
+func (r *SQLiteRepository) ExportAuditEvents(ctx context.Context, filter models.AuditEventFilter, fn func(event *models.AuditEvent) error) error {
+	// Выгрузка может длиться дольше queryTimeout, поэтому ее ограничивает только ctx запроса
+	ctx, end := r.observeOperation(ctx, "ExportAuditEvents")
+	defer end()
+
+	rows, err := r.queryAuditEvents(ctx, filter, "ASC")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return scanAuditEvents(rows, fn)
+}
+
+// queryAuditEvents выполняет поиск по журналу аудита с указанным порядком сортировки по ID
+func (r *SQLiteRepository) queryAuditEvents(ctx context.Context, filter models.AuditEventFilter, order string) (*sql.Rows, error) {
+	query := `
+	SELECT ` + auditEventColumns + `
+	FROM audit_events
+	WHERE ($1 = '' OR actor_id = $1 OR subject_id = $1)
+		AND ($2 = '' OR event_type = $2)
+		AND ($3 IS NULL OR occurred_at >= $3)
+		AND ($4 IS NULL OR occurred_at < $4)
+	ORDER BY id ` + order + `
+	LIMIT CASE WHEN $5 > 0 THEN $5 ELSE -1 END OFFSET $6
+	`
+
+	var from, to sql.NullTime
+	if !filter.From.IsZero() {
+		from = sql.NullTime{Time: filter.From.UTC(), Valid: true}
+	}
+	if !filter.To.IsZero() {
+		to = sql.NullTime{Time: filter.To.UTC(), Valid: true}
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, filter.UserID, filter.EventType, from, to, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска по журналу аудита: %w", err)
+	}
+
+	return rows, nil
+}
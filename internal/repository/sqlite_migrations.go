@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+)
+
+// sqliteMigrations миграции схемы SQLite.
+// SQLite не поддерживает advisory-блокировки, поэтому lock ничего не блокирует: если
+// миграции одновременно запустят несколько процессов, у одного из них миграция завершится
+// ошибкой и будет откатана. Ограничение описано в справке подкоманды migrate.
+var sqliteMigrations = migrationDialect{
+	dir: "migrations/sqlite",
+	createTable: `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)
+	`,
+	tableExists: `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')`,
+	lock: func(ctx context.Context, conn *sql.Conn) (func(), error) {
+		return func() {}, nil
+	},
+}
+
+// SQLiteRepository поддерживает версионируемую схему
+var _ Migrator = (*SQLiteRepository)(nil)
+
+// Migrate применяет все еще не примененные миграции
+func (r *SQLiteRepository) Migrate(ctx context.Context) error {
+	return migrate(ctx, r.db, sqliteMigrations)
+}
+
+// MigrateTo приводит схему к версии version: применяет миграции до нее включительно
+// и откатывает примененные миграции с большей версией. Версия 0 откатывает все миграции.
+func (r *SQLiteRepository) MigrateTo(ctx context.Context, version int) error {
+	return migrateTo(ctx, r.db, sqliteMigrations, version)
+}
+
+// MigrationStatus возвращает состояние всех встроенных миграций
+func (r *SQLiteRepository) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	return migrationStatus(ctx, r.db, sqliteMigrations)
+}
+
+// CheckSchema проверяет, что применены все миграции, известные этой версии сервиса
+func (r *SQLiteRepository) CheckSchema(ctx context.Context) error {
+	ctx, end := r.startOperation(ctx, "CheckSchema")
+	defer end()
+
+	return checkSchema(ctx, r.db, sqliteMigrations)
+}
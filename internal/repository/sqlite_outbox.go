@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EnqueueWebhookEvents сохраняет события в outbox:
+// для каждой включенной подписки на тип события создается отдельное сообщение
+func (r *SQLiteRepository) EnqueueWebhookEvents(ctx context.Context, events ...models.Event) error {
+	ctx, end := r.startOperation(ctx, "EnqueueWebhookEvents")
+	defer end()
+
+	query := `
+	INSERT INTO webhook_outbox (subscription_id, event_id, event_type, payload, next_attempt_at, created_at)
+	SELECT id, $1, $2, $3, $4, $4
+	FROM webhook_subscriptions
+	WHERE enabled AND EXISTS (SELECT 1 FROM json_each(event_types) WHERE value IN ($2, '*'))
+	`
+
+	return r.withTx(ctx, func(tx *sql.Tx) error {
+		for _, event := range events {
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("ошибка сериализации события: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx, query, event.ID, event.Type, payload, sqliteNow()); err != nil {
+				return fmt.Errorf("не удалось сохранить событие webhook: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ClaimWebhookMessages захватывает готовые к отправке сообщения
+func (r *SQLiteRepository) ClaimWebhookMessages(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookMessage, error) {
+	ctx, end := r.startOperation(ctx, "ClaimWebhookMessages")
+	defer end()
+
+	// Запрос выполняется под блокировкой записи базы, поэтому сообщение
+	// не может быть захвачено двумя обработчиками одновременно
+	query := `
+	UPDATE webhook_outbox
+	SET attempts = attempts + 1, locked_until = $3
+	WHERE id IN (
+		SELECT id FROM webhook_outbox
+		WHERE status = 'pending'
+			AND next_attempt_at <= $2
+			AND (locked_until IS NULL OR locked_until <= $2)
+		ORDER BY next_attempt_at, id
+		LIMIT $1
+	)
+	RETURNING ` + webhookMessageColumns
+
+	now := sqliteNow()
+	rows, err := r.db.QueryContext(ctx, query, limit, now, now.Add(lease))
+	if err != nil {
+		return nil, fmt.Errorf("не удалось захватить сообщения webhook: %w", err)
+	}
+	defer rows.Close()
+
+	return scanWebhookMessages(rows)
+}
+
+// MarkWebhookDelivered отмечает сообщение как доставленное
+func (r *SQLiteRepository) MarkWebhookDelivered(ctx context.Context, id int64) error {
+	ctx, end := r.startOperation(ctx, "MarkWebhookDelivered")
+	defer end()
+
+	query := `
+	UPDATE webhook_outbox
+	SET status = 'delivered', delivered_at = $1, locked_until = NULL, last_error = ''
+	WHERE id = $2
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, sqliteNow(), id); err != nil {
+		return fmt.Errorf("не удалось отметить доставку webhook: %w", err)
+	}
+
+	return nil
+}
+
+// MarkWebhookFailed сохраняет ошибку доставки и планирует повторную попытку
+func (r *SQLiteRepository) MarkWebhookFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error {
+	ctx, end := r.startOperation(ctx, "MarkWebhookFailed")
+	defer end()
+
+	status := models.WebhookStatusPending
+	if dead {
+		status = models.WebhookStatusDead
+	}
+
+	query := `
+	UPDATE webhook_outbox
+	SET status = $1, last_error = $2, next_attempt_at = $3, locked_until = NULL
+	WHERE id = $4
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, status, lastError, nextAttemptAt.UTC(), id); err != nil {
+		return fmt.Errorf("не удалось сохранить ошибку доставки webhook: %w", err)
+	}
+
+	return nil
+}
+
+// RetryWebhookMessage возвращает сообщение в очередь для немедленной отправки
+func (r *SQLiteRepository) RetryWebhookMessage(ctx context.Context, id int64) error {
+	ctx, end := r.startOperation(ctx, "RetryWebhookMessage")
+	defer end()
+
+	query := `
+	UPDATE webhook_outbox
+	SET status = 'pending', attempts = 0, next_attempt_at = $1, locked_until = NULL
+	WHERE id = $2 AND status <> 'delivered'
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sqliteNow(), id)
+	if err != nil {
+		return fmt.Errorf("не удалось повторно поставить webhook в очередь: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось повторно поставить webhook в очередь: %w", err)
+	}
+	if affected == 0 {
+		return ErrWebhookMessageNotFound
+	}
+
+	return nil
+}
+
+// GetWebhookMessage возвращает сообщение по ID
+func (r *SQLiteRepository) GetWebhookMessage(ctx context.Context, id int64) (*models.WebhookMessage, error) {
+	ctx, end := r.startOperation(ctx, "GetWebhookMessage")
+	defer end()
+
+	query := `SELECT ` + webhookMessageColumns + ` FROM webhook_outbox WHERE id = $1`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сообщения webhook: %w", err)
+	}
+	defer rows.Close()
+
+	messages, err := scanWebhookMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, ErrWebhookMessageNotFound
+	}
+
+	return messages[0], nil
+}
+
+// ListWebhookMessages возвращает сообщения, удовлетворяющие фильтру
+func (r *SQLiteRepository) ListWebhookMessages(ctx context.Context, filter models.WebhookMessageFilter) ([]*models.WebhookMessage, error) {
+	ctx, end := r.startOperation(ctx, "ListWebhookMessages")
+	defer end()
+
+	query := `
+	SELECT ` + webhookMessageColumns + `
+	FROM webhook_outbox
+	WHERE ($1 = '' OR status = $1) AND ($2 = 0 OR subscription_id = $2)
+	ORDER BY id DESC
+	LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.Status, filter.SubscriptionID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сообщений webhook: %w", err)
+	}
+	defer rows.Close()
+
+	return scanWebhookMessages(rows)
+}
+
+// CountWebhookBacklog возвращает количество сообщений, которые должны были быть отправлены
+// раньше, чем olderThan назад, но все еще ожидают доставки
+func (r *SQLiteRepository) CountWebhookBacklog(ctx context.Context, olderThan time.Duration) (int, error) {
+	ctx, end := r.startOperation(ctx, "CountWebhookBacklog")
+	defer end()
+
+	query := `SELECT COUNT(*) FROM webhook_outbox WHERE status = 'pending' AND next_attempt_at <= $1`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, sqliteNow().Add(-olderThan)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка подсчета очереди webhook: %w", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// BlockSessionsCreatedBefore блокирует все незаблокированные сессии, созданные раньше before
+func (r *SQLiteRepository) BlockSessionsCreatedBefore(ctx context.Context, before time.Time, events ...models.Event) (int, error) {
+	ctx, end := r.startOperation(ctx, "BlockSessionsCreatedBefore")
+	defer end()
+
+	query := `
+	UPDATE sessions
+	SET is_blocked = TRUE, updated_at = $1
+	WHERE NOT is_blocked AND created_at < $2
+	`
+
+	var blocked int64
+	err := r.withTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, query, sqliteNow(), before.UTC())
+		if err != nil {
+			return err
+		}
+		if blocked, err = result.RowsAffected(); err != nil {
+			return err
+		}
+		return insertSQLiteEvents(ctx, tx, 0, events)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("не удалось заблокировать сессии: %w", err)
+	}
+
+	return int(blocked), nil
+}
+
+// ListSessions возвращает сессии по фильтру, начиная с самых новых
+func (r *SQLiteRepository) ListSessions(ctx context.Context, filter models.SessionFilter) ([]*models.Session, error) {
+	ctx, end := r.startOperation(ctx, "ListSessions")
+	defer end()
+
+	query := `
+	SELECT id, user_id, refresh_token, user_agent, client_ip, is_blocked, expires_at, refresh_token_id, created_at, cert_thumbprint, jwk_thumbprint, kind
+	FROM sessions
+	WHERE ($1 IS NULL OR user_id = $1)
+		AND (NOT $2 OR (NOT is_blocked AND expires_at > $3))
+	ORDER BY id DESC
+	LIMIT CASE WHEN $4 > 0 THEN $4 ELSE -1 END OFFSET $5
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, sessionUserFilter(filter.UserID), filter.ActiveOnly, time.Now().Unix(), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сессий: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSessions(rows)
+}
+
+// ListBlockedSessions возвращает сессии, заблокированные не раньше since
+func (r *SQLiteRepository) ListBlockedSessions(ctx context.Context, since time.Time) ([]models.BlockedSession, error) {
+	ctx, end := r.startOperation(ctx, "ListBlockedSessions")
+	defer end()
+
+	query := `SELECT id, user_id, updated_at FROM sessions WHERE is_blocked AND updated_at >= $1 ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения заблокированных сессий: %w", err)
+	}
+	defer rows.Close()
+
+	return scanBlockedSessions(rows)
+}
+
+// DeleteStaleSessions удаляет истекшие и заблокированные раньше before сессии.
+// История refresh токенов удаляется каскадно.
+func (r *SQLiteRepository) DeleteStaleSessions(ctx context.Context, before time.Time, limit int) (int, error) {
+	ctx, end := r.startOperation(ctx, "DeleteStaleSessions")
+	defer end()
+
+	query := `
+	DELETE FROM sessions
+	WHERE id IN (
+		SELECT id FROM sessions
+		WHERE expires_at < $1 OR (is_blocked AND updated_at < $2)
+		LIMIT $3
+	)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, before.Unix(), before.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось удалить устаревшие сессии: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("не удалось удалить устаревшие сессии: %w", err)
+	}
+
+	return int(deleted), nil
+}
+
+// scanSessions читает сессии из результата запроса
+func scanSessions(rows *sql.Rows) ([]*models.Session, error) {
+	var sessions []*models.Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения сессии: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения сессий: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// scanBlockedSessions читает заблокированные сессии из результата запроса
+func scanBlockedSessions(rows *sql.Rows) ([]models.BlockedSession, error) {
+	var blocked []models.BlockedSession
+	for rows.Next() {
+		var session models.BlockedSession
+		if err := rows.Scan(&session.SessionID, &session.UserID, &session.BlockedAt); err != nil {
+			return nil, fmt.Errorf("ошибка чтения заблокированной сессии: %w", err)
+		}
+		blocked = append(blocked, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения заблокированных сессий: %w", err)
+	}
+
+	return blocked, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// CreateWebhookSubscription создает подписку на webhook
+func (r *SQLiteRepository) CreateWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	ctx, end := r.startOperation(ctx, "CreateWebhookSubscription")
+	defer end()
+
+	query := `
+	INSERT INTO webhook_subscriptions (url, description, event_types, secrets, enabled, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $6)
+	RETURNING id, created_at, updated_at
+	`
+
+	eventTypes, secrets, err := encodeSubscriptionLists(subscription)
+	if err != nil {
+		return fmt.Errorf("не удалось создать подписку на webhook: %w", err)
+	}
+
+	err = r.db.QueryRowContext(ctx, query,
+		subscription.URL,
+		subscription.Description,
+		eventTypes,
+		secrets,
+		subscription.Enabled,
+		sqliteNow(),
+	).Scan(&subscription.ID, &subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("не удалось создать подписку на webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetWebhookSubscription возвращает подписку по ID
+func (r *SQLiteRepository) GetWebhookSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	ctx, end := r.startOperation(ctx, "GetWebhookSubscription")
+	defer end()
+
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	subscription, err := scanSQLiteWebhookSubscription(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookSubscriptionNotFound
+		}
+		return nil, fmt.Errorf("ошибка получения подписки на webhook: %w", err)
+	}
+
+	return subscription, nil
+}
+
+// ListWebhookSubscriptions возвращает все подписки
+func (r *SQLiteRepository) ListWebhookSubscriptions(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	ctx, end := r.startOperation(ctx, "ListWebhookSubscriptions")
+	defer end()
+
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions ORDER BY id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения подписок на webhook: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.WebhookSubscription
+	for rows.Next() {
+		subscription, err := scanSQLiteWebhookSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения подписки на webhook: %w", err)
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения подписок на webhook: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// UpdateWebhookSubscription сохраняет изменения подписки
+func (r *SQLiteRepository) UpdateWebhookSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	ctx, end := r.startOperation(ctx, "UpdateWebhookSubscription")
+	defer end()
+
+	query := `
+	UPDATE webhook_subscriptions
+	SET url = $1, description = $2, event_types = $3, secrets = $4, enabled = $5, updated_at = $6
+	WHERE id = $7
+	RETURNING updated_at
+	`
+
+	eventTypes, secrets, err := encodeSubscriptionLists(subscription)
+	if err != nil {
+		return fmt.Errorf("не удалось обновить подписку на webhook: %w", err)
+	}
+
+	err = r.db.QueryRowContext(ctx, query,
+		subscription.URL,
+		subscription.Description,
+		eventTypes,
+		secrets,
+		subscription.Enabled,
+		sqliteNow(),
+		subscription.ID,
+	).Scan(&subscription.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebhookSubscriptionNotFound
+		}
+		return fmt.Errorf("не удалось обновить подписку на webhook: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteWebhookSubscription удаляет подписку вместе с ее сообщениями в outbox
+func (r *SQLiteRepository) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	ctx, end := r.startOperation(ctx, "DeleteWebhookSubscription")
+	defer end()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("не удалось удалить подписку на webhook: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("не удалось удалить подписку на webhook: %w", err)
+	}
+	if affected == 0 {
+		return ErrWebhookSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// encodeSubscriptionLists кодирует типы событий и секреты подписки в JSON-массивы
+func encodeSubscriptionLists(subscription *models.WebhookSubscription) (string, string, error) {
+	eventTypes, err := json.Marshal(nonNilStrings(subscription.EventTypes))
+	if err != nil {
+		return "", "", err
+	}
+	secrets, err := json.Marshal(nonNilStrings(subscription.Secrets))
+	if err != nil {
+		return "", "", err
+	}
+	return string(eventTypes), string(secrets), nil
+}
+
+// scanSQLiteWebhookSubscription читает подписку из строки результата
+func scanSQLiteWebhookSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+	subscription := &models.WebhookSubscription{}
+	var eventTypes, secrets string
+	err := row.Scan(
+		&subscription.ID,
+		&subscription.URL,
+		&subscription.Description,
+		&eventTypes,
+		&secrets,
+		&subscription.Enabled,
+		&subscription.CreatedAt,
+		&subscription.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(eventTypes), &subscription.EventTypes); err != nil {
+		return nil, fmt.Errorf("ошибка разбора типов событий подписки %d: %w", subscription.ID, err)
+	}
+	if err := json.Unmarshal([]byte(secrets), &subscription.Secrets); err != nil {
+		return nil, fmt.Errorf("ошибка разбора секретов подписки %d: %w", subscription.ID, err)
+	}
+	return subscription, nil
+}
@@ -0,0 +1,86 @@
+package repository_test
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/repository"
+	"auth-service/internal/repository/repotest"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteRepository(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repository.Repository {
+		repo, err := repository.NewSQLiteRepository(config.DatabaseConfig{
+			DSN: filepath.Join(t.TempDir(), "auth.db"),
+		})
+		if err != nil {
+			t.Fatalf("NewSQLiteRepository: %v", err)
+		}
+		if err := repo.Migrate(context.Background()); err != nil {
+			_ = repo.Close()
+			t.Fatalf("Migrate: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewSQLiteRepository(config.DatabaseConfig{
+		DSN: filepath.Join(t.TempDir(), "auth.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewSQLiteRepository: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+
+	// applied проверяет, что применены ровно первые count миграций
+	applied := func(t *testing.T, count int) {
+		t.Helper()
+		statuses, err := repo.MigrationStatus(ctx)
+		if err != nil {
+			t.Fatalf("MigrationStatus: %v", err)
+		}
+		if len(statuses) < 2 {
+			t.Fatalf("миграций %d, ожидалось не меньше двух", len(statuses))
+		}
+		for i, status := range statuses {
+			if (status.AppliedAt != nil) != (i < count) {
+				t.Fatalf("миграция %d_%s: применена %t, ожидалось %t", status.Version, status.Name, status.AppliedAt != nil, i < count)
+			}
+		}
+		if err := repo.CheckSchema(ctx); (err == nil) != (count == len(statuses)) {
+			t.Fatalf("CheckSchema при %d примененных миграциях из %d: %v", count, len(statuses), err)
+		}
+	}
+
+	statuses, err := repo.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatalf("MigrationStatus: %v", err)
+	}
+	all := len(statuses)
+	applied(t, 0)
+
+	steps := []struct {
+		name    string
+		migrate func() error
+		applied int
+	}{
+		{name: "up", migrate: func() error { return repo.Migrate(ctx) }, applied: all},
+		{name: "goto первой версии", migrate: func() error { return repo.MigrateTo(ctx, statuses[0].Version) }, applied: 1},
+		{name: "goto 0", migrate: func() error { return repo.MigrateTo(ctx, 0) }, applied: 0},
+		{name: "повторный up", migrate: func() error { return repo.Migrate(ctx) }, applied: all},
+		{name: "up без новых миграций", migrate: func() error { return repo.Migrate(ctx) }, applied: all},
+	}
+	for _, step := range steps {
+		if err := step.migrate(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		applied(t, step.applied)
+	}
+
+	if err := repo.MigrateTo(ctx, statuses[all-1].Version+1); err == nil {
+		t.Fatal("MigrateTo неизвестной версии выполнен без ошибки")
+	}
+}
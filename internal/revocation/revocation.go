@@ -0,0 +1,313 @@
+package revocation
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// clockSkew запас на расхождение часов сервиса и базы данных при сравнении времени блокировки
+	clockSkew = time.Minute
+	// notifyBatchSize количество сессий в одном уведомлении, чтобы не превысить ограничение NOTIFY в 8000 байт
+	notifyBatchSize = 500
+)
+
+// ErrNotificationsUnsupported возвращается, если хранилище не доставляет уведомления между экземплярами
+var ErrNotificationsUnsupported = errors.New("хранилище не поддерживает уведомления между экземплярами")
+
+// message уведомление о блокировке сессий или о ротации ключей подписи
+type message struct {
+	Sessions []int `json:"sessions,omitempty"`
+	// KeysRotated сообщает, что ключи подписи изменены и конфигурацию нужно перечитать
+	KeysRotated bool `json:"keys_rotated,omitempty"`
+}
+
+// Repository хранит в памяти процесса сессии, заблокированные за время жизни access токена,
+// и отзывает их access токены. Блокировки, выполненные через Repository, сразу распространяются
+// на другие экземпляры сервиса через уведомления хранилища; пропущенные уведомления
+// восполняются полной загрузкой заблокированных сессий из хранилища.
+type Repository struct {
+	repository.Repository
+	// broadcaster доставляет уведомления между экземплярами; nil, если хранилище их не поддерживает
+	broadcaster repository.Broadcaster
+	channel     string
+	// ttl время жизни access токена в наносекундах, в течение которого отзыв сессии действует
+	ttl            atomic.Int64
+	resyncInterval time.Duration
+
+	mu sync.RWMutex
+	// revoked время, до которого отозваны access токены сессии
+	revoked map[int]time.Time
+	// keysRotated перечитывает ключи подписи по уведомлению о ротации; nil, если не задан
+	keysRotated func()
+}
+
+// Repository учитывает отзыв access токенов
+var _ repository.RevocationChecker = (*Repository)(nil)
+
+// NewRepository создает список заблокированных сессий перед хранилищем repo
+func NewRepository(repo repository.Repository, cfg config.RevocationConfig, jwt config.JWTConfig) *Repository {
+	r := &Repository{
+		Repository:     repo,
+		channel:        cfg.NotifyChannel,
+		resyncInterval: cfg.ResyncInterval,
+		revoked:        make(map[int]time.Time),
+	}
+	r.ttl.Store(int64(jwt.AccessExpiry))
+	if broadcaster, ok := repo.(repository.Broadcaster); ok && cfg.NotifyChannel != "" {
+		r.broadcaster = broadcaster
+	}
+	return r
+}
+
+// SetJWTConfig применяет новое время жизни access токенов после перезагрузки конфигурации.
+// Время хранения отзывов только увеличивается: токены, выпущенные до перезагрузки,
+// действуют прежний срок.
+func (r *Repository) SetJWTConfig(jwt config.JWTConfig) {
+	for {
+		ttl := r.ttl.Load()
+		if int64(jwt.AccessExpiry) <= ttl || r.ttl.CompareAndSwap(ttl, int64(jwt.AccessExpiry)) {
+			return
+		}
+	}
+}
+
+// OnKeysRotated задает обработчик уведомления о ротации ключей подписи, который перечитывает
+// конфигурацию. Вызывается до Run.
+func (r *Repository) OnKeysRotated(handler func()) {
+	r.keysRotated = handler
+}
+
+// NotifyKeysRotated уведомляет запущенные экземпляры о ротации ключей подписи: они перечитывают
+// конфигурацию и заново загружают заблокированные сессии
+func (r *Repository) NotifyKeysRotated(ctx context.Context) error {
+	if r.broadcaster == nil {
+		return ErrNotificationsUnsupported
+	}
+	payload, err := json.Marshal(message{KeysRotated: true})
+	if err != nil {
+		return err
+	}
+	return r.broadcaster.Notify(ctx, r.channel, string(payload))
+}
+
+// BlockSession блокирует сессию, отзывает ее access токены и уведомляет другие экземпляры
+func (r *Repository) BlockSession(ctx context.Context, sessionID int, events ...models.Event) error {
+	if err := r.Repository.BlockSession(ctx, sessionID, events...); err != nil {
+		return err
+	}
+
+	r.revoke(time.Now(), sessionID)
+	// Блокировка уже сохранена, поэтому уведомление не должно прерываться отменой запроса
+	r.publish(context.WithoutCancel(ctx), sessionID)
+
+	return nil
+}
+
+// BlockAllUserSessions блокирует все сессии пользователя, отзывает их access токены
+// и уведомляет другие экземпляры
+func (r *Repository) BlockAllUserSessions(ctx context.Context, userID uuid.UUID, events ...models.Event) error {
+	startedAt := time.Now()
+	if err := r.Repository.BlockAllUserSessions(ctx, userID, events...); err != nil {
+		return err
+	}
+
+	// Блокировка уже сохранена, поэтому отзыв не должен прерываться отменой запроса
+	r.revokeBlockedSince(context.WithoutCancel(ctx), startedAt, func(session models.BlockedSession) bool {
+		return session.UserID == userID
+	})
+
+	return nil
+}
+
+// BlockSessionsCreatedBefore блокирует сессии, созданные раньше before, отзывает их access токены
+// и уведомляет другие экземпляры
+func (r *Repository) BlockSessionsCreatedBefore(ctx context.Context, before time.Time, events ...models.Event) (int, error) {
+	startedAt := time.Now()
+	blocked, err := r.Repository.BlockSessionsCreatedBefore(ctx, before, events...)
+	if err != nil || blocked == 0 {
+		return blocked, err
+	}
+
+	// Вместе с ними могут попасть сессии, заблокированные одновременно по другой причине:
+	// их повторный отзыв ничего не меняет
+	r.revokeBlockedSince(context.WithoutCancel(ctx), startedAt, func(models.BlockedSession) bool {
+		return true
+	})
+
+	return blocked, nil
+}
+
+// revokeBlockedSince отзывает access токены сессий, заблокированных после startedAt
+// и удовлетворяющих match, и уведомляет о них другие экземпляры
+func (r *Repository) revokeBlockedSince(ctx context.Context, startedAt time.Time, match func(session models.BlockedSession) bool) {
+	blocked, err := r.Repository.ListBlockedSessions(ctx, startedAt.Add(-clockSkew))
+	if err != nil {
+		// Сессии будут отозваны при следующей полной загрузке
+		slog.WarnContext(ctx, "Ошибка получения заблокированных сессий", slog.Any("error", err))
+		return
+	}
+
+	var sessionIDs []int
+	for _, session := range blocked {
+		if match(session) {
+			sessionIDs = append(sessionIDs, session.SessionID)
+		}
+	}
+	r.revoke(time.Now(), sessionIDs...)
+	r.publish(ctx, sessionIDs...)
+}
+
+// IsAccessTokenRevoked сообщает, заблокирована ли сессия access токена
+func (r *Repository) IsAccessTokenRevoked(ctx context.Context, userID uuid.UUID, sessionID int, tokenID string, issuedAt time.Time) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	until, ok := r.revoked[sessionID]
+	return ok && time.Now().Before(until), nil
+}
+
+// Resync загружает из хранилища сессии, заблокированные за время жизни access токена
+func (r *Repository) Resync(ctx context.Context) error {
+	ttl := time.Duration(r.ttl.Load())
+	blocked, err := r.Repository.ListBlockedSessions(ctx, time.Now().Add(-ttl-clockSkew))
+	if err != nil {
+		return err
+	}
+
+	// Блокировка необратима, поэтому загруженные сессии только дополняют список
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, session := range blocked {
+		until := session.BlockedAt.Add(ttl + clockSkew)
+		if until.After(r.revoked[session.SessionID]) {
+			r.revoked[session.SessionID] = until
+		}
+	}
+	r.pruneLocked(time.Now())
+
+	return nil
+}
+
+// Run получает уведомления других экземпляров и периодически загружает
+// заблокированные сессии из хранилища до отмены ctx
+func (r *Repository) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	if r.broadcaster != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.listen(ctx)
+		}()
+	}
+	defer wg.Wait()
+
+	ticker := time.NewTicker(r.resyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.resync(ctx)
+		}
+	}
+}
+
+// listen получает уведомления и повторяет подписку после ошибки до отмены ctx
+func (r *Repository) listen(ctx context.Context) {
+	for {
+		err := r.broadcaster.Subscribe(ctx, r.channel, func(payload string) { r.handle(ctx, payload) }, func() { r.resync(ctx) })
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		slog.ErrorContext(ctx, "Ошибка подписки на уведомления о блокировках", slog.String("channel", r.channel), slog.Any("error", err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.resyncInterval):
+		}
+	}
+}
+
+// handle отзывает access токены сессий из уведомления другого экземпляра или перечитывает
+// ключи подписи после их ротации
+func (r *Repository) handle(ctx context.Context, payload string) {
+	var msg message
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		slog.WarnContext(ctx, "Некорректное уведомление о блокировке сессий", slog.String("payload", payload), slog.Any("error", err))
+		return
+	}
+	r.revoke(time.Now(), msg.Sessions...)
+
+	if msg.KeysRotated {
+		slog.InfoContext(ctx, "Получено уведомление о ротации ключей подписи")
+		if r.keysRotated != nil {
+			r.keysRotated()
+		}
+		// Вместе с ротацией могло измениться время жизни access токенов, а сессии, подписанные
+		// скомпрометированным ключом, обычно блокируются, поэтому список загружается заново
+		r.resync(ctx)
+	}
+}
+
+// resync выполняет полную загрузку и записывает ошибку в лог
+func (r *Repository) resync(ctx context.Context) {
+	if err := r.Resync(ctx); err != nil && ctx.Err() == nil {
+		slog.ErrorContext(ctx, "Ошибка загрузки заблокированных сессий", slog.Any("error", err))
+	}
+}
+
+// revoke отзывает access токены сессий, заблокированных в момент at
+func (r *Repository) revoke(at time.Time, sessionIDs ...int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	until := at.Add(time.Duration(r.ttl.Load()))
+	for _, id := range sessionIDs {
+		if until.After(r.revoked[id]) {
+			r.revoked[id] = until
+		}
+	}
+	r.pruneLocked(at)
+}
+
+// pruneLocked удаляет сессии, access токены которых уже истекли; вызывается под r.mu
+func (r *Repository) pruneLocked(now time.Time) {
+	for id, until := range r.revoked {
+		if !now.Before(until) {
+			delete(r.revoked, id)
+		}
+	}
+}
+
+// publish уведомляет другие экземпляры о блокировке сессий.
+// Ошибка отправки только записывается в лог: другие экземпляры получат блокировку при полной загрузке.
+func (r *Repository) publish(ctx context.Context, sessionIDs ...int) {
+	if r.broadcaster == nil {
+		return
+	}
+
+	for start := 0; start < len(sessionIDs); start += notifyBatchSize {
+		end := min(start+notifyBatchSize, len(sessionIDs))
+		payload, err := json.Marshal(message{Sessions: sessionIDs[start:end]})
+		if err == nil {
+			err = r.broadcaster.Notify(ctx, r.channel, string(payload))
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "Ошибка отправки уведомления о блокировке сессий", slog.String("channel", r.channel), slog.Any("error", err))
+			return
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package revocation
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// broadcastRepository доставляет уведомления подписчикам в процессе теста
+type broadcastRepository struct {
+	repository.Repository
+
+	mu       sync.Mutex
+	handlers []func(payload string)
+}
+
+func (r *broadcastRepository) Notify(ctx context.Context, channel, payload string) error {
+	r.mu.Lock()
+	handlers := append([]func(string){}, r.handlers...)
+	r.mu.Unlock()
+	for _, handle := range handlers {
+		handle(payload)
+	}
+	return nil
+}
+
+func (r *broadcastRepository) Subscribe(ctx context.Context, channel string, handle func(payload string), resync func()) error {
+	r.mu.Lock()
+	r.handlers = append(r.handlers, handle)
+	r.mu.Unlock()
+	resync()
+	<-ctx.Done()
+	return nil
+}
+
+// subscribers возвращает число подписчиков
+func (r *broadcastRepository) subscribers() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.handlers)
+}
+
+func TestNotifyKeysRotated(t *testing.T) {
+	store := &broadcastRepository{Repository: repository.NewMemoryRepository()}
+	cfg := config.RevocationConfig{NotifyChannel: "test", ResyncInterval: time.Hour}
+	jwt := config.JWTConfig{AccessExpiry: 15 * time.Minute}
+	sender, receiver := NewRepository(store, cfg, jwt), NewRepository(store, cfg, jwt)
+
+	reloaded := make(chan struct{}, 1)
+	receiver.OnKeysRotated(func() { reloaded <- struct{}{} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		receiver.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	for store.subscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Сессия заблокирована в обход receiver, например другим экземпляром без уведомления
+	sessionID, err := store.CreateSession(ctx, uuid.New(), "hash", "id", "agent", "192.0.2.1", models.SessionKindUser, models.TokenBinding{}, time.Now().Add(time.Hour).Unix())
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if err := store.BlockSession(ctx, sessionID); err != nil {
+		t.Fatalf("BlockSession: %v", err)
+	}
+
+	if err := sender.NotifyKeysRotated(ctx); err != nil {
+		t.Fatalf("NotifyKeysRotated: %v", err)
+	}
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("конфигурация не перечитана по уведомлению о ротации")
+	}
+	if revoked, _ := receiver.IsAccessTokenRevoked(ctx, uuid.Nil, sessionID, "", time.Now()); !revoked {
+		t.Fatal("заблокированные сессии не загружены после уведомления о ротации")
+	}
+}
+
+func TestNotifyKeysRotatedUnsupported(t *testing.T) {
+	repo := NewRepository(repository.NewMemoryRepository(), config.RevocationConfig{NotifyChannel: "test"}, config.JWTConfig{})
+	if err := repo.NotifyKeysRotated(context.Background()); !errors.Is(err, ErrNotificationsUnsupported) {
+		t.Fatalf("NotifyKeysRotated без уведомлений: ожидалась ErrNotificationsUnsupported, получено %v", err)
+	}
+}
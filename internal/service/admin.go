@@ -0,0 +1,105 @@
+package service
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRevokeReason причина блокировки сессий администратором, если другая не указана
+const DefaultRevokeReason = "отзыв администратором"
+
+// ErrAdminForbidden возвращается, если владелец access токена не имеет доступа к административному API
+var ErrAdminForbidden = errors.New("нет доступа к административному API")
+
+// AuthorizeAdmin проверяет access токен и возвращает сервисного клиента, которому он выдан,
+// если клиенту предоставлена область доступа admin. Принимаются только токены, выпущенные
+// клиенту по его секрету: ID клиента не является секретом.
+func (s *AuthService) AuthorizeAdmin(ctx context.Context, accessToken string, binding models.TokenBinding) (*models.Client, error) {
+	clientID, claims, err := s.validate(ctx, accessToken, binding)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ClientID != clientID.String() {
+		return nil, ErrAdminForbidden
+	}
+
+	client, err := s.repo.GetClient(ctx, clientID)
+	if errors.Is(err, repository.ErrClientNotFound) {
+		return nil, ErrAdminForbidden
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения клиента: %w", err)
+	}
+	if client.Disabled || !client.HasScope(models.ScopeAdmin) {
+		return nil, ErrAdminForbidden
+	}
+
+	return client, nil
+}
+
+// RevokeSession блокирует сессию по решению администратора actor
+func (s *AuthService) RevokeSession(ctx context.Context, sessionID int, actor, reason string) error {
+	session, err := s.repo.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	data := s.adminEventData(actor, reason)
+	data.UserID = session.UserID.String()
+	data.SessionID = session.ID
+	event, err := s.newEvent(ctx, models.EventSessionRevoked, session.UserID, data)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.BlockSession(ctx, session.ID, event); err != nil {
+		return fmt.Errorf("ошибка блокировки сессии: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserSessions блокирует все сессии пользователя по решению администратора actor
+func (s *AuthService) RevokeUserSessions(ctx context.Context, userID uuid.UUID, actor, reason string) error {
+	data := s.adminEventData(actor, reason)
+	data.UserID = userID.String()
+	event, err := s.newEvent(ctx, models.EventSessionRevoked, userID, data)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.BlockAllUserSessions(ctx, userID, event); err != nil {
+		return fmt.Errorf("ошибка блокировки сессий: %w", err)
+	}
+	return nil
+}
+
+// RevokeSessionsCreatedBefore блокирует все сессии, созданные раньше before, по решению
+// администратора actor, например после компрометации ключа подписи. Возвращает количество
+// заблокированных сессий.
+func (s *AuthService) RevokeSessionsCreatedBefore(ctx context.Context, before time.Time, actor, reason string) (int, error) {
+	event, err := s.newEvent(ctx, models.EventSessionRevoked, uuid.Nil, s.adminEventData(actor, reason))
+	if err != nil {
+		return 0, err
+	}
+
+	blocked, err := s.repo.BlockSessionsCreatedBefore(ctx, before, event)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка блокировки сессий: %w", err)
+	}
+	return blocked, nil
+}
+
+// adminEventData заполняет данные события о действии администратора
+func (s *AuthService) adminEventData(actor, reason string) models.SessionEventData {
+	return models.SessionEventData{
+		ActorID: actor,
+		Outcome: models.AuditOutcomeSuccess,
+		Reason:  reason,
+	}
+}
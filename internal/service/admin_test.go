@@ -0,0 +1,104 @@
+package service
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"errors"
+	"testing"
+)
+
+// clientToken регистрирует сервисного клиента с областями доступа scopes и возвращает
+// его access токен
+func clientToken(t *testing.T, s *AuthService, scopes []string) (*models.Client, string) {
+	t.Helper()
+	ctx := context.Background()
+	client, secret, err := s.CreateClient(ctx, "client", scopes, "test")
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+	pair, err := s.ClientCredentials(ctx, client.ID, secret, testUserAgent, testClientIP, models.TokenBinding{})
+	if err != nil {
+		t.Fatalf("ClientCredentials: %v", err)
+	}
+	return client, pair.AccessToken
+}
+
+func TestAuthorizeAdmin(t *testing.T) {
+	tests := []struct {
+		name string
+		// token возвращает access токен и ID клиента, которому он выдан
+		token func(t *testing.T, s *AuthService) (string, *models.Client)
+		want  error
+	}{
+		{
+			name: "клиент с областью admin",
+			token: func(t *testing.T, s *AuthService) (string, *models.Client) {
+				client, token := clientToken(t, s, []string{models.ScopeAdmin})
+				return token, client
+			},
+		},
+		{
+			name: "клиент без области admin",
+			token: func(t *testing.T, s *AuthService) (string, *models.Client) {
+				_, token := clientToken(t, s, nil)
+				return token, nil
+			},
+			want: ErrAdminForbidden,
+		},
+		{
+			name: "отключенный клиент с областью admin",
+			token: func(t *testing.T, s *AuthService) (string, *models.Client) {
+				client, token := clientToken(t, s, []string{models.ScopeAdmin})
+				client.Disabled = true
+				if err := s.repo.UpdateClient(context.Background(), client); err != nil {
+					t.Fatalf("UpdateClient: %v", err)
+				}
+				return token, nil
+			},
+			want: ErrAdminForbidden,
+		},
+		{
+			name: "токен пользователя",
+			token: func(t *testing.T, s *AuthService) (string, *models.Client) {
+				_, pair := login(t, s, models.TokenBinding{})
+				return pair.AccessToken, nil
+			},
+			want: ErrAdminForbidden,
+		},
+		{
+			name: "токен пользователя с ID клиента с областью admin",
+			token: func(t *testing.T, s *AuthService) (string, *models.Client) {
+				client, _ := clientToken(t, s, []string{models.ScopeAdmin})
+				pair, err := s.login(context.Background(), client.ID, models.SessionKindUser, testUserAgent, testClientIP, models.TokenBinding{})
+				if err != nil {
+					t.Fatalf("login: %v", err)
+				}
+				return pair.AccessToken, nil
+			},
+			want: ErrAdminForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, 0)
+			token, want := tt.token(t, s)
+
+			client, err := s.AuthorizeAdmin(context.Background(), token, models.TokenBinding{})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("AuthorizeAdmin = %v, ожидалось %v", err, tt.want)
+			}
+			if want != nil && (client == nil || client.ID != want.ID) {
+				t.Fatalf("AuthorizeAdmin вернул клиента %+v, ожидался %s", client, want.ID)
+			}
+		})
+	}
+
+	t.Run("некорректный токен", func(t *testing.T) {
+		s, _ := newTestService(t, 0)
+		_, err := s.AuthorizeAdmin(context.Background(), "not-a-token", models.TokenBinding{})
+		if err == nil || errors.Is(err, ErrAdminForbidden) {
+			t.Fatalf("AuthorizeAdmin = %v, ожидалась ошибка проверки токена", err)
+		}
+	})
+}
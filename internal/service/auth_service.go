@@ -2,51 +2,77 @@ package service
 
 import (
 	"auth-service/internal/config"
+	"auth-service/internal/events"
+	"auth-service/internal/metrics"
 	"auth-service/internal/models"
 	"auth-service/internal/repository"
+	"auth-service/internal/tracing"
 	"auth-service/pkg/jwt"
-	"bytes"
+	"context"
 	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("auth-service/internal/service")
+
 // AuthService реализация сервиса авторизации
 type AuthService struct {
-	repo   repository.Repository
-	config *config.Config
-}
-
-// LoginRequest структура для отправки webhook о попытке входа с нового IP
-type LoginRequest struct {
-	UserID  string `json:"user_id"`
-	OldIP   string `json:"old_ip"`
-	NewIP   string `json:"new_ip"`
-	Time    string `json:"time"`
-	Message string `json:"message"`
+	repo repository.Repository
+	// config действующая конфигурация; каждая операция использует один ее снимок
+	config *config.Store
+	// revocations хранилище отозванных access токенов; nil, если хранилище их не учитывает
+	revocations repository.RevocationChecker
 }
 
 // NewAuthService создает новый экземпляр сервиса авторизации
-func NewAuthService(repo repository.Repository, config *config.Config) *AuthService {
+func NewAuthService(repo repository.Repository, config *config.Store) *AuthService {
+	revocations, _ := repo.(repository.RevocationChecker)
 	return &AuthService{
-		repo:   repo,
-		config: config,
+		repo:        repo,
+		config:      config,
+		revocations: revocations,
 	}
 }
 
-// Login создает новую сессию для пользователя и возвращает пару токенов
-func (s *AuthService) Login(userID uuid.UUID, userAgent, clientIP string) (*models.TokenPair, error) {
-	// Генерируем access токен
-	accessToken, err := jwt.GenerateAccessToken(userID, s.config.JWT.AccessSecret, s.config.JWT.AccessExpiry)
+// Login создает новую сессию для пользователя и возвращает пару токенов,
+// привязанных к ключу клиента binding. Вход с ID сервисного клиента запрещен:
+// клиент получает токены только по своему секрету.
+func (s *AuthService) Login(ctx context.Context, userID uuid.UUID, userAgent, clientIP string, binding models.TokenBinding) (*models.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Login")
+	defer span.End()
+
+	_, err := s.repo.GetClient(ctx, userID)
+	if err == nil {
+		err = ErrClientLogin
+	} else if errors.Is(err, repository.ErrClientNotFound) {
+		err = nil
+	} else {
+		err = fmt.Errorf("ошибка получения клиента: %w", err)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("ошибка создания access токена: %w", err)
+		metrics.Login(metrics.OutcomeFailure)
+		reason := "ошибка получения клиента"
+		if errors.Is(err, ErrClientLogin) {
+			reason = ErrClientLogin.Error()
+		}
+		s.publishFailure(ctx, models.EventLoginFailed, userID, s.sessionEventData(userID, userAgent, clientIP, reason))
+		return nil, err
 	}
 
+	return s.login(ctx, userID, models.SessionKindUser, userAgent, clientIP, binding)
+}
+
+// login создает сессию вида kind, токены которой привязаны к ключу клиента binding,
+// и возвращает пару токенов
+func (s *AuthService) login(ctx context.Context, userID uuid.UUID, kind, userAgent, clientIP string, binding models.TokenBinding) (*models.TokenPair, error) {
+	cfg := s.config.Load()
+
 	// Генерируем refresh токен и его ID
 	refreshToken, refreshTokenID := jwt.GenerateRefreshToken()
 
@@ -54,28 +80,51 @@ func (s *AuthService) Login(userID uuid.UUID, userAgent, clientIP string) (*mode
 	hashedRefreshToken := jwt.HashRefreshToken(refreshToken)
 
 	// Вычисляем время истечения refresh токена
-	expiresAt := time.Now().Add(s.config.JWT.RefreshExpiry).Unix()
+	expiresAt := time.Now().Add(cfg.JWT.RefreshExpiry).Unix()
 
-	// Сохраняем сессию в базе данных
-	_, err = s.repo.CreateSession(userID, hashedRefreshToken, refreshTokenID, userAgent, clientIP, expiresAt)
+	// Сохраняем сессию в базе данных вместе с событием
+	event, err := s.newEvent(ctx, models.EventSessionCreated, userID, s.sessionEventData(userID, userAgent, clientIP, ""))
+	if err != nil {
+		return nil, err
+	}
+	sessionID, err := s.repo.CreateSession(ctx, userID, hashedRefreshToken, refreshTokenID, userAgent, clientIP, kind, binding, expiresAt, event)
 	if err != nil {
+		metrics.Login(metrics.OutcomeFailure)
+		s.publishFailure(ctx, models.EventLoginFailed, userID, s.sessionEventData(userID, userAgent, clientIP, "ошибка сохранения сессии"))
 		return nil, fmt.Errorf("ошибка сохранения сессии: %w", err)
 	}
 
+	// Генерируем access токен, привязанный к сессии и refresh токену пары
+	accessToken, err := jwt.GenerateAccessToken(userID, sessionID, refreshTokenID, tokenClientID(userID, kind), confirmation(binding), cfg.JWT.AccessSecret, cfg.JWT.AccessExpiry)
+	if err != nil {
+		metrics.Login(metrics.OutcomeFailure)
+		s.publishFailure(ctx, models.EventLoginFailed, userID, s.sessionEventData(userID, userAgent, clientIP, "ошибка создания access токена"))
+		return nil, fmt.Errorf("ошибка создания access токена: %w", err)
+	}
+	metrics.Login(metrics.OutcomeSuccess)
+
 	// Кодируем refresh токен в base64 для передачи клиенту
 	refreshTokenBase64 := base64.StdEncoding.EncodeToString([]byte(refreshToken))
 
 	return &models.TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshTokenBase64,
+		TokenType:    tokenType(binding),
 	}, nil
 }
 
-// Refresh обновляет пару токенов
-func (s *AuthService) Refresh(refreshTokenBase64, userAgent, clientIP string) (*models.TokenPair, error) {
+// Refresh обновляет пару токенов. Если токены сессии привязаны к ключу клиента,
+// запрос должен быть подтвержден тем же ключом binding.
+func (s *AuthService) Refresh(ctx context.Context, refreshTokenBase64, userAgent, clientIP string, binding models.TokenBinding) (*models.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Refresh")
+	defer span.End()
+	cfg := s.config.Load()
+
 	// Декодируем refresh токен из base64
 	refreshTokenBytes, err := base64.StdEncoding.DecodeString(refreshTokenBase64)
 	if err != nil {
+		s.refreshFailed(ctx, metrics.ReasonMalformed)
+		s.publishFailure(ctx, models.EventRefreshFailed, uuid.Nil, s.sessionEventData(uuid.Nil, userAgent, clientIP, "неверный формат refresh токена"))
 		return nil, fmt.Errorf("неверный формат refresh токена: %w", err)
 	}
 	refreshToken := string(refreshTokenBytes)
@@ -84,125 +133,385 @@ func (s *AuthService) Refresh(refreshTokenBase64, userAgent, clientIP string) (*
 	hashedRefreshToken := jwt.HashRefreshToken(refreshToken)
 
 	// Получаем сессию по refresh токену
-	session, err := s.repo.GetSessionByRefreshToken(hashedRefreshToken)
+	session, err := s.repo.GetSessionByRefreshToken(ctx, hashedRefreshToken)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		// Токен мог быть заменен ранее
+		return s.refreshRotated(ctx, refreshToken, hashedRefreshToken, userAgent, clientIP, binding)
+	}
 	if err != nil {
+		s.sessionFailed(ctx, err, uuid.Nil, s.sessionEventData(uuid.Nil, userAgent, clientIP, ""))
 		return nil, fmt.Errorf("сессия не найдена: %w", err)
 	}
 
+	data := s.sessionEventData(session.UserID, userAgent, clientIP, "")
+	data.SessionID = session.ID
+
+	// Похищенный привязанный токен бесполезен без ключа клиента, поэтому сессия не блокируется
+	if err := verifyBinding(session.Binding, binding); err != nil {
+		s.bindingFailed(ctx, session.UserID, data)
+		return nil, err
+	}
+
 	// Проверяем, что User-Agent совпадает
 	if session.UserAgent != userAgent {
 		// Блокируем все сессии пользователя при попытке обновления токенов с другого устройства
-		_ = s.repo.BlockAllUserSessions(session.UserID)
+		s.refreshFailed(ctx, metrics.ReasonUAMismatch)
+		locked := data
+		locked.Outcome = models.AuditOutcomeFailure
+		locked.Reason = "обновление токенов с другого устройства"
+		event, err := s.newEvent(ctx, models.EventUserLocked, session.UserID, locked)
+		if err == nil {
+			// Блокировка не должна прерываться, если клиент разорвал соединение
+			err = s.repo.BlockAllUserSessions(context.WithoutCancel(ctx), session.UserID, event)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Ошибка блокировки сессий пользователя", slog.String("user_id", session.UserID.String()), slog.Any("error", err))
+		}
 		return nil, errors.New("обновление токенов с другого устройства запрещено")
 	}
 
+	refreshed, err := s.newEvent(ctx, models.EventSessionRefreshed, session.UserID, data)
+	if err != nil {
+		return nil, err
+	}
+	sessionEvents := []models.Event{refreshed}
+
 	// Проверяем IP-адрес
 	if session.ClientIP != clientIP {
-		// Отправляем webhook о попытке входа с нового IP
-		go s.sendLoginWebhook(session.UserID, session.ClientIP, clientIP)
+		// Публикуем событие о попытке входа с нового IP
+		ipChanged := data
+		ipChanged.OldIP = session.ClientIP
+		ipChanged.NewIP = clientIP
+		ipChanged.Reason = "обнаружена попытка обновления токенов с нового IP-адреса"
+		event, err := s.newEvent(ctx, models.EventSessionIPChanged, session.UserID, ipChanged)
+		if err != nil {
+			return nil, err
+		}
+		sessionEvents = append(sessionEvents, event)
 	}
 
-	// Генерируем новые токены
-	accessToken, err := jwt.GenerateAccessToken(session.UserID, s.config.JWT.AccessSecret, s.config.JWT.AccessExpiry)
+	// Генерируем новый refresh токен и его ID
+	newRefreshToken, newRefreshTokenID := jwt.GenerateRefreshToken()
+
+	// Генерируем новый access токен
+	accessToken, err := jwt.GenerateAccessToken(session.UserID, session.ID, newRefreshTokenID, tokenClientID(session.UserID, session.Kind), confirmation(session.Binding), cfg.JWT.AccessSecret, cfg.JWT.AccessExpiry)
 	if err != nil {
+		metrics.Refresh(metrics.OutcomeFailure)
+		data.Reason = "ошибка создания access токена"
+		s.publishFailure(ctx, models.EventRefreshFailed, session.UserID, data)
 		return nil, fmt.Errorf("ошибка создания access токена: %w", err)
 	}
 
-	// Генерируем новый refresh токен и его ID
-	newRefreshToken, newRefreshTokenID := jwt.GenerateRefreshToken()
-
-	// Хешируем новый refresh токен для хранения в базе данных
-	hashedNewRefreshToken := jwt.HashRefreshToken(newRefreshToken)
+	// Кодируем новый refresh токен в base64 для передачи клиенту
+	pair := &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: base64.StdEncoding.EncodeToString([]byte(newRefreshToken)),
+		TokenType:    tokenType(session.Binding),
+	}
 
-	// Вычисляем время истечения нового refresh токена
-	expiresAt := time.Now().Add(s.config.JWT.RefreshExpiry).Unix()
+	rotation := models.RefreshTokenRotation{
+		SessionID:         session.ID,
+		OldRefreshToken:   hashedRefreshToken,
+		NewRefreshToken:   jwt.HashRefreshToken(newRefreshToken),
+		NewRefreshTokenID: newRefreshTokenID,
+		ExpiresAt:         time.Now().Add(cfg.JWT.RefreshExpiry).Unix(),
+	}
+	if cfg.JWT.RefreshReuseGrace > 0 {
+		// Сохраняем пару для повторных запросов со старым токеном в течение окна
+		if rotation.Successor, err = sealSuccessor(refreshToken, pair); err != nil {
+			metrics.Refresh(metrics.OutcomeFailure)
+			data.Reason = "ошибка шифрования пары токенов"
+			s.publishFailure(ctx, models.EventRefreshFailed, session.UserID, data)
+			return nil, fmt.Errorf("ошибка шифрования пары токенов: %w", err)
+		}
+	}
 
-	// Обновляем сессию в базе данных
-	err = s.repo.UpdateSession(session.ID, hashedNewRefreshToken, newRefreshTokenID, expiresAt)
+	// Заменяем refresh токен, события записываются в той же транзакции
+	err = s.repo.RotateRefreshToken(ctx, rotation, sessionEvents...)
+	if errors.Is(err, repository.ErrRefreshTokenRotated) {
+		// Конкурирующий запрос с тем же токеном успел заменить его первым
+		return s.refreshRotated(ctx, refreshToken, hashedRefreshToken, userAgent, clientIP, binding)
+	}
 	if err != nil {
+		data.Reason = "ошибка обновления сессии"
+		s.sessionFailed(ctx, err, session.UserID, data)
 		return nil, fmt.Errorf("ошибка обновления сессии: %w", err)
 	}
+	metrics.Refresh(metrics.OutcomeSuccess)
 
-	// Кодируем новый refresh токен в base64 для передачи клиенту
-	newRefreshTokenBase64 := base64.StdEncoding.EncodeToString([]byte(newRefreshToken))
+	return pair, nil
+}
 
-	return &models.TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshTokenBase64,
-	}, nil
+// refreshRotated обрабатывает предъявление уже замененного refresh токена.
+// В течение RefreshReuseGrace после замены тому же устройству возвращается выданная
+// при замене пара, чтобы повтор запроса при нестабильной сети не считался атакой.
+// Иначе токен считается похищенным, и сессия блокируется.
+func (s *AuthService) refreshRotated(ctx context.Context, refreshToken, hashedRefreshToken, userAgent, clientIP string, binding models.TokenBinding) (*models.TokenPair, error) {
+	history, err := s.repo.GetRefreshTokenHistory(ctx, hashedRefreshToken)
+	if err != nil {
+		s.sessionFailed(ctx, err, uuid.Nil, s.sessionEventData(uuid.Nil, userAgent, clientIP, ""))
+		return nil, fmt.Errorf("сессия не найдена: %w", err)
+	}
+
+	session, err := s.repo.GetSession(ctx, history.SessionID)
+	if err != nil {
+		s.sessionFailed(ctx, err, uuid.Nil, s.sessionEventData(uuid.Nil, userAgent, clientIP, ""))
+		return nil, fmt.Errorf("сессия не найдена: %w", err)
+	}
+
+	data := s.sessionEventData(session.UserID, userAgent, clientIP, "")
+	data.SessionID = session.ID
+
+	switch {
+	case session.IsBlocked:
+		err = repository.ErrSessionRevoked
+	case session.ExpiresAt <= time.Now().Unix():
+		err = repository.ErrSessionExpired
+	}
+	if err != nil {
+		s.sessionFailed(ctx, err, session.UserID, data)
+		return nil, fmt.Errorf("сессия недействительна: %w", err)
+	}
+
+	// Без ключа клиента замененный токен не считается повторно использованным владельцем
+	if err := verifyBinding(session.Binding, binding); err != nil {
+		s.bindingFailed(ctx, session.UserID, data)
+		return nil, err
+	}
+
+	inGrace := history.Successor != nil && time.Since(history.RotatedAt) <= s.config.Load().JWT.RefreshReuseGrace
+	if inGrace && session.UserAgent == userAgent {
+		pair, err := openSuccessor(refreshToken, history.Successor)
+		if err == nil {
+			metrics.Refresh(metrics.OutcomeSuccess)
+			return pair, nil
+		}
+		slog.ErrorContext(ctx, "Ошибка расшифровки пары токенов", slog.Int("session_id", session.ID), slog.Any("error", err))
+	}
+
+	// Повторное использование замененного токена означает, что им владеет кто-то еще
+	s.refreshFailed(ctx, metrics.ReasonReused)
+	data.Outcome = models.AuditOutcomeFailure
+	data.Reason = "повторное использование замененного refresh токена"
+	event, err := s.newEvent(ctx, models.EventRefreshReuseDetected, session.UserID, data)
+	if err == nil {
+		// Блокировка не должна прерываться, если клиент разорвал соединение
+		err = s.repo.BlockSession(context.WithoutCancel(ctx), session.ID, event)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка блокировки сессии", slog.Int("session_id", session.ID), slog.Any("error", err))
+	}
+
+	return nil, errors.New("refresh токен уже использован")
+}
+
+// Validate проверяет access токен и возвращает ID пользователя. Токен, привязанный
+// к ключу клиента, принимается, только если запрос подтвержден этим ключом binding.
+func (s *AuthService) Validate(ctx context.Context, accessToken string, binding models.TokenBinding) (uuid.UUID, error) {
+	userID, _, err := s.validate(ctx, accessToken, binding)
+	return userID, err
 }
 
-// Validate проверяет access токен и возвращает ID пользователя
-func (s *AuthService) Validate(accessToken string) (uuid.UUID, error) {
+// validate проверяет access токен так же, как Validate, и возвращает также его claims
+func (s *AuthService) validate(ctx context.Context, accessToken string, binding models.TokenBinding) (uuid.UUID, *jwt.TokenClaims, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.Validate")
+	defer span.End()
+
 	// Проверяем валидность access токена
-	claims, err := jwt.ValidateAccessToken(accessToken, s.config.JWT.AccessSecret)
+	claims, err := jwt.ValidateAccessToken(accessToken, s.verificationKeys()...)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("невалидный access токен: %w", err)
+		reason := accessTokenFailureReason(err)
+		metrics.ValidationFailure("access", reason)
+		tracing.Fail(ctx, reason)
+		return uuid.Nil, nil, fmt.Errorf("невалидный access токен: %w", err)
 	}
 
 	// Парсим ID пользователя
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("неверный формат ID пользователя: %w", err)
+		metrics.ValidationFailure("access", metrics.ReasonMalformed)
+		tracing.Fail(ctx, metrics.ReasonMalformed)
+		return uuid.Nil, nil, fmt.Errorf("неверный формат ID пользователя: %w", err)
 	}
 
-	return userID, nil
+	if err := verifyBinding(claimsBinding(claims), binding); err != nil {
+		metrics.ValidationFailure("access", metrics.ReasonBindingMismatch)
+		tracing.Fail(ctx, metrics.ReasonBindingMismatch)
+		return uuid.Nil, nil, err
+	}
+
+	// Проверяем, что токен не отозван блокировкой сессии.
+	// Недоступность хранилища отзывов не должна останавливать проверку токенов.
+	if s.revocations != nil {
+		var issuedAt time.Time
+		if claims.IssuedAt != nil {
+			issuedAt = claims.IssuedAt.Time
+		}
+		revoked, err := s.revocations.IsAccessTokenRevoked(ctx, userID, claims.SessionID, claims.ID, issuedAt)
+		if err != nil {
+			slog.WarnContext(ctx, "Ошибка проверки отзыва access токена", slog.String("user_id", userID.String()), slog.Any("error", err))
+		}
+		if revoked {
+			metrics.ValidationFailure("access", metrics.ReasonRevoked)
+			tracing.Fail(ctx, metrics.ReasonRevoked)
+			return uuid.Nil, nil, errors.New("access токен отозван")
+		}
+	}
+
+	return userID, claims, nil
 }
 
 // Logout деавторизует пользователя (делает токены недействительными)
-func (s *AuthService) Logout(accessToken string) error {
+func (s *AuthService) Logout(ctx context.Context, accessToken, userAgent, clientIP string) error {
+	ctx, span := tracer.Start(ctx, "AuthService.Logout")
+	defer span.End()
+
 	// Проверяем валидность access токена
-	claims, err := jwt.ValidateAccessToken(accessToken, s.config.JWT.AccessSecret)
+	claims, err := jwt.ValidateAccessToken(accessToken, s.verificationKeys()...)
 	if err != nil {
+		metrics.Logout(metrics.OutcomeFailure)
+		metrics.ValidationFailure("access", accessTokenFailureReason(err))
+		s.publishFailure(ctx, models.EventLogoutFailed, uuid.Nil, s.sessionEventData(uuid.Nil, userAgent, clientIP, "невалидный access токен"))
 		return fmt.Errorf("невалидный access токен: %w", err)
 	}
 
 	// Парсим ID пользователя
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
+		metrics.Logout(metrics.OutcomeFailure)
+		metrics.ValidationFailure("access", metrics.ReasonMalformed)
+		s.publishFailure(ctx, models.EventLogoutFailed, uuid.Nil, s.sessionEventData(uuid.Nil, userAgent, clientIP, "неверный формат ID пользователя"))
 		return fmt.Errorf("неверный формат ID пользователя: %w", err)
 	}
 
 	// Блокируем все сессии пользователя
-	err = s.repo.BlockAllUserSessions(userID)
+	data := s.sessionEventData(userID, userAgent, clientIP, "выход пользователя")
+	event, err := s.newEvent(ctx, models.EventSessionRevoked, userID, data)
+	if err != nil {
+		return err
+	}
+	err = s.repo.BlockAllUserSessions(ctx, userID, event)
 	if err != nil {
+		metrics.Logout(metrics.OutcomeFailure)
+		data.Reason = "ошибка блокировки сессий"
+		s.publishFailure(ctx, models.EventLogoutFailed, userID, data)
 		return fmt.Errorf("ошибка блокировки сессий: %w", err)
 	}
+	metrics.Logout(metrics.OutcomeSuccess)
 
 	return nil
 }
 
-// sendLoginWebhook отправляет webhook о попытке входа с нового IP
-func (s *AuthService) sendLoginWebhook(userID uuid.UUID, oldIP, newIP string) {
-	// Проверяем, задан ли URL для webhook
-	if s.config.Webhook.URL == "" {
-		return
+// CheckSigningKey проверяет, что ключ подписи access токенов задан и позволяет
+// выпустить и проверить токен
+func (s *AuthService) CheckSigningKey(ctx context.Context) error {
+	cfg := s.config.Load()
+	if cfg.JWT.AccessSecret == "" {
+		return errors.New("не задан ключ подписи access токенов")
 	}
 
-	// Готовим данные для отправки
-	data := LoginRequest{
-		UserID:  userID.String(),
-		OldIP:   oldIP,
-		NewIP:   newIP,
-		Time:    time.Now().Format(time.RFC3339),
-		Message: "Обнаружена попытка обновления токенов с нового IP-адреса",
+	token, err := jwt.GenerateAccessToken(uuid.Nil, 0, "", "", nil, cfg.JWT.AccessSecret, time.Minute)
+	if err != nil {
+		return fmt.Errorf("ошибка подписи access токена: %w", err)
+	}
+	if _, err := jwt.ValidateAccessToken(token, cfg.JWT.AccessSecret); err != nil {
+		return fmt.Errorf("ошибка проверки подписи access токена: %w", err)
 	}
 
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		fmt.Printf("Ошибка сериализации данных для webhook: %v\n", err)
-		return
+	return nil
+}
+
+// verificationKeys возвращает ключи проверки подписи access токенов: текущий и прежние
+func (s *AuthService) verificationKeys() []string {
+	cfg := s.config.Load()
+	return append([]string{cfg.JWT.AccessSecret}, cfg.JWT.PreviousAccessSecrets...)
+}
+
+// sessionFailed учитывает отказ в обновлении токенов из-за ошибки получения или замены сессии
+func (s *AuthService) sessionFailed(ctx context.Context, err error, userID uuid.UUID, data models.SessionEventData) {
+	reason := data.Reason
+	switch {
+	case errors.Is(err, repository.ErrSessionNotFound):
+		s.refreshFailed(ctx, metrics.ReasonNotFound)
+		reason = "сессия не найдена"
+	case errors.Is(err, repository.ErrSessionExpired):
+		s.refreshFailed(ctx, metrics.ReasonExpired)
+		reason = "сессия истекла"
+	case errors.Is(err, repository.ErrSessionRevoked):
+		s.refreshFailed(ctx, metrics.ReasonRevoked)
+		reason = "сессия заблокирована"
+	default:
+		metrics.Refresh(metrics.OutcomeFailure)
+		if reason == "" {
+			reason = "ошибка получения сессии"
+		}
 	}
+	data.Reason = reason
+	s.publishFailure(ctx, models.EventRefreshFailed, userID, data)
+}
+
+// refreshFailed учитывает отказ в обновлении токенов из-за недействительного refresh токена
+func (s *AuthService) refreshFailed(ctx context.Context, reason string) {
+	tracing.Fail(ctx, reason)
+	metrics.Refresh(metrics.OutcomeFailure)
+	metrics.ValidationFailure("refresh", reason)
+}
 
-	// Отправляем запрос
-	resp, err := http.Post(s.config.Webhook.URL, "application/json", bytes.NewBuffer(jsonData))
+// bindingFailed учитывает отказ в обновлении токенов, привязанных к другому ключу клиента
+func (s *AuthService) bindingFailed(ctx context.Context, userID uuid.UUID, data models.SessionEventData) {
+	s.refreshFailed(ctx, metrics.ReasonBindingMismatch)
+	data.Reason = ErrTokenBindingMismatch.Error()
+	s.publishFailure(ctx, models.EventRefreshFailed, userID, data)
+}
+
+// accessTokenFailureReason определяет причину отказа в проверке access токена для метрик
+func accessTokenFailureReason(err error) string {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return metrics.ReasonExpired
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid), errors.Is(err, jwt.ErrTokenUnverifiable):
+		return metrics.ReasonBadSignature
+	default:
+		return metrics.ReasonMalformed
+	}
+}
+
+// sessionEventData заполняет данные события о действии пользователя.
+// Инициатором действия считается сам пользователь, если он известен.
+func (s *AuthService) sessionEventData(userID uuid.UUID, userAgent, clientIP, reason string) models.SessionEventData {
+	data := models.SessionEventData{
+		UserAgent: userAgent,
+		ClientIP:  clientIP,
+		Outcome:   models.AuditOutcomeSuccess,
+		Reason:    reason,
+	}
+	if userID != uuid.Nil {
+		data.ActorID = userID.String()
+		data.UserID = userID.String()
+	}
+	return data
+}
+
+// publishFailure публикует событие о неудачном действии.
+// Событие записывается, даже если ctx уже отменен: неудача могла быть вызвана именно отменой.
+// Ошибка публикации только логируется, чтобы не скрыть исходную ошибку.
+func (s *AuthService) publishFailure(ctx context.Context, eventType string, userID uuid.UUID, data models.SessionEventData) {
+	data.Outcome = models.AuditOutcomeFailure
+	tracing.Fail(ctx, data.Reason)
+	event, err := s.newEvent(ctx, eventType, userID, data)
+	if err == nil {
+		err = s.repo.AppendEvents(context.WithoutCancel(ctx), event)
+	}
 	if err != nil {
-		fmt.Printf("Ошибка отправки webhook: %v\n", err)
-		return
+		slog.ErrorContext(ctx, "Ошибка публикации события", slog.String("event_type", eventType), slog.Any("error", err))
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode >= 400 {
-		fmt.Printf("Webhook вернул ошибку: HTTP %d\n", resp.StatusCode)
+// newEvent создает событие CloudEvents, субъектом которого является пользователь
+func (s *AuthService) newEvent(ctx context.Context, eventType string, userID uuid.UUID, data interface{}) (models.Event, error) {
+	subject := ""
+	if userID != uuid.Nil {
+		subject = userID.String()
 	}
+	return events.New(ctx, s.config.Load().Events.Source, eventType, subject, data)
 }
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"auth-service/internal/models"
+	"auth-service/pkg/jwt"
+	"errors"
+)
+
+// ErrTokenBindingMismatch возвращается, если токен привязан к ключу клиента,
+// а запрос подтвержден другим ключом или не подтвержден вовсе
+var ErrTokenBindingMismatch = errors.New("токен привязан к другому ключу клиента")
+
+// verifyBinding проверяет, что клиент подтвердил владение ключом presented,
+// к которому привязаны токены bound. Токены, не привязанные к сертификату, принимаются
+// по соединению с любым сертификатом, а DPoP доказательство допускается только
+// для токенов, привязанных к его ключу (RFC 9449, раздел 7.1).
+func verifyBinding(bound, presented models.TokenBinding) error {
+	if bound.CertThumbprint != "" && bound.CertThumbprint != presented.CertThumbprint {
+		return ErrTokenBindingMismatch
+	}
+	if bound.JWKThumbprint != presented.JWKThumbprint {
+		return ErrTokenBindingMismatch
+	}
+	return nil
+}
+
+// confirmation возвращает claim cnf access токена, привязанного к ключу binding;
+// nil, если токен не привязан
+func confirmation(binding models.TokenBinding) *jwt.Confirmation {
+	if binding.IsZero() {
+		return nil
+	}
+	return &jwt.Confirmation{CertThumbprint: binding.CertThumbprint, JWKThumbprint: binding.JWKThumbprint}
+}
+
+// claimsBinding возвращает ключ клиента, к которому привязан access токен
+func claimsBinding(claims *jwt.TokenClaims) models.TokenBinding {
+	if claims.Confirmation == nil {
+		return models.TokenBinding{}
+	}
+	return models.TokenBinding{
+		CertThumbprint: claims.Confirmation.CertThumbprint,
+		JWKThumbprint:  claims.Confirmation.JWKThumbprint,
+	}
+}
+
+// tokenType возвращает тип access токена, привязанного к ключу binding, для ответа клиенту:
+// токен, привязанный к ключу DPoP, предъявляется в схеме DPoP, остальные — в схеме Bearer
+func tokenType(binding models.TokenBinding) string {
+	if binding.JWKThumbprint != "" {
+		return models.TokenTypeDPoP
+	}
+	return models.TokenTypeBearer
+}
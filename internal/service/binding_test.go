@@ -0,0 +1,132 @@
+package service
+
+import (
+	"auth-service/internal/models"
+	"auth-service/pkg/jwt"
+	"context"
+	"errors"
+	"testing"
+)
+
+var (
+	certBinding  = models.TokenBinding{CertThumbprint: "cert"}
+	otherCert    = models.TokenBinding{CertThumbprint: "other-cert"}
+	dpopBinding  = models.TokenBinding{JWKThumbprint: "jkt"}
+	otherDPoP    = models.TokenBinding{JWKThumbprint: "other-jkt"}
+	certWithDPoP = models.TokenBinding{CertThumbprint: "cert", JWKThumbprint: "jkt"}
+)
+
+// bindingTests случаи предъявления токенов, привязанных к bound, в запросе,
+// подтвержденном ключами presented
+var bindingTests = []struct {
+	name      string
+	bound     models.TokenBinding
+	presented models.TokenBinding
+	valid     bool
+}{
+	{"непривязанный токен без ключей", models.TokenBinding{}, models.TokenBinding{}, true},
+	{"непривязанный токен с сертификатом", models.TokenBinding{}, certBinding, true},
+	{"непривязанный токен с DPoP доказательством", models.TokenBinding{}, dpopBinding, false},
+	{"токен сертификата с тем же сертификатом", certBinding, certBinding, true},
+	{"токен сертификата без сертификата", certBinding, models.TokenBinding{}, false},
+	{"токен сертификата с другим сертификатом", certBinding, otherCert, false},
+	{"токен сертификата с сертификатом и DPoP доказательством", certBinding, certWithDPoP, false},
+	{"токен DPoP с тем же ключом", dpopBinding, dpopBinding, true},
+	{"токен DPoP с тем же ключом и сертификатом", dpopBinding, certWithDPoP, true},
+	{"токен DPoP без доказательства", dpopBinding, models.TokenBinding{}, false},
+	{"токен DPoP с другим ключом", dpopBinding, otherDPoP, false},
+	{"токен DPoP с сертификатом вместо доказательства", dpopBinding, certBinding, false},
+}
+
+func TestVerifyBinding(t *testing.T) {
+	for _, tt := range bindingTests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyBinding(tt.bound, tt.presented)
+			if tt.valid && err != nil {
+				t.Fatalf("verifyBinding: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrTokenBindingMismatch) {
+				t.Fatalf("ожидалась ErrTokenBindingMismatch, получено %v", err)
+			}
+		})
+	}
+}
+
+func TestClaimsBinding(t *testing.T) {
+	tests := []struct {
+		name    string
+		binding models.TokenBinding
+	}{
+		{"непривязанный токен", models.TokenBinding{}},
+		{"токен сертификата", certBinding},
+		{"токен DPoP", dpopBinding},
+		{"токен сертификата и DPoP", certWithDPoP},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &jwt.TokenClaims{Confirmation: confirmation(tt.binding)}
+			if tt.binding.IsZero() != (claims.Confirmation == nil) {
+				t.Fatalf("cnf %+v для привязки %+v", claims.Confirmation, tt.binding)
+			}
+			if got := claimsBinding(claims); got != tt.binding {
+				t.Fatalf("claimsBinding = %+v, ожидалось %+v", got, tt.binding)
+			}
+		})
+	}
+}
+
+func TestValidateBinding(t *testing.T) {
+	for _, tt := range bindingTests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(t, 0)
+			userID, pair := login(t, s, tt.bound)
+			if want := tokenType(tt.bound); pair.TokenType != want {
+				t.Fatalf("тип токена %s, ожидался %s", pair.TokenType, want)
+			}
+
+			validated, err := s.Validate(context.Background(), pair.AccessToken, tt.presented)
+			if !tt.valid {
+				if !errors.Is(err, ErrTokenBindingMismatch) {
+					t.Fatalf("ожидалась ErrTokenBindingMismatch, получено %v", err)
+				}
+				return
+			}
+			if err != nil || validated != userID {
+				t.Fatalf("Validate: %v, пользователь %s", err, validated)
+			}
+		})
+	}
+}
+
+func TestRefreshBinding(t *testing.T) {
+	for _, tt := range bindingTests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestService(t, 0)
+			ctx := context.Background()
+			userID, pair := login(t, s, tt.bound)
+
+			refreshed, err := s.Refresh(ctx, pair.RefreshToken, testUserAgent, testClientIP, tt.presented)
+			if !tt.valid {
+				if !errors.Is(err, ErrTokenBindingMismatch) {
+					t.Fatalf("ожидалась ErrTokenBindingMismatch, получено %v", err)
+				}
+				// Токен, предъявленный без ключа, не расходуется
+				if userSession(t, repo, userID).IsBlocked {
+					t.Fatal("сессия заблокирована после предъявления токена без ключа")
+				}
+				if _, err := s.Refresh(ctx, pair.RefreshToken, testUserAgent, testClientIP, tt.bound); err != nil {
+					t.Fatalf("Refresh с ключом привязки: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Refresh: %v", err)
+			}
+
+			// Новая пара привязана к тому же ключу, что и прежняя
+			if _, err := s.Validate(ctx, refreshed.AccessToken, tt.bound); err != nil {
+				t.Fatalf("Validate новой пары: %v", err)
+			}
+		})
+	}
+}
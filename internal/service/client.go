@@ -0,0 +1,188 @@
+package service
+
+import (
+	"auth-service/internal/metrics"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInvalidClient возвращается, если клиент не найден, отключен или предъявил неверный секрет
+	ErrInvalidClient = errors.New("неверные учетные данные клиента")
+	// ErrUnknownScope возвращается при попытке предоставить клиенту неизвестную область доступа
+	ErrUnknownScope = errors.New("неизвестная область доступа")
+	// ErrClientLogin возвращается при попытке входа пользователя с ID сервисного клиента
+	ErrClientLogin = errors.New("ID принадлежит сервисному клиенту")
+)
+
+// clientSecretSize длина секрета сервисного клиента в байтах
+const clientSecretSize = 32
+
+// CreateClient регистрирует сервисного клиента с областями доступа scopes по решению администратора actor.
+// Возвращает клиента и его секрет: секрет не хранится и показывается только один раз.
+func (s *AuthService) CreateClient(ctx context.Context, name string, scopes []string, actor string) (*models.Client, string, error) {
+	if err := validateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := generateClientSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := &models.Client{
+		ID:         uuid.New(),
+		Name:       name,
+		SecretHash: hashClientSecret(secret),
+		Scopes:     append([]string{}, scopes...),
+	}
+	if err := s.repo.CreateClient(ctx, client); err != nil {
+		return nil, "", err
+	}
+
+	if err := s.appendClientEvent(ctx, models.EventClientCreated, client, actor, ""); err != nil {
+		return nil, "", err
+	}
+
+	return client, secret, nil
+}
+
+// UpdateClient сохраняет название, области доступа и состояние клиента по решению администратора actor.
+// При отключении клиента его сессии блокируются, и выданные ему токены перестают действовать.
+func (s *AuthService) UpdateClient(ctx context.Context, client *models.Client, actor string) error {
+	if err := validateScopes(client.Scopes); err != nil {
+		return err
+	}
+
+	previous, err := s.repo.GetClient(ctx, client.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdateClient(ctx, client); err != nil {
+		return err
+	}
+
+	if err := s.appendClientEvent(ctx, models.EventClientUpdated, client, actor, ""); err != nil {
+		return err
+	}
+
+	if client.Disabled && !previous.Disabled {
+		return s.RevokeUserSessions(ctx, client.ID, actor, "клиент отключен")
+	}
+	return nil
+}
+
+// RotateClientSecret заменяет секрет клиента по решению администратора actor и возвращает новый секрет.
+// Прежний секрет сразу перестает действовать; выданные по нему токены остаются действительными.
+func (s *AuthService) RotateClientSecret(ctx context.Context, clientID uuid.UUID, actor string) (string, error) {
+	client, err := s.repo.GetClient(ctx, clientID)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := generateClientSecret()
+	if err != nil {
+		return "", err
+	}
+
+	client.SecretHash = hashClientSecret(secret)
+	if err := s.repo.UpdateClient(ctx, client); err != nil {
+		return "", err
+	}
+
+	if err := s.appendClientEvent(ctx, models.EventClientUpdated, client, actor, "замена секрета"); err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+// ClientCredentials проверяет секрет сервисного клиента и создает для него сессию вида
+// SessionKindClient. Токены привязываются к ключу клиента binding.
+func (s *AuthService) ClientCredentials(ctx context.Context, clientID uuid.UUID, secret, userAgent, clientIP string, binding models.TokenBinding) (*models.TokenPair, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.ClientCredentials")
+	defer span.End()
+
+	client, err := s.repo.GetClient(ctx, clientID)
+	if err != nil && !errors.Is(err, repository.ErrClientNotFound) {
+		metrics.Login(metrics.OutcomeFailure)
+		s.publishFailure(ctx, models.EventLoginFailed, clientID, s.sessionEventData(clientID, userAgent, clientIP, "ошибка получения клиента"))
+		return nil, fmt.Errorf("ошибка получения клиента: %w", err)
+	}
+
+	// Неизвестный клиент, отключенный клиент и неверный секрет неотличимы для вызывающего
+	if client == nil || client.Disabled ||
+		subtle.ConstantTimeCompare([]byte(hashClientSecret(secret)), []byte(client.SecretHash)) != 1 {
+		metrics.Login(metrics.OutcomeFailure)
+		s.publishFailure(ctx, models.EventLoginFailed, clientID, s.sessionEventData(clientID, userAgent, clientIP, ErrInvalidClient.Error()))
+		return nil, ErrInvalidClient
+	}
+
+	return s.login(ctx, client.ID, models.SessionKindClient, userAgent, clientIP, binding)
+}
+
+// tokenClientID возвращает значение claim client_id access токена сессии вида kind:
+// ID клиента для сессии сервисного клиента и пустую строку для сессии пользователя
+func tokenClientID(userID uuid.UUID, kind string) string {
+	if kind != models.SessionKindClient {
+		return ""
+	}
+	return userID.String()
+}
+
+// appendClientEvent записывает в журнал событие об изменении клиента администратором actor
+func (s *AuthService) appendClientEvent(ctx context.Context, eventType string, client *models.Client, actor, reason string) error {
+	data := s.adminEventData(actor, reason)
+	data.UserID = client.ID.String()
+	event, err := s.newEvent(ctx, eventType, client.ID, data)
+	if err == nil {
+		err = s.repo.AppendEvents(ctx, event)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка записи события: %w", err)
+	}
+	return nil
+}
+
+// validateScopes проверяет, что все области доступа известны
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		known := false
+		for _, candidate := range models.ClientScopes {
+			if scope == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("%w: %s", ErrUnknownScope, scope)
+		}
+	}
+	return nil
+}
+
+// generateClientSecret создает случайный секрет клиента
+func generateClientSecret() (string, error) {
+	secret := make([]byte, clientSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("ошибка генерации секрета клиента: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// hashClientSecret хеширует секрет клиента для хранения и сравнения.
+// Секрет случайный и достаточно длинный, поэтому медленный хеш не требуется.
+func hashClientSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
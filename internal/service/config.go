@@ -0,0 +1,40 @@
+package service
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// ReloadConfig перезагружает конфигурацию и записывает в журнал событие с изменениями
+// или причиной отказа. Инициатором считается trigger: config.ReloadSignal, config.ReloadFile
+// или config.ReloadKeysRotated.
+// Ошибка записи события только логируется: новая конфигурация к этому моменту уже действует.
+func (s *AuthService) ReloadConfig(ctx context.Context, trigger string) ([]models.ConfigChange, error) {
+	changes, reloadErr := s.config.Reload()
+	if reloadErr == nil && len(changes) == 0 {
+		return nil, nil
+	}
+
+	data := models.ConfigEventData{
+		ActorID: "config:" + trigger,
+		Outcome: models.AuditOutcomeSuccess,
+		Changes: changes,
+	}
+	if reloadErr != nil {
+		data.Outcome = models.AuditOutcomeFailure
+		data.Reason = reloadErr.Error()
+	}
+
+	event, err := s.newEvent(ctx, models.EventConfigReloaded, uuid.Nil, data)
+	if err == nil {
+		err = s.repo.AppendEvents(ctx, event)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Ошибка публикации события", slog.String("event_type", models.EventConfigReloaded), slog.Any("error", err))
+	}
+
+	return changes, reloadErr
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"auth-service/internal/models"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// successorKeyLabel разделяет ключ шифрования выданной пары и хеш refresh токена
+const successorKeyLabel = "auth-service refresh successor"
+
+// sealSuccessor шифрует пару токенов, выданную взамен refreshToken.
+// Ключ выводится из самого refreshToken, поэтому расшифровать пару может только
+// предъявивший его клиент, а в базе данных токены в открытом виде не хранятся.
+func sealSuccessor(refreshToken string, pair *models.TokenPair) ([]byte, error) {
+	aead, err := successorCipher(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := json.Marshal(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// openSuccessor расшифровывает пару токенов, выданную взамен refreshToken
+func openSuccessor(refreshToken string, sealed []byte) (*models.TokenPair, error) {
+	aead, err := successorCipher(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("некорректная длина зашифрованной пары токенов")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка расшифровки пары токенов: %w", err)
+	}
+
+	pair := &models.TokenPair{}
+	if err := json.Unmarshal(plaintext, pair); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// successorCipher создает AES-GCM с ключом HMAC-SHA256(refreshToken, successorKeyLabel)
+func successorCipher(refreshToken string) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, []byte(refreshToken))
+	mac.Write([]byte(successorKeyLabel))
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
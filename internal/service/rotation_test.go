@@ -0,0 +1,169 @@
+package service
+
+import (
+	"auth-service/internal/models"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRefreshConcurrentRotation(t *testing.T) {
+	tests := []struct {
+		name  string
+		grace time.Duration
+		// winners ожидаемое число успешных запросов
+		winners int
+	}{
+		// Проигравшие запросы предъявляют уже замененный токен, и сессия блокируется
+		{name: "без окна", grace: 0, winners: 1},
+		// Проигравшие запросы получают пару, выданную победителю
+		{name: "с окном", grace: time.Minute, winners: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestService(t, tt.grace)
+			userID, pair := login(t, s, models.TokenBinding{})
+
+			const requests = 10
+			var wg sync.WaitGroup
+			results := make(chan *models.TokenPair, requests)
+			start := make(chan struct{})
+			for i := 0; i < requests; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					if refreshed, err := s.Refresh(context.Background(), pair.RefreshToken, testUserAgent, testClientIP, models.TokenBinding{}); err == nil {
+						results <- refreshed
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+			close(results)
+
+			var pairs []*models.TokenPair
+			for refreshed := range results {
+				pairs = append(pairs, refreshed)
+			}
+			if len(pairs) != tt.winners {
+				t.Fatalf("успешных обновлений %d, ожидалось %d", len(pairs), tt.winners)
+			}
+			for _, refreshed := range pairs[1:] {
+				if *refreshed != *pairs[0] {
+					t.Fatalf("конкурирующие запросы получили разные пары: %+v и %+v", refreshed, pairs[0])
+				}
+			}
+
+			if blocked := userSession(t, repo, userID).IsBlocked; blocked != (tt.grace == 0) {
+				t.Fatalf("сессия заблокирована: %v", blocked)
+			}
+		})
+	}
+}
+
+func TestRefreshReuseOutsideGrace(t *testing.T) {
+	s, repo := newTestService(t, 0)
+	ctx := context.Background()
+	userID, pair := login(t, s, models.TokenBinding{})
+
+	refreshed, err := s.Refresh(ctx, pair.RefreshToken, testUserAgent, testClientIP, models.TokenBinding{})
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	// Повторное предъявление замененного токена считается утечкой
+	if _, err := s.Refresh(ctx, pair.RefreshToken, testUserAgent, testClientIP, models.TokenBinding{}); err == nil {
+		t.Fatal("замененный refresh токен принят повторно")
+	}
+	if !userSession(t, repo, userID).IsBlocked {
+		t.Fatal("сессия не заблокирована после повторного использования токена")
+	}
+	if _, err := s.Refresh(ctx, refreshed.RefreshToken, testUserAgent, testClientIP, models.TokenBinding{}); err == nil {
+		t.Fatal("новый refresh токен заблокированной сессии принят")
+	}
+}
+
+func TestRefreshReplayInsideGrace(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		// replayed повторный запрос получает ту же пару
+		replayed bool
+	}{
+		{name: "то же устройство", userAgent: testUserAgent, replayed: true},
+		{name: "другое устройство", userAgent: "other-agent", replayed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newTestService(t, time.Minute)
+			ctx := context.Background()
+			userID, pair := login(t, s, models.TokenBinding{})
+
+			refreshed, err := s.Refresh(ctx, pair.RefreshToken, testUserAgent, testClientIP, models.TokenBinding{})
+			if err != nil {
+				t.Fatalf("Refresh: %v", err)
+			}
+
+			replayed, err := s.Refresh(ctx, pair.RefreshToken, tt.userAgent, testClientIP, models.TokenBinding{})
+			if !tt.replayed {
+				if err == nil {
+					t.Fatal("замененный токен принят с другого устройства")
+				}
+				if !userSession(t, repo, userID).IsBlocked {
+					t.Fatal("сессия не заблокирована после повторного использования токена с другого устройства")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("повторный запрос в окне: %v", err)
+			}
+			if *replayed != *refreshed {
+				t.Fatalf("повторный запрос получил пару %+v, ожидалась %+v", replayed, refreshed)
+			}
+			if userSession(t, repo, userID).IsBlocked {
+				t.Fatal("сессия заблокирована после повторного запроса в окне")
+			}
+			// Выданная пара остается действующей
+			if _, err := s.Refresh(ctx, replayed.RefreshToken, testUserAgent, testClientIP, models.TokenBinding{}); err != nil {
+				t.Fatalf("обновление выданной пары: %v", err)
+			}
+		})
+	}
+}
+
+func TestSealedSuccessor(t *testing.T) {
+	pair := &models.TokenPair{AccessToken: "access", RefreshToken: "refresh", TokenType: models.TokenTypeBearer}
+	sealed, err := sealSuccessor("old-refresh-token", pair)
+	if err != nil {
+		t.Fatalf("sealSuccessor: %v", err)
+	}
+
+	opened, err := openSuccessor("old-refresh-token", sealed)
+	if err != nil || *opened != *pair {
+		t.Fatalf("openSuccessor: %+v, %v", opened, err)
+	}
+
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+	tests := []struct {
+		name   string
+		token  string
+		sealed []byte
+	}{
+		{"другой токен", "other-refresh-token", sealed},
+		{"измененные данные", "old-refresh-token", tampered},
+		{"короткие данные", "old-refresh-token", sealed[:4]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if opened, err := openSuccessor(tt.token, tt.sealed); err == nil {
+				t.Fatalf("пара расшифрована: %+v", opened)
+			}
+		})
+	}
+}
@@ -2,21 +2,51 @@ package service
 
 import (
 	"auth-service/internal/models"
+	"context"
 
 	"github.com/google/uuid"
 )
 
 // Service интерфейс бизнес-логики приложения
 type Service interface {
-	// Login создает новую сессию для пользователя и возвращает токены
-	Login(userID uuid.UUID, userAgent, clientIP string) (*models.TokenPair, error)
+	// Login создает новую сессию для пользователя и возвращает токены, привязанные к ключу клиента binding
+	Login(ctx context.Context, userID uuid.UUID, userAgent, clientIP string, binding models.TokenBinding) (*models.TokenPair, error)
 
-	// Refresh обновляет пару токенов
-	Refresh(refreshToken, userAgent, clientIP string) (*models.TokenPair, error)
+	// ClientCredentials проверяет учетные данные сервисного клиента и возвращает токены,
+	// привязанные к ключу клиента binding. Возвращает ErrInvalidClient, если учетные данные неверны.
+	ClientCredentials(ctx context.Context, clientID uuid.UUID, secret, userAgent, clientIP string, binding models.TokenBinding) (*models.TokenPair, error)
 
-	// Validate проверяет access токен и возвращает ID пользователя
-	Validate(accessToken string) (uuid.UUID, error)
+	// Refresh обновляет пару токенов. Возвращает ErrTokenBindingMismatch, если токены
+	// привязаны к ключу клиента, а запрос подтвержден другим ключом binding.
+	Refresh(ctx context.Context, refreshToken, userAgent, clientIP string, binding models.TokenBinding) (*models.TokenPair, error)
+
+	// Validate проверяет access токен, предъявленный с ключом клиента binding, и возвращает ID пользователя
+	Validate(ctx context.Context, accessToken string, binding models.TokenBinding) (uuid.UUID, error)
 
 	// Logout деавторизует пользователя (делает токены недействительными)
-	Logout(accessToken string) error
+	Logout(ctx context.Context, accessToken, userAgent, clientIP string) error
+}
+
+// Admin интерфейс действий администратора. Действия записываются в журнал аудита
+// с инициатором actor.
+type Admin interface {
+	// AuthorizeAdmin проверяет access токен сервисного клиента с областью доступа admin.
+	// Возвращает ErrAdminForbidden, если токен выдан не такому клиенту.
+	AuthorizeAdmin(ctx context.Context, accessToken string, binding models.TokenBinding) (*models.Client, error)
+
+	// RevokeSession блокирует сессию
+	RevokeSession(ctx context.Context, sessionID int, actor, reason string) error
+
+	// RevokeUserSessions блокирует все сессии пользователя
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID, actor, reason string) error
+
+	// CreateClient регистрирует сервисного клиента и возвращает его секрет.
+	// Возвращает ErrUnknownScope, если область доступа неизвестна.
+	CreateClient(ctx context.Context, name string, scopes []string, actor string) (*models.Client, string, error)
+
+	// UpdateClient сохраняет изменения клиента; сессии отключенного клиента блокируются
+	UpdateClient(ctx context.Context, client *models.Client, actor string) error
+
+	// RotateClientSecret заменяет секрет клиента и возвращает новый секрет
+	RotateClientSecret(ctx context.Context, clientID uuid.UUID, actor string) (string, error)
 }
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	testUserAgent = "agent"
+	testClientIP  = "192.0.2.1"
+)
+
+// newTestService создает сервис поверх хранилища в памяти с окном повторного
+// использования refresh токена grace
+func newTestService(t *testing.T, grace time.Duration) (*AuthService, *repository.MemoryRepository) {
+	t.Helper()
+	repo := repository.NewMemoryRepository()
+	t.Cleanup(func() { _ = repo.Close() })
+
+	cfg := &config.Config{
+		JWT: config.JWTConfig{
+			AccessSecret:      "test-access-secret-0123456789abcdef",
+			AccessExpiry:      time.Minute,
+			RefreshExpiry:     time.Hour,
+			RefreshReuseGrace: grace,
+		},
+		Events: config.EventsConfig{Source: "test"},
+	}
+	return NewAuthService(repo, config.NewStore(cfg)), repo
+}
+
+// login создает сессию нового пользователя с токенами, привязанными к binding
+func login(t *testing.T, s *AuthService, binding models.TokenBinding) (uuid.UUID, *models.TokenPair) {
+	t.Helper()
+	userID := uuid.New()
+	pair, err := s.Login(context.Background(), userID, testUserAgent, testClientIP, binding)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return userID, pair
+}
+
+// userSession возвращает единственную сессию пользователя
+func userSession(t *testing.T, repo repository.Repository, userID uuid.UUID) *models.Session {
+	t.Helper()
+	sessions, err := repo.ListSessions(context.Background(), models.SessionFilter{UserID: userID})
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("ListSessions: %v, найдено сессий %d", err, len(sessions))
+	}
+	return sessions[0]
+}
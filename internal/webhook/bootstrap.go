@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"fmt"
+)
+
+// EnsureDefaultSubscription создает подписку для WEBHOOK_URL, если ее еще нет.
+// Подписка получает только события о новом IP, которые отправлялись на WEBHOOK_URL
+// до появления реестра подписок; остальные события подключаются через административный API.
+func EnsureDefaultSubscription(ctx context.Context, subscriptions repository.WebhookSubscriptions, config config.WebhookConfig) error {
+	if config.URL == "" {
+		return nil
+	}
+
+	existing, err := subscriptions.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, subscription := range existing {
+		if subscription.URL == config.URL {
+			return nil
+		}
+	}
+
+	err = subscriptions.CreateWebhookSubscription(ctx, &models.WebhookSubscription{
+		URL:         config.URL,
+		Description: "создана из WEBHOOK_URL",
+		EventTypes:  []string{models.EventSessionIPChanged},
+		Secrets:     config.Secrets,
+		Enabled:     true,
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось создать подписку для WEBHOOK_URL: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,296 @@
+package webhook
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/events"
+	"auth-service/internal/metrics"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/pkg/webhooksig"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxErrorLength ограничивает длину сохраняемого текста ошибки доставки
+const maxErrorLength = 1024
+
+var tracer = otel.Tracer("auth-service/internal/webhook")
+
+// errPermanent ошибка, при которой повторять доставку бессмысленно
+var errPermanent = errors.New("доставка невозможна")
+
+// Store интерфейс хранилища, необходимого Dispatcher
+type Store interface {
+	repository.WebhookOutbox
+	repository.WebhookSubscriptions
+}
+
+// Dispatcher доставляет сообщения из outbox с повторными попытками
+type Dispatcher struct {
+	store    Store
+	settings atomic.Pointer[dispatcherSettings]
+}
+
+// dispatcherSettings параметры доставки, которые заменяются при перезагрузке конфигурации
+type dispatcherSettings struct {
+	config config.WebhookConfig
+	client *http.Client
+	// signer подписывает сообщения без подписки, созданные до появления реестра подписок
+	signer *webhooksig.Signer
+}
+
+// NewDispatcher создает новый экземпляр Dispatcher
+func NewDispatcher(store Store, config config.WebhookConfig) (*Dispatcher, error) {
+	d := &Dispatcher{store: store}
+	if err := d.SetConfig(config); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SetConfig применяет параметры доставки после перезагрузки конфигурации.
+// Начатые доставки завершаются с прежними параметрами; количество одновременных
+// доставок меняется только при перезапуске.
+func (d *Dispatcher) SetConfig(config config.WebhookConfig) error {
+	signer, err := webhooksig.NewSigner(config.Secrets)
+	if err != nil {
+		return fmt.Errorf("ошибка настройки подписи webhook: %w", err)
+	}
+
+	d.settings.Store(&dispatcherSettings{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		signer: signer,
+	})
+	return nil
+}
+
+// Run обрабатывает outbox до отмены ctx.
+// Одновременно выполняется не более config.Workers доставок.
+// После отмены ctx Run дожидается завершения начатых доставок.
+func (d *Dispatcher) Run(ctx context.Context) {
+	workers := d.settings.Load().config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan *models.WebhookMessage)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for message := range jobs {
+				d.deliver(message)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(jobs)
+
+	for {
+		settings := d.settings.Load()
+		// Сообщение захватывается на время, заведомо большее таймаута доставки:
+		// если процесс завершится во время отправки, сообщение снова станет доступным
+		lease := 2*settings.config.Timeout + time.Minute
+
+		messages, err := d.store.ClaimWebhookMessages(ctx, workers, lease)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Ошибка чтения outbox webhook", slog.Any("error", err))
+		}
+
+		for _, message := range messages {
+			select {
+			case jobs <- message:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		// Если очередь не пуста, сразу забираем следующую порцию
+		if len(messages) == workers {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(settings.config.PollInterval):
+		}
+	}
+}
+
+// deliver выполняет одну попытку доставки и сохраняет ее результат.
+// Начатая доставка не прерывается при остановке Dispatcher, поэтому она
+// выполняется в собственном контексте, продолжающем трассировку события.
+func (d *Dispatcher) deliver(message *models.WebhookMessage) {
+	ctx, span := d.startSpan(message)
+	defer span.End()
+
+	settings := d.settings.Load()
+	err := d.send(ctx, settings, message)
+	if err == nil {
+		metrics.WebhookDelivery(metrics.WebhookDelivered)
+		if err := d.store.MarkWebhookDelivered(ctx, message.ID); err != nil {
+			slog.ErrorContext(ctx, "Ошибка сохранения статуса webhook", slog.Int64("message_id", message.ID), slog.Any("error", err))
+		}
+		return
+	}
+
+	dead := message.Attempts >= settings.config.MaxAttempts || errors.Is(err, errPermanent)
+	nextAttemptAt := time.Now().Add(backoff(settings.config, message.Attempts))
+
+	errText := err.Error()
+	if len(errText) > maxErrorLength {
+		errText = errText[:maxErrorLength]
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, errText)
+	if dead {
+		metrics.WebhookDelivery(metrics.WebhookDead)
+		slog.WarnContext(ctx, "Webhook не доставлен",
+			slog.Int64("message_id", message.ID),
+			slog.String("event_type", message.EventType),
+			slog.Int("attempts", message.Attempts),
+			slog.Any("error", err),
+		)
+	} else {
+		metrics.WebhookDelivery(metrics.WebhookRetry)
+	}
+
+	if err := d.store.MarkWebhookFailed(ctx, message.ID, errText, nextAttemptAt, dead); err != nil {
+		slog.ErrorContext(ctx, "Ошибка сохранения статуса webhook", slog.Int64("message_id", message.ID), slog.Any("error", err))
+	}
+}
+
+// startSpan начинает span доставки, дочерний к операции, создавшей событие
+func (d *Dispatcher) startSpan(message *models.WebhookMessage) (context.Context, trace.Span) {
+	ctx := context.Background()
+	var event models.Event
+	if err := json.Unmarshal(message.Payload, &event); err == nil {
+		ctx = events.TraceContext(ctx, event)
+	}
+
+	return tracer.Start(ctx, "webhook.deliver",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.Int64("webhook.message_id", message.ID),
+			attribute.String("webhook.event_type", message.EventType),
+			attribute.Int("webhook.attempt", message.Attempts),
+		),
+	)
+}
+
+// target возвращает адрес получателя и подпись для сообщения
+func (d *Dispatcher) target(ctx context.Context, settings *dispatcherSettings, message *models.WebhookMessage) (string, *webhooksig.Signer, error) {
+	if message.SubscriptionID == nil {
+		if settings.config.URL == "" {
+			return "", nil, fmt.Errorf("%w: не задан WEBHOOK_URL", errPermanent)
+		}
+		return settings.config.URL, settings.signer, nil
+	}
+
+	subscription, err := d.store.GetWebhookSubscription(ctx, *message.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrWebhookSubscriptionNotFound) {
+			return "", nil, fmt.Errorf("%w: %v", errPermanent, err)
+		}
+		return "", nil, err
+	}
+	if !subscription.Enabled {
+		return "", nil, fmt.Errorf("%w: подписка %d отключена", errPermanent, subscription.ID)
+	}
+
+	signer, err := webhooksig.NewSigner(subscription.Secrets)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", errPermanent, err)
+	}
+
+	return subscription.URL, signer, nil
+}
+
+// send отправляет сообщение получателю
+func (d *Dispatcher) send(ctx context.Context, settings *dispatcherSettings, message *models.WebhookMessage) error {
+	url, signer, err := d.target(ctx, settings, message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(message.Payload))
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+	// Идентификатор не меняется между попытками, чтобы получатель мог отбросить повторы
+	msgID := fmt.Sprintf("msg_%d", message.ID)
+	if message.EventID != "" {
+		// Тело содержит событие CloudEvents в структурированном режиме
+		msgID = message.EventID
+		req.Header.Set("Content-Type", "application/cloudevents+json")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	signer.SetHeaders(req.Header, msgID, time.Now(), message.Payload)
+	// Передаем получателю контекст трассировки (traceparent) span-а доставки
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := settings.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ошибка отправки: %w", err)
+	}
+	defer resp.Body.Close()
+	trace.SpanFromContext(ctx).SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("получатель вернул HTTP %d", resp.StatusCode)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		// Получатель отклонил само сообщение, и повтор вернет тот же ответ
+		return fmt.Errorf("%w: получатель вернул HTTP %d", errPermanent, resp.StatusCode)
+	default:
+		return fmt.Errorf("получатель вернул HTTP %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// backoff вычисляет задержку перед следующей попыткой:
+// экспоненциальный рост от BackoffBase до BackoffMax со случайным разбросом
+// в диапазоне [d/2, d), чтобы повторные попытки не приходили одновременно
+func backoff(config config.WebhookConfig, attempts int) time.Duration {
+	delay := config.BackoffBase
+	for i := 1; i < attempts && delay < config.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > config.BackoffMax {
+		delay = config.BackoffMax
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)))
+}
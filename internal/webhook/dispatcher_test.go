@@ -0,0 +1,288 @@
+package webhook
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/pkg/webhooksig"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+// deliveryResult результат попытки доставки, сохраненный Dispatcher
+type deliveryResult struct {
+	delivered     bool
+	lastError     string
+	nextAttemptAt time.Time
+	dead          bool
+}
+
+// recordingStore запоминает результаты доставок. Остальные методы хранилища не реализованы.
+type recordingStore struct {
+	Store
+
+	mu            sync.Mutex
+	results       map[int64]deliveryResult
+	subscriptions map[int64]*models.WebhookSubscription
+}
+
+func newRecordingStore() *recordingStore {
+	return &recordingStore{
+		results:       make(map[int64]deliveryResult),
+		subscriptions: make(map[int64]*models.WebhookSubscription),
+	}
+}
+
+func (s *recordingStore) MarkWebhookDelivered(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[id] = deliveryResult{delivered: true}
+	return nil
+}
+
+func (s *recordingStore) MarkWebhookFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[id] = deliveryResult{lastError: lastError, nextAttemptAt: nextAttemptAt, dead: dead}
+	return nil
+}
+
+func (s *recordingStore) GetWebhookSubscription(ctx context.Context, id int64) (*models.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subscription, ok := s.subscriptions[id]
+	if !ok {
+		return nil, repository.ErrWebhookSubscriptionNotFound
+	}
+	return subscription, nil
+}
+
+func (s *recordingStore) result(t *testing.T, id int64) deliveryResult {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.results[id]
+	if !ok {
+		t.Fatalf("результат доставки сообщения %d не сохранен", id)
+	}
+	return result
+}
+
+// testWebhookConfig параметры доставки на адрес url
+func testWebhookConfig(url string) config.WebhookConfig {
+	return config.WebhookConfig{
+		URL:          url,
+		Secrets:      []string{testSecret},
+		Timeout:      100 * time.Millisecond,
+		Workers:      1,
+		MaxAttempts:  3,
+		BackoffBase:  time.Second,
+		BackoffMax:   time.Minute,
+		PollInterval: time.Second,
+	}
+}
+
+// newReceiver запускает получателя, который отвечает статусом status
+// или не отвечает дольше таймаута доставки, если status равен 0
+func newReceiver(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	verifier, err := webhooksig.NewVerifier([]string{testSecret}, webhooksig.DefaultTolerance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status == 0 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("чтение сообщения: %v", err)
+		}
+		if err := verifier.Verify(body, r.Header); err != nil {
+			t.Errorf("подпись сообщения: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDeliver(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int
+		// retry ожидается повторная попытка, dead - перевод в статус dead
+		delivered bool
+		retry     bool
+		dead      bool
+		lastError string
+	}{
+		{name: "успешная доставка", status: http.StatusNoContent, attempts: 1, delivered: true},
+		{name: "ответ 5xx", status: http.StatusInternalServerError, attempts: 1, retry: true, lastError: "HTTP 500"},
+		{name: "ответ 503", status: http.StatusServiceUnavailable, attempts: 2, retry: true, lastError: "HTTP 503"},
+		{name: "таймаут", status: 0, attempts: 1, retry: true, lastError: "ошибка отправки"},
+		{name: "ответ 429", status: http.StatusTooManyRequests, attempts: 1, retry: true, lastError: "HTTP 429"},
+		{name: "ответ 408", status: http.StatusRequestTimeout, attempts: 1, retry: true, lastError: "HTTP 408"},
+		{name: "ответ 400 без повторов", status: http.StatusBadRequest, attempts: 1, dead: true, lastError: "HTTP 400"},
+		{name: "ответ 410 без повторов", status: http.StatusGone, attempts: 1, dead: true, lastError: "HTTP 410"},
+		{name: "ответ 5xx на последней попытке", status: http.StatusBadGateway, attempts: 3, dead: true, lastError: "HTTP 502"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newReceiver(t, tt.status)
+			store := newRecordingStore()
+			d, err := NewDispatcher(store, testWebhookConfig(server.URL))
+			if err != nil {
+				t.Fatalf("NewDispatcher: %v", err)
+			}
+
+			started := time.Now()
+			d.deliver(&models.WebhookMessage{ID: 1, EventType: "test", Payload: []byte(`{"test":1}`), Attempts: tt.attempts})
+			result := store.result(t, 1)
+
+			if result.delivered != tt.delivered || result.dead != tt.dead {
+				t.Fatalf("результат %+v", result)
+			}
+			if !strings.Contains(result.lastError, tt.lastError) {
+				t.Fatalf("ошибка %q, ожидалась %q", result.lastError, tt.lastError)
+			}
+			if tt.retry {
+				// Задержка перед повторной попыткой выбирается из [d/2, d)
+				delay := backoffDelay(t, testWebhookConfig(server.URL), tt.attempts)
+				if result.nextAttemptAt.Before(started.Add(delay/2)) || result.nextAttemptAt.After(time.Now().Add(delay)) {
+					t.Fatalf("следующая попытка через %s, ожидалось от %s до %s", result.nextAttemptAt.Sub(started), delay/2, delay)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliverDeadLetter(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := testWebhookConfig(server.URL)
+	store := newRecordingStore()
+	d, err := NewDispatcher(store, cfg)
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+
+	// Хранилище увеличивает счетчик попыток при каждом захвате сообщения
+	for attempt := 1; attempt <= cfg.MaxAttempts; attempt++ {
+		d.deliver(&models.WebhookMessage{ID: 1, EventType: "test", Payload: []byte(`{}`), Attempts: attempt})
+		result := store.result(t, 1)
+		if dead := attempt == cfg.MaxAttempts; result.dead != dead {
+			t.Fatalf("попытка %d: dead = %v, ожидалось %v", attempt, result.dead, dead)
+		}
+	}
+	if requests != cfg.MaxAttempts {
+		t.Fatalf("запросов %d, ожидалось %d", requests, cfg.MaxAttempts)
+	}
+}
+
+func TestDeliverSubscription(t *testing.T) {
+	server := newReceiver(t, http.StatusOK)
+	store := newRecordingStore()
+	store.subscriptions[1] = &models.WebhookSubscription{ID: 1, URL: server.URL, Secrets: []string{testSecret}, Enabled: true}
+	store.subscriptions[2] = &models.WebhookSubscription{ID: 2, URL: server.URL, Secrets: []string{testSecret}}
+	d, err := NewDispatcher(store, testWebhookConfig(""))
+	if err != nil {
+		t.Fatalf("NewDispatcher: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		subscription *int64
+		delivered    bool
+	}{
+		{name: "включенная подписка", subscription: ptr(1), delivered: true},
+		{name: "отключенная подписка", subscription: ptr(2)},
+		{name: "удаленная подписка", subscription: ptr(3)},
+		{name: "без подписки и без WEBHOOK_URL"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := int64(i + 1)
+			d.deliver(&models.WebhookMessage{ID: id, SubscriptionID: tt.subscription, EventType: "test", Payload: []byte(`{}`), Attempts: 1})
+			result := store.result(t, id)
+			// Сообщение, которое некуда доставить, сразу переходит в статус dead
+			if result.delivered != tt.delivered || result.dead == tt.delivered {
+				t.Fatalf("результат %+v", result)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := config.WebhookConfig{BackoffBase: time.Second, BackoffMax: 10 * time.Second}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: time.Second},
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 3, want: 4 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		// Задержка не превышает BackoffMax
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 100, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(t, cfg, tt.attempts); got != tt.want {
+			t.Fatalf("попытка %d: задержка до %s, ожидалось %s", tt.attempts, got, tt.want)
+		}
+	}
+
+	if got := backoff(config.WebhookConfig{}, 1); got != 0 {
+		t.Fatalf("задержка без BackoffBase %s", got)
+	}
+}
+
+// backoffDelay проверяет, что случайные задержки backoff лежат в [d/2, d), и возвращает d -
+// наименьшую степень двойки от BackoffBase, ограниченную BackoffMax, которая их покрывает
+func backoffDelay(t *testing.T, cfg config.WebhookConfig, attempts int) time.Duration {
+	t.Helper()
+	delay := cfg.BackoffBase
+	for i := 1; i < attempts && delay < cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > cfg.BackoffMax {
+		delay = cfg.BackoffMax
+	}
+
+	for i := 0; i < 1000; i++ {
+		got := backoff(cfg, attempts)
+		if got < delay/2 || got >= delay {
+			t.Fatalf("попытка %d: задержка %s вне [%s, %s)", attempts, got, delay/2, delay)
+		}
+	}
+	return delay
+}
+
+func ptr(id int64) *int64 {
+	return &id
+}
@@ -0,0 +1,23 @@
+package webhook
+
+import (
+	"auth-service/internal/config"
+	"auth-service/internal/repository"
+	"context"
+	"fmt"
+)
+
+// BacklogCheck возвращает проверку готовности, которая завершается ошибкой,
+// если просроченных сообщений в outbox больше cfg.WebhookBacklogMax
+func BacklogCheck(outbox repository.WebhookOutbox, cfg config.HealthConfig) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		count, err := outbox.CountWebhookBacklog(ctx, cfg.WebhookBacklogAge)
+		if err != nil {
+			return err
+		}
+		if count > cfg.WebhookBacklogMax {
+			return fmt.Errorf("в очереди webhook %d сообщений ожидают отправки дольше %s", count, cfg.WebhookBacklogAge)
+		}
+		return nil
+	}
+}
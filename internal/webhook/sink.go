@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+)
+
+// Sink передает события в outbox для доставки подписчикам webhook
+type Sink struct {
+	outbox repository.WebhookOutbox
+}
+
+// NewSink создает новый экземпляр Sink
+func NewSink(outbox repository.WebhookOutbox) *Sink {
+	return &Sink{outbox: outbox}
+}
+
+// Name возвращает имя sink-а
+func (s *Sink) Name() string {
+	return "webhook"
+}
+
+// Write ставит события в очередь для каждой подписки на их тип
+func (s *Sink) Write(ctx context.Context, events []models.Event) error {
+	return s.outbox.EnqueueWebhookEvents(ctx, events...)
+}
@@ -1,7 +1,11 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -10,24 +14,57 @@ import (
 	"github.com/google/uuid"
 )
 
+// Ошибки проверки access токена, с которыми можно сравнить результат ValidateAccessToken через errors.Is
+var (
+	// ErrTokenExpired срок действия токена истек
+	ErrTokenExpired = jwt.ErrTokenExpired
+	// ErrTokenSignatureInvalid подпись токена неверна
+	ErrTokenSignatureInvalid = jwt.ErrTokenSignatureInvalid
+	// ErrTokenUnverifiable подпись токена невозможно проверить (например, неожиданный алгоритм)
+	ErrTokenUnverifiable = jwt.ErrTokenUnverifiable
+)
+
 // TokenClaims структура данных для JWT токена
 type TokenClaims struct {
 	UserID         string `json:"user_id"`
 	RefreshTokenID string `json:"refresh_token_id,omitempty"`
+	// SessionID ID сессии, для которой выпущен токен
+	SessionID int `json:"sid,omitempty"`
+	// Confirmation ключ, которым должен владеть предъявитель токена; nil, если токен не привязан
+	Confirmation *Confirmation `json:"cnf,omitempty"`
+	// ClientID ID сервисного клиента, подтвердившего свой секрет; пуст в токенах пользователей
+	ClientID string `json:"client_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateAccessToken создает JWT access token
-func GenerateAccessToken(userID uuid.UUID, secret string, expiry time.Duration) (string, error) {
+// Confirmation claim cnf (RFC 7800) привязанного токена
+type Confirmation struct {
+	// CertThumbprint SHA-256 отпечаток клиентского сертификата в base64url без дополнения (RFC 8705)
+	CertThumbprint string `json:"x5t#S256,omitempty"`
+	// JWKThumbprint SHA-256 отпечаток открытого ключа DPoP в base64url без дополнения (RFC 9449)
+	JWKThumbprint string `json:"jkt,omitempty"`
+}
+
+// GenerateAccessToken создает JWT access token сессии sessionID.
+// tokenID записывается в jti и совпадает с идентификатором refresh токена той же пары.
+// clientID записывается в client_id токена, выпущенного сервисному клиенту.
+// Если confirmation не nil, токен привязывается к ключу клиента.
+func GenerateAccessToken(userID uuid.UUID, sessionID int, tokenID, clientID string, confirmation *Confirmation, secret string, expiry time.Duration) (string, error) {
 	claims := TokenClaims{
-		UserID: userID.String(),
+		UserID:       userID.String(),
+		SessionID:    sessionID,
+		Confirmation: confirmation,
+		ClientID:     clientID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+	// Идентификатор ключа позволяет проверять токены во время ротации ключей
+	token.Header["kid"] = KeyID(secret)
 	
 	// Создаем подпись с использованием SHA512
 	signedToken, err := token.SignedString([]byte(secret))
@@ -46,16 +83,31 @@ func GenerateRefreshToken() (string, string) {
 	return refreshToken, refreshTokenID
 }
 
-// ValidateAccessToken проверяет валидность access токена
-func ValidateAccessToken(tokenString, secret string) (*TokenClaims, error) {
+// ValidateAccessToken проверяет валидность access токена.
+// Первый из secrets — текущий ключ подписи, остальные — прежние ключи, действующие во время ротации.
+// Токен с идентификатором ключа проверяется только этим ключом, токен без него — текущим ключом.
+func ValidateAccessToken(tokenString string, secrets ...string) (*TokenClaims, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("не задан ключ подписи")
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
 		// Проверяем, что алгоритм подписи токена - HS512
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || token.Method.Alg() != "HS512" {
 			return nil, fmt.Errorf("неожиданный алгоритм подписи: %v", token.Header["alg"])
 		}
 		
-		// Возвращаем секретный ключ для проверки подписи
-		return []byte(secret), nil
+		// Выбираем ключ проверки подписи по его идентификатору
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return []byte(secrets[0]), nil
+		}
+		for _, secret := range secrets {
+			if KeyID(secret) == kid {
+				return []byte(secret), nil
+			}
+		}
+		return nil, fmt.Errorf("неизвестный ключ подписи: %s", kid)
 	})
 
 	if err != nil {
@@ -70,6 +122,22 @@ func ValidateAccessToken(tokenString, secret string) (*TokenClaims, error) {
 	return claims, nil
 }
 
+// KeyID возвращает идентификатор ключа подписи, записываемый в заголовок kid.
+// Идентификатор получен хешированием и не раскрывает ключ.
+func KeyID(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:8])
+}
+
+// GenerateSecret создает случайный ключ подписи длиной 512 бит
+func GenerateSecret() (string, error) {
+	secret := make([]byte, 64)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("ошибка генерации ключа: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
 // HashRefreshToken создает bcrypt хеш refresh токена
 func HashRefreshToken(refreshToken string) string {
 	// Используем SHA-512 для хеширования refresh токена
@@ -0,0 +1,198 @@
+// Package webhooksig реализует подпись и проверку webhook по спецификации Standard Webhooks.
+//
+// Каждый запрос содержит заголовки:
+//
+//	webhook-id:        уникальный идентификатор сообщения (одинаков для всех повторных попыток)
+//	webhook-timestamp: время отправки в секундах Unix
+//	webhook-signature: список подписей через пробел в формате "v1,<base64>"
+//
+// Подпись - это HMAC-SHA256 от строки "<webhook-id>.<webhook-timestamp>.<тело запроса>".
+// Во время ротации секретов отправитель подписывает сообщение всеми активными
+// секретами, а получателю достаточно совпадения любой из подписей.
+//
+// Пример проверки на стороне получателя:
+//
+//	verifier, err := webhooksig.NewVerifier([]string{os.Getenv("WEBHOOK_SECRET")}, webhooksig.DefaultTolerance)
+//	...
+//	body, _ := io.ReadAll(r.Body)
+//	if err := verifier.Verify(body, r.Header); err != nil {
+//		http.Error(w, "invalid signature", http.StatusUnauthorized)
+//		return
+//	}
+//
+// Для защиты от повторного воспроизведения Verify отклоняет сообщения,
+// время которых отличается от текущего больше чем на tolerance. Получателю
+// также рекомендуется хранить обработанные webhook-id хотя бы в течение tolerance
+// и игнорировать повторы.
+package webhooksig
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Заголовки подписанного webhook
+const (
+	HeaderID        = "webhook-id"
+	HeaderTimestamp = "webhook-timestamp"
+	HeaderSignature = "webhook-signature"
+)
+
+// DefaultTolerance допустимое расхождение времени отправки и проверки
+const DefaultTolerance = 5 * time.Minute
+
+// secretPrefix префикс секрета в формате Standard Webhooks
+const secretPrefix = "whsec_"
+
+// signatureVersion версия схемы подписи
+const signatureVersion = "v1"
+
+// Ошибки проверки подписи
+var (
+	ErrMissingHeaders   = errors.New("webhooksig: отсутствуют заголовки подписи")
+	ErrInvalidTimestamp = errors.New("webhooksig: некорректный заголовок webhook-timestamp")
+	ErrTimestampTooOld  = errors.New("webhooksig: сообщение слишком старое")
+	ErrTimestampTooNew  = errors.New("webhooksig: время сообщения в будущем")
+	ErrNoMatch          = errors.New("webhooksig: ни одна подпись не совпала")
+)
+
+// Signer подписывает webhook всеми активными секретами
+type Signer struct {
+	keys [][]byte
+}
+
+// NewSigner создает новый экземпляр Signer.
+// Секрет задается в формате "whsec_<base64>" или как произвольная строка.
+func NewSigner(secrets []string) (*Signer, error) {
+	keys, err := decodeSecrets(secrets)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{keys: keys}, nil
+}
+
+// Sign возвращает значение заголовка webhook-signature
+func (s *Signer) Sign(msgID string, timestamp time.Time, payload []byte) string {
+	signatures := make([]string, 0, len(s.keys))
+	for _, key := range s.keys {
+		signatures = append(signatures, signatureVersion+","+sign(key, msgID, timestamp.Unix(), payload))
+	}
+	return strings.Join(signatures, " ")
+}
+
+// SetHeaders подписывает payload и устанавливает заголовки подписи в запрос
+func (s *Signer) SetHeaders(header http.Header, msgID string, timestamp time.Time, payload []byte) {
+	header.Set(HeaderID, msgID)
+	header.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+	if len(s.keys) > 0 {
+		header.Set(HeaderSignature, s.Sign(msgID, timestamp, payload))
+	}
+}
+
+// Verifier проверяет подписи webhook
+type Verifier struct {
+	keys      [][]byte
+	tolerance time.Duration
+}
+
+// NewVerifier создает новый экземпляр Verifier.
+// Во время ротации передайте и старый, и новый секрет.
+func NewVerifier(secrets []string, tolerance time.Duration) (*Verifier, error) {
+	keys, err := decodeSecrets(secrets)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("webhooksig: не задан ни один секрет")
+	}
+	return &Verifier{keys: keys, tolerance: tolerance}, nil
+}
+
+// Verify проверяет время отправки и подпись сообщения
+func (v *Verifier) Verify(payload []byte, header http.Header) error {
+	msgID := header.Get(HeaderID)
+	timestampStr := header.Get(HeaderTimestamp)
+	signatureHeader := header.Get(HeaderSignature)
+	if msgID == "" || timestampStr == "" || signatureHeader == "" {
+		return ErrMissingHeaders
+	}
+
+	timestamp, err := strconv.ParseInt(timestampStr, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	now := time.Now()
+	sent := time.Unix(timestamp, 0)
+	if v.tolerance > 0 {
+		if now.Sub(sent) > v.tolerance {
+			return ErrTimestampTooOld
+		}
+		if sent.Sub(now) > v.tolerance {
+			return ErrTimestampTooNew
+		}
+	}
+
+	for _, key := range v.keys {
+		expected := []byte(sign(key, msgID, timestamp, payload))
+		for _, candidate := range strings.Fields(signatureHeader) {
+			version, signature, ok := strings.Cut(candidate, ",")
+			if !ok || version != signatureVersion {
+				continue
+			}
+			if hmac.Equal([]byte(signature), expected) {
+				return nil
+			}
+		}
+	}
+
+	return ErrNoMatch
+}
+
+// GenerateSecret создает новый случайный секрет в формате "whsec_<base64>"
+func GenerateSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("webhooksig: не удалось сгенерировать секрет: %w", err)
+	}
+	return secretPrefix + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// sign вычисляет подпись v1 в base64
+func sign(key []byte, msgID string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msgID))
+	mac.Write([]byte("."))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// decodeSecrets преобразует секреты в ключи HMAC
+func decodeSecrets(secrets []string) ([][]byte, error) {
+	keys := make([][]byte, 0, len(secrets))
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		if encoded, ok := strings.CutPrefix(secret, secretPrefix); ok {
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("webhooksig: некорректный секрет в формате whsec_: %w", err)
+			}
+			keys = append(keys, key)
+			continue
+		}
+		keys = append(keys, []byte(secret))
+	}
+	return keys, nil
+}
@@ -0,0 +1,187 @@
+package webhooksig_test
+
+import (
+	"auth-service/pkg/webhooksig"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Тестовый пример из спецификации Standard Webhooks
+const (
+	vectorSecret    = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+	vectorMsgID     = "msg_p5jXN8AQM9LWM0D4loKWxJek"
+	vectorTimestamp = 1614265330
+	vectorPayload   = `{"test": 2432232314}`
+	vectorSignature = "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE="
+)
+
+// signedHeader возвращает заголовки сообщения, подписанного секретами secrets в момент timestamp
+func signedHeader(t *testing.T, secrets []string, msgID string, timestamp time.Time, payload string) http.Header {
+	t.Helper()
+	signer, err := webhooksig.NewSigner(secrets)
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	header := http.Header{}
+	signer.SetHeaders(header, msgID, timestamp, []byte(payload))
+	return header
+}
+
+func TestSignVector(t *testing.T) {
+	signer, err := webhooksig.NewSigner([]string{vectorSecret})
+	if err != nil {
+		t.Fatalf("NewSigner: %v", err)
+	}
+	if got := signer.Sign(vectorMsgID, time.Unix(vectorTimestamp, 0), []byte(vectorPayload)); got != vectorSignature {
+		t.Fatalf("Sign = %s, ожидалась %s", got, vectorSignature)
+	}
+
+	header := signedHeader(t, []string{vectorSecret}, vectorMsgID, time.Unix(vectorTimestamp, 0), vectorPayload)
+	want := http.Header{
+		"Webhook-Id":        {vectorMsgID},
+		"Webhook-Timestamp": {strconv.Itoa(vectorTimestamp)},
+		"Webhook-Signature": {vectorSignature},
+	}
+	for name, values := range want {
+		if got := header.Values(name); len(got) != 1 || got[0] != values[0] {
+			t.Fatalf("заголовок %s = %v, ожидалось %v", name, got, values)
+		}
+	}
+}
+
+func TestVerifyVector(t *testing.T) {
+	// Время примера давно прошло, поэтому проверка времени отключена
+	verifier, err := webhooksig.NewVerifier([]string{vectorSecret}, 0)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		payload   string
+		msgID     string
+		signature string
+		want      error
+	}{
+		{name: "подпись из спецификации", payload: vectorPayload, msgID: vectorMsgID, signature: vectorSignature},
+		{name: "среди нескольких подписей", payload: vectorPayload, msgID: vectorMsgID, signature: "v1,Zm9v " + vectorSignature},
+		{name: "подпись неизвестной версии", payload: vectorPayload, msgID: vectorMsgID, signature: "v2," + strings.TrimPrefix(vectorSignature, "v1,"), want: webhooksig.ErrNoMatch},
+		{name: "подпись без версии", payload: vectorPayload, msgID: vectorMsgID, signature: strings.TrimPrefix(vectorSignature, "v1,"), want: webhooksig.ErrNoMatch},
+		{name: "измененное тело", payload: `{"test": 2432232315}`, msgID: vectorMsgID, signature: vectorSignature, want: webhooksig.ErrNoMatch},
+		{name: "другой идентификатор", payload: vectorPayload, msgID: "msg_other", signature: vectorSignature, want: webhooksig.ErrNoMatch},
+		{name: "без подписи", payload: vectorPayload, msgID: vectorMsgID, want: webhooksig.ErrMissingHeaders},
+		{name: "без идентификатора", payload: vectorPayload, signature: vectorSignature, want: webhooksig.ErrMissingHeaders},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set(webhooksig.HeaderID, tt.msgID)
+			header.Set(webhooksig.HeaderTimestamp, strconv.Itoa(vectorTimestamp))
+			header.Set(webhooksig.HeaderSignature, tt.signature)
+			if err := verifier.Verify([]byte(tt.payload), header); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, ожидалось %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyTimestamp(t *testing.T) {
+	verifier, err := webhooksig.NewVerifier([]string{vectorSecret}, webhooksig.DefaultTolerance)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		want      error
+	}{
+		{name: "текущее время", timestamp: now},
+		{name: "в пределах допуска в прошлом", timestamp: now.Add(-webhooksig.DefaultTolerance + time.Minute)},
+		{name: "в пределах допуска в будущем", timestamp: now.Add(webhooksig.DefaultTolerance - time.Minute)},
+		{name: "старше допуска", timestamp: now.Add(-webhooksig.DefaultTolerance - time.Minute), want: webhooksig.ErrTimestampTooOld},
+		{name: "позже допуска", timestamp: now.Add(webhooksig.DefaultTolerance + time.Minute), want: webhooksig.ErrTimestampTooNew},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := signedHeader(t, []string{vectorSecret}, vectorMsgID, tt.timestamp, vectorPayload)
+			if err := verifier.Verify([]byte(vectorPayload), header); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, ожидалось %v", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("некорректное время", func(t *testing.T) {
+		header := signedHeader(t, []string{vectorSecret}, vectorMsgID, now, vectorPayload)
+		header.Set(webhooksig.HeaderTimestamp, "yesterday")
+		if err := verifier.Verify([]byte(vectorPayload), header); !errors.Is(err, webhooksig.ErrInvalidTimestamp) {
+			t.Fatalf("Verify = %v, ожидалось %v", err, webhooksig.ErrInvalidTimestamp)
+		}
+	})
+}
+
+func TestSecretRotation(t *testing.T) {
+	oldSecret, err := webhooksig.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	newSecret, err := webhooksig.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret: %v", err)
+	}
+	if !strings.HasPrefix(newSecret, "whsec_") || newSecret == oldSecret {
+		t.Fatalf("GenerateSecret вернул %s после %s", newSecret, oldSecret)
+	}
+
+	tests := []struct {
+		name     string
+		signer   []string
+		verifier []string
+		want     error
+	}{
+		// Во время ротации отправитель подписывает обоими секретами
+		{name: "получатель со старым секретом", signer: []string{newSecret, oldSecret}, verifier: []string{oldSecret}},
+		{name: "получатель с новым секретом", signer: []string{newSecret, oldSecret}, verifier: []string{newSecret}},
+		{name: "получатель с обоими секретами", signer: []string{newSecret}, verifier: []string{oldSecret, newSecret}},
+		{name: "после удаления старого секрета", signer: []string{newSecret}, verifier: []string{oldSecret}, want: webhooksig.ErrNoMatch},
+		{name: "секрет в виде строки", signer: []string{"plain-secret"}, verifier: []string{"plain-secret"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := webhooksig.NewVerifier(tt.verifier, webhooksig.DefaultTolerance)
+			if err != nil {
+				t.Fatalf("NewVerifier: %v", err)
+			}
+			header := signedHeader(t, tt.signer, vectorMsgID, time.Now(), vectorPayload)
+			if got := len(strings.Fields(header.Get(webhooksig.HeaderSignature))); got != len(tt.signer) {
+				t.Fatalf("подписей %d, ожидалось %d", got, len(tt.signer))
+			}
+			if err := verifier.Verify([]byte(vectorPayload), header); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, ожидалось %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidSecrets(t *testing.T) {
+	if _, err := webhooksig.NewSigner([]string{"whsec_не-base64"}); err == nil {
+		t.Fatal("NewSigner: принят некорректный секрет whsec_")
+	}
+	if _, err := webhooksig.NewVerifier([]string{"", ""}, webhooksig.DefaultTolerance); err == nil {
+		t.Fatal("NewVerifier: принят пустой список секретов")
+	}
+
+	// Без секретов сообщение отправляется без подписи
+	header := signedHeader(t, nil, vectorMsgID, time.Now(), vectorPayload)
+	if header.Get(webhooksig.HeaderSignature) != "" || header.Get(webhooksig.HeaderID) != vectorMsgID {
+		t.Fatalf("заголовки без секретов: %v", header)
+	}
+}